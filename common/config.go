@@ -5,6 +5,7 @@ import (
 	"os"
 	"path/filepath"
 	"strings"
+	"time"
 
 	yaml "gopkg.in/yaml.v2"
 
@@ -20,6 +21,8 @@ const (
 
 	DefaultIRODSPort          int    = 1247
 	DefaultIRODSSharedDirName string = "public"
+
+	DefaultContentIndexRefreshInterval string = "1h"
 )
 
 // Config holds the parameters list which can be configured
@@ -39,6 +42,16 @@ type Config struct {
 	IRODSSharedDirName string `yaml:"irods_shared_dir_name,omitempty" json:"irods_shared_dir_name,omitempty" envconfig:"IRODS_MCP_SVR_IRODS_SHARED_DIR_NAME"`
 	IRODSWebDAVURL     string `yaml:"irods_webdav_url,omitempty" json:"irods_webdav_url,omitempty" envconfig:"IRODS_MCP_SVR_IRODS_WEBDAV_URL"`
 
+	// Content index config, indexing is disabled if the dir is empty
+	ContentIndexDir             string   `yaml:"content_index_dir,omitempty" json:"content_index_dir,omitempty" envconfig:"IRODS_MCP_SVR_CONTENT_INDEX_DIR"`
+	ContentIndexPaths           []string `yaml:"content_index_paths,omitempty" json:"content_index_paths,omitempty" envconfig:"IRODS_MCP_SVR_CONTENT_INDEX_PATHS"`
+	ContentIndexRefreshInterval string   `yaml:"content_index_refresh_interval,omitempty" json:"content_index_refresh_interval,omitempty" envconfig:"IRODS_MCP_SVR_CONTENT_INDEX_REFRESH_INTERVAL"`
+	ContentIndexNotebooks       bool     `yaml:"content_index_notebooks,omitempty" json:"content_index_notebooks,omitempty" envconfig:"IRODS_MCP_SVR_CONTENT_INDEX_NOTEBOOKS"`
+	ContentIndexPDFs            bool     `yaml:"content_index_pdfs,omitempty" json:"content_index_pdfs,omitempty" envconfig:"IRODS_MCP_SVR_CONTENT_INDEX_PDFS"`
+
+	// GenQuery tool config, columns the tool may select or filter on, the default set is used if empty
+	GenQueryColumns []string `yaml:"genquery_columns,omitempty" json:"genquery_columns,omitempty" envconfig:"IRODS_MCP_SVR_GENQUERY_COLUMNS"`
+
 	// OAuth2 / OIDC config
 	OIDCDiscoveryURL   string `yaml:"oidc_discovery_url" json:"oidc_discovery_url" envconfig:"IRODS_MCP_SVR_OIDC_DISCOVERY_URL"`
 	OAuth2ClientID     string `yaml:"oauth2_client_id" json:"oauth2_client_id" envconfig:"IRODS_MCP_SVR_OAUTH2_CLIENT_ID"`
@@ -61,6 +74,14 @@ func NewDefaultConfig() *Config {
 		IRODSSharedDirName: DefaultIRODSSharedDirName, // use default
 		IRODSWebDAVURL:     "",
 
+		ContentIndexDir:             "", // disabled by default
+		ContentIndexPaths:           []string{},
+		ContentIndexRefreshInterval: DefaultContentIndexRefreshInterval,
+		ContentIndexNotebooks:       false,
+		ContentIndexPDFs:            false,
+
+		GenQueryColumns: []string{}, // use default
+
 		OIDCDiscoveryURL:   "",
 		OAuth2ClientID:     "",
 		OAuth2ClientSecret: "",
@@ -207,6 +228,28 @@ func (config *Config) GetPublicServiceURL() string {
 	return config.GetServiceURL()
 }
 
+func (config *Config) IsContentIndexEnabled() bool {
+	return len(config.ContentIndexDir) > 0
+}
+
+// GetContentIndexRefreshInterval returns the interval between content index refreshes
+func (config *Config) GetContentIndexRefreshInterval() time.Duration {
+	interval, err := time.ParseDuration(config.ContentIndexRefreshInterval)
+	if err != nil || interval <= 0 {
+		interval, _ = time.ParseDuration(DefaultContentIndexRefreshInterval)
+	}
+
+	return interval
+}
+
+// MakeContentIndexDir makes a content index dir required
+func (config *Config) MakeContentIndexDir() error {
+	logger := log.WithFields(log.Fields{})
+
+	logger.Debugf("making content index dir %q", config.ContentIndexDir)
+	return config.makeDir(config.ContentIndexDir)
+}
+
 func (config *Config) IsOAuth2Enabled() bool {
 	return len(config.OIDCDiscoveryURL) > 0 && len(config.OAuth2ClientID) > 0 && len(config.OAuth2ClientSecret) > 0
 }
@@ -274,6 +317,13 @@ func (config *Config) Validate() error {
 		}
 	}
 
+	if config.IsContentIndexEnabled() && len(config.ContentIndexRefreshInterval) > 0 {
+		_, err := time.ParseDuration(config.ContentIndexRefreshInterval)
+		if err != nil {
+			return errors.Wrapf(err, "invalid content index refresh interval %q", config.ContentIndexRefreshInterval)
+		}
+	}
+
 	account := config.Config.ToIRODSAccount()
 	err := account.Validate()
 	if err != nil {
@@ -0,0 +1,464 @@
+package irods
+
+import (
+	"archive/tar"
+	"archive/zip"
+	"bufio"
+	"compress/gzip"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/cockroachdb/errors"
+	irodsclient_fs "github.com/cyverse/go-irodsclient/fs"
+	irodsclient_types "github.com/cyverse/go-irodsclient/irods/types"
+	"github.com/cyverse/irods-mcp-server/common"
+	irods_common "github.com/cyverse/irods-mcp-server/irods/common"
+	"github.com/cyverse/irods-mcp-server/irods/model"
+	"github.com/google/jsonschema-go/jsonschema"
+	"github.com/modelcontextprotocol/go-sdk/mcp"
+)
+
+const (
+	ArchiveCreateName = irods_common.IRODSAPIPrefix + "archive_create"
+)
+
+type ArchiveCreateInputArgs struct {
+	SourcePath   string   `json:"source_path"`
+	Path         string   `json:"path"`
+	Format       string   `json:"format,omitempty"`
+	Include      []string `json:"include,omitempty"`
+	Exclude      []string `json:"exclude,omitempty"`
+	Overwrite    bool     `json:"overwrite,omitempty"`
+	MaxTotalSize int64    `json:"max_total_size,omitempty"`
+	MaxFiles     int      `json:"max_files,omitempty"`
+}
+
+type ArchiveCreate struct {
+	mcpServer *IRODSMCPServer
+	config    *common.Config
+}
+
+func NewArchiveCreate(svr *IRODSMCPServer) ToolAPI {
+	return &ArchiveCreate{
+		mcpServer: svr,
+		config:    svr.GetConfig(),
+	}
+}
+
+func (t *ArchiveCreate) GetName() string {
+	return ArchiveCreateName
+}
+
+func (t *ArchiveCreate) GetDescription() string {
+	return `Create an archive file (data-object) in tar, tar.gz, or zip format from a directory (collection).
+	The specified paths must be iRODS paths. The archive is streamed directly into iRODS without a local temporary copy.
+	Returns the number of archived files and the size of the archive in JSON format.`
+}
+
+func (t *ArchiveCreate) GetTool() *mcp.Tool {
+	return &mcp.Tool{
+		Name:        t.GetName(),
+		Description: t.GetDescription(),
+		InputSchema: &jsonschema.Schema{
+			Type: "object",
+			Properties: map[string]*jsonschema.Schema{
+				"source_path": {
+					Type:        "string",
+					Description: "The path to the directory (collection) to archive.",
+				},
+				"path": {
+					Type:        "string",
+					Description: "The path to the archive file (data-object) to create.",
+				},
+				"format": {
+					Type:        "string",
+					Enum:        []interface{}{string(irods_common.ArchiveFormatTar), string(irods_common.ArchiveFormatTarGz), string(irods_common.ArchiveFormatZip)},
+					Description: "The archive format. Default is detected from the extension of 'path', such as '.tar', '.tar.gz', '.tgz', or '.zip'.",
+				},
+				"include": {
+					Type:        "array",
+					Items:       &jsonschema.Schema{Type: "string"},
+					Description: "Unix wildcard patterns of files to archive, such as '*.csv'. Patterns without '/' match file names, others match paths relative to the source. Default is all files.",
+				},
+				"exclude": {
+					Type:        "array",
+					Items:       &jsonschema.Schema{Type: "string"},
+					Description: "Unix wildcard patterns of files and directories to leave out.",
+				},
+				"overwrite": {
+					Type:        "boolean",
+					Description: "Set to true to overwrite the archive file if it already exists. Default is false.",
+					Default:     json.RawMessage("false"),
+				},
+				"max_total_size": {
+					Type:        "number",
+					Description: fmt.Sprintf("The maximum total size of files to archive in bytes. Maximum is %d bytes. Default is %d bytes.", irods_common.ArchiveMaxTotalSize, irods_common.ArchiveMaxTotalSizeDefault),
+					Default:     json.RawMessage(fmt.Sprintf("%d", irods_common.ArchiveMaxTotalSizeDefault)),
+				},
+				"max_files": {
+					Type:        "number",
+					Description: fmt.Sprintf("The maximum number of files to archive. Maximum is %d. Default is %d.", irods_common.ArchiveMaxFiles, irods_common.ArchiveMaxFilesDefault),
+					Default:     json.RawMessage(fmt.Sprintf("%d", irods_common.ArchiveMaxFilesDefault)),
+				},
+			},
+			Required: []string{"source_path", "path"},
+		},
+	}
+}
+
+func (t *ArchiveCreate) GetHandler() mcp.ToolHandler {
+	return t.Handler
+}
+
+func (t *ArchiveCreate) GetAccessiblePaths(authValue *common.AuthValue) []string {
+	account, err := t.mcpServer.GetIRODSAccountFromAuthValue(authValue)
+	if err != nil {
+		return []string{}
+	}
+
+	homePath := irods_common.GetHomePath(t.config, account)
+	sharedPath := irods_common.GetSharedPath(t.config, account)
+
+	paths := []string{
+		sharedPath + "/*",
+	}
+
+	if !account.IsAnonymousUser() {
+		paths = append(paths, homePath)
+		paths = append(paths, homePath+"/*")
+	}
+
+	return paths
+}
+
+func (t *ArchiveCreate) Handler(ctx context.Context, request *mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	// arguments
+	args := ArchiveCreateInputArgs{}
+	err := irods_common.MarshalInputArguments(t.GetTool(), request, &args)
+	if err != nil {
+		outputErr := errors.Wrapf(err, "failed to marshal input arguments")
+		return irods_common.ToolErrorResult(outputErr), nil
+	}
+
+	format := irods_common.ArchiveFormat(args.Format)
+	if len(format) == 0 {
+		format = irods_common.GetArchiveFormatFromPath(args.Path)
+		if len(format) == 0 {
+			outputErr := errors.Newf("failed to detect archive format from path %q, format must be given", args.Path)
+			return irods_common.ToolErrorResult(outputErr), nil
+		}
+	}
+
+	// auth
+	authValue, err := common.GetAuthValue(ctx)
+	if err != nil {
+		outputErr := errors.Wrapf(err, "failed to get auth value")
+		return irods_common.ToolErrorResult(outputErr), nil
+	}
+
+	// make a irods filesystem client
+	fs, err := t.mcpServer.GetIRODSFSClientFromAuthValue(&authValue)
+	if err != nil {
+		outputErr := errors.Wrapf(err, "failed to create a irods fs client")
+		return irods_common.ToolErrorResult(outputErr), nil
+	}
+
+	irodsSourcePath := irods_common.MakeIRODSPath(t.config, fs.GetAccount(), args.SourcePath)
+	irodsPath := irods_common.MakeIRODSPath(t.config, fs.GetAccount(), args.Path)
+
+	// check permission
+	if !irods_common.IsAccessAllowed(irodsSourcePath, t.GetAccessiblePaths(&authValue)) {
+		outputErr := errors.Newf("%q request is not permitted for path %q", t.GetName(), irodsSourcePath)
+		return irods_common.ToolErrorResult(outputErr), nil
+	}
+	if !irods_common.IsAccessAllowed(irodsPath, t.GetAccessiblePaths(&authValue)) {
+		outputErr := errors.Newf("%q request is not permitted for path %q", t.GetName(), irodsPath)
+		return irods_common.ToolErrorResult(outputErr), nil
+	}
+
+	sourceEntry, err := fs.Stat(irodsSourcePath)
+	if err != nil {
+		outputErr := errors.Wrapf(err, "failed to stat directory info for %q", irodsSourcePath)
+		return irods_common.ToolErrorResult(outputErr), nil
+	}
+
+	if !sourceEntry.IsDir() {
+		outputErr := errors.Newf("path %q is not a directory (collection)", irodsSourcePath)
+		return irods_common.ToolErrorResult(outputErr), nil
+	}
+
+	destEntry, err := fs.Stat(irodsPath)
+	if err != nil {
+		if !irodsclient_types.IsFileNotFoundError(err) {
+			outputErr := errors.Wrapf(err, "failed to stat file info for %q", irodsPath)
+			return irods_common.ToolErrorResult(outputErr), nil
+		}
+	} else {
+		if destEntry.IsDir() {
+			outputErr := errors.Newf("path %q is a directory (collection)", irodsPath)
+			return irods_common.ToolErrorResult(outputErr), nil
+		}
+
+		if !args.Overwrite {
+			outputErr := errors.Newf("file %q already exists", irodsPath)
+			return irods_common.ToolErrorResult(outputErr), nil
+		}
+	}
+
+	progress := irods_common.NewProgressReporter(ctx, request)
+
+	content, err := t.createArchive(ctx, fs, sourceEntry, irodsPath, format, progress, &args)
+	if err != nil {
+		outputErr := errors.Wrapf(err, "failed to create archive %q from %q", irodsPath, irodsSourcePath)
+		return irods_common.ToolErrorResult(outputErr), nil
+	}
+
+	return irods_common.ToolJSONResult(*content)
+}
+
+func (t *ArchiveCreate) scanSource(ctx context.Context, fs *irodsclient_fs.FileSystem, sourcePath string, archivePath string, args *ArchiveCreateInputArgs) ([]*irodsclient_fs.Entry, error) {
+	maxTotalSize := args.MaxTotalSize
+	if maxTotalSize <= 0 || maxTotalSize > irods_common.ArchiveMaxTotalSize {
+		maxTotalSize = irods_common.ArchiveMaxTotalSize
+	}
+
+	maxFiles := args.MaxFiles
+	if maxFiles <= 0 || maxFiles > irods_common.ArchiveMaxFiles {
+		maxFiles = irods_common.ArchiveMaxFiles
+	}
+
+	entries := []*irodsclient_fs.Entry{}
+	totalSize := int64(0)
+	fileNum := 0
+
+	err := irods_common.WalkCollection(fs, sourcePath, 0, func(entry *irodsclient_fs.Entry, depth int) error {
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+
+		relPath := irods_common.GetRelativeIRODSPath(sourcePath, entry.Path)
+		if entry.IsDir() {
+			if !irods_common.MatchGlobs(relPath, nil, args.Exclude) {
+				return irods_common.ErrSkipDir
+			}
+
+			entries = append(entries, entry)
+			return nil
+		}
+
+		// do not archive the archive itself
+		if entry.Path == archivePath || !irods_common.MatchGlobs(relPath, args.Include, args.Exclude) {
+			return nil
+		}
+
+		fileNum++
+		if fileNum > maxFiles {
+			return errors.Newf("too many files to archive, maximum is %d", maxFiles)
+		}
+
+		totalSize += entry.Size
+		if totalSize > maxTotalSize {
+			return errors.Newf("files to archive are too large, maximum total size is %d bytes", maxTotalSize)
+		}
+
+		entries = append(entries, entry)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return entries, nil
+}
+
+func (t *ArchiveCreate) createArchive(ctx context.Context, fs *irodsclient_fs.FileSystem, sourceEntry *irodsclient_fs.Entry, archivePath string, format irods_common.ArchiveFormat, progress *irods_common.ProgressReporter, args *ArchiveCreateInputArgs) (*model.ArchiveCreateOutput, error) {
+	entries, err := t.scanSource(ctx, fs, sourceEntry.Path, archivePath, args)
+	if err != nil {
+		return nil, err
+	}
+
+	archiveCreateOutput := &model.ArchiveCreateOutput{
+		Path:       archivePath,
+		SourcePath: sourceEntry.Path,
+		Format:     string(format),
+	}
+
+	for _, entry := range entries {
+		if !entry.IsDir() {
+			archiveCreateOutput.TotalSize += entry.Size
+		}
+	}
+
+	progress.SetTotal(float64(archiveCreateOutput.TotalSize))
+
+	// write to a temp file, an existing archive is replaced only when the new one is complete
+	tempPath := irods_common.MakeTempDataObjectPath(archivePath, "archive")
+	handle, err := fs.OpenFile(tempPath, "", string(irodsclient_types.FileOpenModeWriteTruncate))
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to open file %q", tempPath)
+	}
+
+	bufferedWriter := bufio.NewWriterSize(handle, irods_common.ArchiveIOBufferSize)
+	countingWriter := &irods_common.CountingWriter{Writer: bufferedWriter}
+
+	err = t.writeArchive(ctx, fs, sourceEntry.Path, entries, countingWriter, format, progress, archiveCreateOutput)
+	if err == nil {
+		err = bufferedWriter.Flush()
+	}
+
+	closeErr := handle.Close()
+	if err == nil && closeErr != nil {
+		err = errors.Wrapf(closeErr, "failed to close file %q", tempPath)
+	}
+
+	if err == nil {
+		if fs.ExistsFile(archivePath) {
+			err = irods_common.ReplaceDataObject(fs, tempPath, archivePath)
+		} else {
+			err = fs.RenameFileToFile(tempPath, archivePath)
+			if err != nil {
+				err = errors.Wrapf(err, "failed to rename file %q to %q", tempPath, archivePath)
+			}
+		}
+	}
+
+	if err != nil {
+		// remove the incomplete archive
+		fs.RemoveFile(tempPath, true) //nolint
+		return nil, err
+	}
+
+	archiveCreateOutput.Size = countingWriter.Count
+	progress.Done(fmt.Sprintf("created archive %q", archivePath))
+
+	return archiveCreateOutput, nil
+}
+
+func (t *ArchiveCreate) writeArchive(ctx context.Context, fs *irodsclient_fs.FileSystem, sourcePath string, entries []*irodsclient_fs.Entry, writer io.Writer, format irods_common.ArchiveFormat, progress *irods_common.ProgressReporter, output *model.ArchiveCreateOutput) error {
+	copyBuffer := make([]byte, irods_common.ArchiveIOBufferSize)
+
+	switch format {
+	case irods_common.ArchiveFormatZip:
+		zipWriter := zip.NewWriter(writer)
+		for _, entry := range entries {
+			if ctx.Err() != nil {
+				return ctx.Err()
+			}
+
+			relPath := irods_common.GetRelativeIRODSPath(sourcePath, entry.Path)
+			header := &zip.FileHeader{
+				Name:     relPath,
+				Method:   zip.Deflate,
+				Modified: entry.ModifyTime,
+			}
+
+			if entry.IsDir() {
+				header.Name += "/"
+				header.Method = zip.Store
+				header.SetMode(os.ModeDir | 0o755)
+			} else {
+				header.SetMode(0o644)
+			}
+
+			memberWriter, err := zipWriter.CreateHeader(header)
+			if err != nil {
+				return errors.Wrapf(err, "failed to write zip header for %q", relPath)
+			}
+
+			err = t.writeMember(fs, entry, memberWriter, copyBuffer, progress, output)
+			if err != nil {
+				return err
+			}
+		}
+
+		err := zipWriter.Close()
+		if err != nil {
+			return errors.Wrapf(err, "failed to finish zip archive")
+		}
+		return nil
+	case irods_common.ArchiveFormatTar, irods_common.ArchiveFormatTarGz:
+		var gzipWriter *gzip.Writer
+		if format == irods_common.ArchiveFormatTarGz {
+			gzipWriter = gzip.NewWriter(writer)
+			writer = gzipWriter
+		}
+
+		tarWriter := tar.NewWriter(writer)
+		for _, entry := range entries {
+			if ctx.Err() != nil {
+				return ctx.Err()
+			}
+
+			relPath := irods_common.GetRelativeIRODSPath(sourcePath, entry.Path)
+			header := &tar.Header{
+				Name:     relPath,
+				Mode:     0o644,
+				Size:     entry.Size,
+				ModTime:  entry.ModifyTime,
+				Typeflag: tar.TypeReg,
+			}
+
+			if entry.IsDir() {
+				header.Name += "/"
+				header.Mode = 0o755
+				header.Size = 0
+				header.Typeflag = tar.TypeDir
+			}
+
+			err := tarWriter.WriteHeader(header)
+			if err != nil {
+				return errors.Wrapf(err, "failed to write tar header for %q", relPath)
+			}
+
+			err = t.writeMember(fs, entry, tarWriter, copyBuffer, progress, output)
+			if err != nil {
+				return err
+			}
+		}
+
+		err := tarWriter.Close()
+		if err != nil {
+			return errors.Wrapf(err, "failed to finish tar archive")
+		}
+
+		if gzipWriter != nil {
+			err = gzipWriter.Close()
+			if err != nil {
+				return errors.Wrapf(err, "failed to finish gzip stream")
+			}
+		}
+		return nil
+	default:
+		return errors.Newf("unknown archive format %q", format)
+	}
+}
+
+func (t *ArchiveCreate) writeMember(fs *irodsclient_fs.FileSystem, entry *irodsclient_fs.Entry, writer io.Writer, copyBuffer []byte, progress *irods_common.ProgressReporter, output *model.ArchiveCreateOutput) error {
+	if entry.IsDir() {
+		output.Dirs++
+		return nil
+	}
+
+	handle, err := fs.OpenFile(entry.Path, "", "r")
+	if err != nil {
+		return errors.Wrapf(err, "failed to open file %q", entry.Path)
+	}
+	defer handle.Close()
+
+	// the size is recorded in the header, so copy exactly that many bytes
+	copied, err := io.CopyBuffer(writer, io.LimitReader(handle, entry.Size), copyBuffer)
+	if err != nil {
+		return errors.Wrapf(err, "failed to archive file %q", entry.Path)
+	}
+
+	if copied != entry.Size {
+		return errors.Newf("file %q changed while archiving, expected %d bytes but read %d bytes", entry.Path, entry.Size, copied)
+	}
+
+	output.Files++
+	progress.Add(float64(copied), fmt.Sprintf("archived %q", entry.Path))
+	return nil
+}
@@ -0,0 +1,487 @@
+package irods
+
+import (
+	"archive/tar"
+	"archive/zip"
+	"bufio"
+	"compress/gzip"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"path"
+
+	"github.com/cockroachdb/errors"
+	irodsclient_fs "github.com/cyverse/go-irodsclient/fs"
+	irodsclient_types "github.com/cyverse/go-irodsclient/irods/types"
+	"github.com/cyverse/irods-mcp-server/common"
+	irods_common "github.com/cyverse/irods-mcp-server/irods/common"
+	"github.com/cyverse/irods-mcp-server/irods/model"
+	"github.com/google/jsonschema-go/jsonschema"
+	"github.com/modelcontextprotocol/go-sdk/mcp"
+)
+
+const (
+	ArchiveExtractName = irods_common.IRODSAPIPrefix + "archive_extract"
+)
+
+type ArchiveExtractInputArgs struct {
+	Path            string   `json:"path"`
+	DestinationPath string   `json:"destination_path"`
+	Format          string   `json:"format,omitempty"`
+	Include         []string `json:"include,omitempty"`
+	Exclude         []string `json:"exclude,omitempty"`
+	Overwrite       bool     `json:"overwrite,omitempty"`
+	MaxTotalSize    int64    `json:"max_total_size,omitempty"`
+	MaxFiles        int      `json:"max_files,omitempty"`
+}
+
+type ArchiveExtract struct {
+	mcpServer *IRODSMCPServer
+	config    *common.Config
+}
+
+func NewArchiveExtract(svr *IRODSMCPServer) ToolAPI {
+	return &ArchiveExtract{
+		mcpServer: svr,
+		config:    svr.GetConfig(),
+	}
+}
+
+func (t *ArchiveExtract) GetName() string {
+	return ArchiveExtractName
+}
+
+func (t *ArchiveExtract) GetDescription() string {
+	return `Extract an archive file (data-object) in tar, tar.gz, or zip format into a directory (collection).
+	The specified paths must be iRODS paths. The archive is read directly from iRODS without a local temporary copy.
+	Returns the number of extracted files in JSON format.`
+}
+
+func (t *ArchiveExtract) GetTool() *mcp.Tool {
+	return &mcp.Tool{
+		Name:        t.GetName(),
+		Description: t.GetDescription(),
+		InputSchema: &jsonschema.Schema{
+			Type: "object",
+			Properties: map[string]*jsonschema.Schema{
+				"path": {
+					Type:        "string",
+					Description: "The path to the archive file (data-object) to extract.",
+				},
+				"destination_path": {
+					Type:        "string",
+					Description: "The path to the directory (collection) to extract into. It is created if it does not exist.",
+				},
+				"format": {
+					Type:        "string",
+					Enum:        []interface{}{string(irods_common.ArchiveFormatTar), string(irods_common.ArchiveFormatTarGz), string(irods_common.ArchiveFormatZip)},
+					Description: "The archive format. Default is detected from the extension of 'path', such as '.tar', '.tar.gz', '.tgz', or '.zip'.",
+				},
+				"include": {
+					Type:        "array",
+					Items:       &jsonschema.Schema{Type: "string"},
+					Description: "Unix wildcard patterns of archive members to extract, such as '*.csv'. Patterns without '/' match file names, others match member paths. Default is all members.",
+				},
+				"exclude": {
+					Type:        "array",
+					Items:       &jsonschema.Schema{Type: "string"},
+					Description: "Unix wildcard patterns of archive members to leave out.",
+				},
+				"overwrite": {
+					Type:        "boolean",
+					Description: "Set to true to overwrite existing files. Default is false, which skips them.",
+					Default:     json.RawMessage("false"),
+				},
+				"max_total_size": {
+					Type:        "number",
+					Description: fmt.Sprintf("The maximum total size of extracted files in bytes. Maximum is %d bytes. Default is %d bytes.", irods_common.ArchiveMaxTotalSize, irods_common.ArchiveMaxTotalSizeDefault),
+					Default:     json.RawMessage(fmt.Sprintf("%d", irods_common.ArchiveMaxTotalSizeDefault)),
+				},
+				"max_files": {
+					Type:        "number",
+					Description: fmt.Sprintf("The maximum number of files to extract. Maximum is %d. Default is %d.", irods_common.ArchiveMaxFiles, irods_common.ArchiveMaxFilesDefault),
+					Default:     json.RawMessage(fmt.Sprintf("%d", irods_common.ArchiveMaxFilesDefault)),
+				},
+			},
+			Required: []string{"path", "destination_path"},
+		},
+	}
+}
+
+func (t *ArchiveExtract) GetHandler() mcp.ToolHandler {
+	return t.Handler
+}
+
+func (t *ArchiveExtract) GetAccessiblePaths(authValue *common.AuthValue) []string {
+	account, err := t.mcpServer.GetIRODSAccountFromAuthValue(authValue)
+	if err != nil {
+		return []string{}
+	}
+
+	homePath := irods_common.GetHomePath(t.config, account)
+	sharedPath := irods_common.GetSharedPath(t.config, account)
+
+	paths := []string{
+		sharedPath + "/*",
+	}
+
+	if !account.IsAnonymousUser() {
+		paths = append(paths, homePath)
+		paths = append(paths, homePath+"/*")
+	}
+
+	return paths
+}
+
+func (t *ArchiveExtract) Handler(ctx context.Context, request *mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	// arguments
+	args := ArchiveExtractInputArgs{}
+	err := irods_common.MarshalInputArguments(t.GetTool(), request, &args)
+	if err != nil {
+		outputErr := errors.Wrapf(err, "failed to marshal input arguments")
+		return irods_common.ToolErrorResult(outputErr), nil
+	}
+
+	format := irods_common.ArchiveFormat(args.Format)
+	if len(format) == 0 {
+		format = irods_common.GetArchiveFormatFromPath(args.Path)
+		if len(format) == 0 {
+			outputErr := errors.Newf("failed to detect archive format from path %q, format must be given", args.Path)
+			return irods_common.ToolErrorResult(outputErr), nil
+		}
+	}
+
+	// auth
+	authValue, err := common.GetAuthValue(ctx)
+	if err != nil {
+		outputErr := errors.Wrapf(err, "failed to get auth value")
+		return irods_common.ToolErrorResult(outputErr), nil
+	}
+
+	// make a irods filesystem client
+	fs, err := t.mcpServer.GetIRODSFSClientFromAuthValue(&authValue)
+	if err != nil {
+		outputErr := errors.Wrapf(err, "failed to create a irods fs client")
+		return irods_common.ToolErrorResult(outputErr), nil
+	}
+
+	irodsPath := irods_common.MakeIRODSPath(t.config, fs.GetAccount(), args.Path)
+	irodsDestinationPath := irods_common.MakeIRODSPath(t.config, fs.GetAccount(), args.DestinationPath)
+
+	// check permission
+	if !irods_common.IsAccessAllowed(irodsPath, t.GetAccessiblePaths(&authValue)) {
+		outputErr := errors.Newf("%q request is not permitted for path %q", t.GetName(), irodsPath)
+		return irods_common.ToolErrorResult(outputErr), nil
+	}
+	if !irods_common.IsAccessAllowed(irodsDestinationPath, t.GetAccessiblePaths(&authValue)) {
+		outputErr := errors.Newf("%q request is not permitted for path %q", t.GetName(), irodsDestinationPath)
+		return irods_common.ToolErrorResult(outputErr), nil
+	}
+
+	entry, err := fs.Stat(irodsPath)
+	if err != nil {
+		outputErr := errors.Wrapf(err, "failed to stat file info for %q", irodsPath)
+		return irods_common.ToolErrorResult(outputErr), nil
+	}
+
+	if entry.IsDir() {
+		outputErr := errors.Newf("path %q is a directory (collection)", irodsPath)
+		return irods_common.ToolErrorResult(outputErr), nil
+	}
+
+	destEntry, err := fs.Stat(irodsDestinationPath)
+	if err != nil {
+		if !irodsclient_types.IsFileNotFoundError(err) {
+			outputErr := errors.Wrapf(err, "failed to stat directory info for %q", irodsDestinationPath)
+			return irods_common.ToolErrorResult(outputErr), nil
+		}
+	} else if !destEntry.IsDir() {
+		outputErr := errors.Newf("path %q is not a directory (collection)", irodsDestinationPath)
+		return irods_common.ToolErrorResult(outputErr), nil
+	}
+
+	progress := irods_common.NewProgressReporter(ctx, request)
+
+	content, err := t.extractArchive(ctx, fs, entry, irodsDestinationPath, format, progress, &args)
+	if err != nil {
+		outputErr := errors.Wrapf(err, "failed to extract archive %q into %q", irodsPath, irodsDestinationPath)
+		return irods_common.ToolErrorResult(outputErr), nil
+	}
+
+	return irods_common.ToolJSONResult(*content)
+}
+
+// archiveExtractor writes archive members into a directory (collection) within the limits
+type archiveExtractor struct {
+	fs           *irodsclient_fs.FileSystem
+	destPath     string
+	args         *ArchiveExtractInputArgs
+	maxTotalSize int64
+	maxFiles     int
+	madeDirs     map[string]bool
+	copyBuffer   []byte
+	progress     *irods_common.ProgressReporter
+	output       *model.ArchiveExtractOutput
+}
+
+func (t *ArchiveExtract) extractArchive(ctx context.Context, fs *irodsclient_fs.FileSystem, entry *irodsclient_fs.Entry, destPath string, format irods_common.ArchiveFormat, progress *irods_common.ProgressReporter, args *ArchiveExtractInputArgs) (*model.ArchiveExtractOutput, error) {
+	extractor := &archiveExtractor{
+		fs:           fs,
+		destPath:     destPath,
+		args:         args,
+		maxTotalSize: args.MaxTotalSize,
+		maxFiles:     args.MaxFiles,
+		madeDirs:     map[string]bool{},
+		copyBuffer:   make([]byte, irods_common.ArchiveIOBufferSize),
+		progress:     progress,
+		output: &model.ArchiveExtractOutput{
+			Path:            entry.Path,
+			DestinationPath: destPath,
+			Format:          string(format),
+		},
+	}
+
+	if extractor.maxTotalSize <= 0 || extractor.maxTotalSize > irods_common.ArchiveMaxTotalSize {
+		extractor.maxTotalSize = irods_common.ArchiveMaxTotalSize
+	}
+
+	if extractor.maxFiles <= 0 || extractor.maxFiles > irods_common.ArchiveMaxFiles {
+		extractor.maxFiles = irods_common.ArchiveMaxFiles
+	}
+
+	err := extractor.makeDir(destPath)
+	if err != nil {
+		return nil, err
+	}
+
+	handle, err := fs.OpenFile(entry.Path, "", "r")
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to open file %q", entry.Path)
+	}
+	defer handle.Close()
+
+	switch format {
+	case irods_common.ArchiveFormatZip:
+		err = extractor.extractZip(ctx, irods_common.NewBlockReaderAt(handle, entry.Size, irods_common.ArchiveIOBufferSize), entry.Size)
+	case irods_common.ArchiveFormatTar, irods_common.ArchiveFormatTarGz:
+		err = extractor.extractTar(ctx, bufio.NewReaderSize(handle, irods_common.ArchiveIOBufferSize), format == irods_common.ArchiveFormatTarGz)
+	default:
+		err = errors.Newf("unknown archive format %q", format)
+	}
+
+	if err != nil {
+		return nil, err
+	}
+
+	progress.Done(fmt.Sprintf("extracted archive %q", entry.Path))
+	return extractor.output, nil
+}
+
+func (extractor *archiveExtractor) extractZip(ctx context.Context, readerAt io.ReaderAt, size int64) error {
+	zipReader, err := zip.NewReader(readerAt, size)
+	if err != nil {
+		return errors.Wrapf(err, "failed to read zip archive")
+	}
+
+	// check limits before extracting anything
+	files := []*zip.File{}
+	totalSize := int64(0)
+	for _, file := range zipReader.File {
+		memberPath, err := irods_common.SanitizeArchiveMemberPath(file.Name)
+		if err != nil {
+			return err
+		}
+
+		if len(memberPath) == 0 {
+			// the extraction root
+			continue
+		}
+
+		if file.FileInfo().IsDir() || !file.Mode().IsRegular() || !irods_common.MatchGlobs(memberPath, extractor.args.Include, extractor.args.Exclude) {
+			files = append(files, file)
+			continue
+		}
+
+		totalSize += int64(file.UncompressedSize64)
+		if totalSize > extractor.maxTotalSize {
+			return errors.Newf("archive is too large to extract, maximum total size is %d bytes", extractor.maxTotalSize)
+		}
+
+		files = append(files, file)
+	}
+
+	extractor.progress.SetTotal(float64(totalSize))
+
+	for _, file := range files {
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+
+		memberPath, _ := irods_common.SanitizeArchiveMemberPath(file.Name)
+
+		if file.FileInfo().IsDir() {
+			if irods_common.MatchGlobs(memberPath, nil, extractor.args.Exclude) {
+				err = extractor.makeDir(path.Join(extractor.destPath, memberPath))
+				if err != nil {
+					return err
+				}
+				extractor.output.Dirs++
+			}
+			continue
+		}
+
+		if !file.Mode().IsRegular() {
+			// symlinks and other special files are not supported
+			extractor.output.SkippedPaths = append(extractor.output.SkippedPaths, memberPath)
+			continue
+		}
+
+		if !irods_common.MatchGlobs(memberPath, extractor.args.Include, extractor.args.Exclude) {
+			continue
+		}
+
+		memberReader, err := file.Open()
+		if err != nil {
+			return errors.Wrapf(err, "failed to open zip member %q", file.Name)
+		}
+
+		err = extractor.writeFile(memberPath, memberReader, int64(file.UncompressedSize64))
+		memberReader.Close()
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (extractor *archiveExtractor) extractTar(ctx context.Context, reader io.Reader, gzipped bool) error {
+	if gzipped {
+		gzipReader, err := gzip.NewReader(reader)
+		if err != nil {
+			return errors.Wrapf(err, "failed to read gzip stream")
+		}
+		defer gzipReader.Close()
+
+		reader = gzipReader
+	}
+
+	tarReader := tar.NewReader(reader)
+	for {
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+
+		header, err := tarReader.Next()
+		if err != nil {
+			if err == io.EOF {
+				return nil
+			}
+			return errors.Wrapf(err, "failed to read tar archive")
+		}
+
+		memberPath, err := irods_common.SanitizeArchiveMemberPath(header.Name)
+		if err != nil {
+			return err
+		}
+
+		if len(memberPath) == 0 {
+			// the extraction root
+			continue
+		}
+
+		switch header.Typeflag {
+		case tar.TypeDir:
+			if irods_common.MatchGlobs(memberPath, nil, extractor.args.Exclude) {
+				err = extractor.makeDir(path.Join(extractor.destPath, memberPath))
+				if err != nil {
+					return err
+				}
+				extractor.output.Dirs++
+			}
+		case tar.TypeReg:
+			if !irods_common.MatchGlobs(memberPath, extractor.args.Include, extractor.args.Exclude) {
+				continue
+			}
+
+			if extractor.output.TotalSize+header.Size > extractor.maxTotalSize {
+				return errors.Newf("archive is too large to extract, maximum total size is %d bytes", extractor.maxTotalSize)
+			}
+
+			err = extractor.writeFile(memberPath, tarReader, header.Size)
+			if err != nil {
+				return err
+			}
+		case tar.TypeXGlobalHeader:
+			// metadata only
+		default:
+			// symlinks and other special files are not supported
+			extractor.output.SkippedPaths = append(extractor.output.SkippedPaths, memberPath)
+		}
+	}
+}
+
+func (extractor *archiveExtractor) makeDir(dirPath string) error {
+	if extractor.madeDirs[dirPath] {
+		return nil
+	}
+
+	err := extractor.fs.MakeDir(dirPath, true)
+	if err != nil {
+		return errors.Wrapf(err, "failed to make directory (collection) %q", dirPath)
+	}
+
+	extractor.madeDirs[dirPath] = true
+	return nil
+}
+
+func (extractor *archiveExtractor) writeFile(memberPath string, reader io.Reader, size int64) error {
+	if extractor.output.Files >= extractor.maxFiles {
+		return errors.Newf("archive has too many files to extract, maximum is %d", extractor.maxFiles)
+	}
+
+	destFilePath := path.Join(extractor.destPath, memberPath)
+
+	err := extractor.makeDir(irods_common.GetIRODSPathDirname(destFilePath))
+	if err != nil {
+		return err
+	}
+
+	destEntry, err := extractor.fs.Stat(destFilePath)
+	if err == nil {
+		if destEntry.IsDir() || !extractor.args.Overwrite {
+			extractor.output.SkippedPaths = append(extractor.output.SkippedPaths, memberPath)
+			return nil
+		}
+	} else if !irodsclient_types.IsFileNotFoundError(err) {
+		return errors.Wrapf(err, "failed to stat file info for %q", destFilePath)
+	}
+
+	handle, err := extractor.fs.OpenFile(destFilePath, "", string(irodsclient_types.FileOpenModeWriteTruncate))
+	if err != nil {
+		return errors.Wrapf(err, "failed to open file %q", destFilePath)
+	}
+
+	bufferedWriter := bufio.NewWriterSize(handle, irods_common.ArchiveIOBufferSize)
+
+	// never write more than the declared size
+	written, err := io.CopyBuffer(bufferedWriter, io.LimitReader(reader, size), extractor.copyBuffer)
+	if err == nil {
+		err = bufferedWriter.Flush()
+	}
+
+	closeErr := handle.Close()
+	if err == nil {
+		err = closeErr
+	}
+
+	if err != nil {
+		return errors.Wrapf(err, "failed to extract archive member %q to %q", memberPath, destFilePath)
+	}
+
+	extractor.output.Files++
+	extractor.output.TotalSize += written
+	extractor.progress.Add(float64(written), fmt.Sprintf("extracted %q", memberPath))
+	return nil
+}
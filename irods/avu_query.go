@@ -0,0 +1,516 @@
+package irods
+
+import (
+	"path"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/cockroachdb/errors"
+	irodsclient_fs "github.com/cyverse/go-irodsclient/fs"
+	irodsclient_common "github.com/cyverse/go-irodsclient/irods/common"
+	irods_common "github.com/cyverse/irods-mcp-server/irods/common"
+	"github.com/cyverse/irods-mcp-server/irods/model"
+)
+
+const (
+	avuOperatorEqual        = "="
+	avuOperatorNotEqual     = "!="
+	avuOperatorLike         = "like"
+	avuOperatorNotLike      = "not like"
+	avuOperatorLess         = "<"
+	avuOperatorLessEqual    = "<="
+	avuOperatorGreater      = ">"
+	avuOperatorGreaterEqual = ">="
+	avuOperatorBetween      = "between"
+	avuOperatorIn           = "in"
+
+	avuMatchAll = "all"
+	avuMatchAny = "any"
+
+	avuEntityAll        = "all"
+	avuEntityDataObject = "data_object"
+	avuEntityCollection = "collection"
+	avuEntityResource   = "resource"
+	avuEntityUser       = "user"
+
+	maxAVUConditionDepth   = 8
+	maxAVUConditionMatches = 50000
+)
+
+var avuOperators = []interface{}{
+	avuOperatorEqual,
+	avuOperatorNotEqual,
+	avuOperatorLike,
+	avuOperatorNotLike,
+	avuOperatorLess,
+	avuOperatorLessEqual,
+	avuOperatorGreater,
+	avuOperatorGreaterEqual,
+	avuOperatorBetween,
+	avuOperatorIn,
+}
+
+// avuEntity is an entity that has AVUs, path is the resource name for resources and name#zone for users
+type avuEntity struct {
+	entityType string
+	path       string
+}
+
+type avuEntitySet map[avuEntity]struct{}
+
+// avuColumns are the catalog columns of an entity type
+type avuColumns struct {
+	attribute irodsclient_common.ICATColumnNumber
+	value     irodsclient_common.ICATColumnNumber
+	unit      irodsclient_common.ICATColumnNumber
+}
+
+var avuEntityColumns = map[string]avuColumns{
+	avuEntityDataObject: {
+		attribute: irodsclient_common.ICAT_COLUMN_META_DATA_ATTR_NAME,
+		value:     irodsclient_common.ICAT_COLUMN_META_DATA_ATTR_VALUE,
+		unit:      irodsclient_common.ICAT_COLUMN_META_DATA_ATTR_UNITS,
+	},
+	avuEntityCollection: {
+		attribute: irodsclient_common.ICAT_COLUMN_META_COLL_ATTR_NAME,
+		value:     irodsclient_common.ICAT_COLUMN_META_COLL_ATTR_VALUE,
+		unit:      irodsclient_common.ICAT_COLUMN_META_COLL_ATTR_UNITS,
+	},
+	avuEntityResource: {
+		attribute: irodsclient_common.ICAT_COLUMN_META_RESC_ATTR_NAME,
+		value:     irodsclient_common.ICAT_COLUMN_META_RESC_ATTR_VALUE,
+		unit:      irodsclient_common.ICAT_COLUMN_META_RESC_ATTR_UNITS,
+	},
+	avuEntityUser: {
+		attribute: irodsclient_common.ICAT_COLUMN_META_USER_ATTR_NAME,
+		value:     irodsclient_common.ICAT_COLUMN_META_USER_ATTR_VALUE,
+		unit:      irodsclient_common.ICAT_COLUMN_META_USER_ATTR_UNITS,
+	},
+}
+
+// avuEntityNameColumns are the catalog columns identifying an entity, in the order makeAVUEntity reads them
+var avuEntityNameColumns = map[string][]irodsclient_common.ICATColumnNumber{
+	avuEntityCollection: {irodsclient_common.ICAT_COLUMN_COLL_NAME},
+	avuEntityDataObject: {irodsclient_common.ICAT_COLUMN_COLL_NAME, irodsclient_common.ICAT_COLUMN_DATA_NAME},
+	avuEntityResource:   {irodsclient_common.ICAT_COLUMN_R_RESC_NAME},
+	avuEntityUser:       {irodsclient_common.ICAT_COLUMN_USER_NAME, irodsclient_common.ICAT_COLUMN_USER_ZONE},
+}
+
+// makeAVURootCondition returns a condition tree combining an attribute/value pair and conditions
+func makeAVURootCondition(attribute string, value string, match string, conditions []model.AVUCondition) *model.AVUCondition {
+	rootConditions := []model.AVUCondition{}
+	if len(attribute) > 0 {
+		rootConditions = append(rootConditions, model.AVUCondition{
+			Attribute: attribute,
+			Operator:  avuOperatorEqual,
+			Value:     value,
+		})
+	}
+	rootConditions = append(rootConditions, conditions...)
+
+	return &model.AVUCondition{
+		Match:      match,
+		Conditions: rootConditions,
+	}
+}
+
+// getAVUEntityTypes returns entity types to search for the given entity type argument
+func getAVUEntityTypes(entityType string) ([]string, error) {
+	switch strings.ToLower(entityType) {
+	case "", avuEntityAll:
+		return []string{avuEntityCollection, avuEntityDataObject}, nil
+	case avuEntityDataObject, "file":
+		return []string{avuEntityDataObject}, nil
+	case avuEntityCollection, "directory":
+		return []string{avuEntityCollection}, nil
+	default:
+		return nil, errors.Newf("unknown entity type %q", entityType)
+	}
+}
+
+// normalizeAVUCondition validates a condition tree and fills in defaults
+func normalizeAVUCondition(cond *model.AVUCondition, depth int) error {
+	if depth > maxAVUConditionDepth {
+		return errors.Newf("conditions are nested deeper than %d levels", maxAVUConditionDepth)
+	}
+
+	if len(cond.Conditions) > 0 {
+		if len(cond.Attribute) > 0 {
+			return errors.Newf("a condition cannot have both attribute %q and nested conditions", cond.Attribute)
+		}
+
+		cond.Match = strings.ToLower(strings.TrimSpace(cond.Match))
+		switch cond.Match {
+		case "", "and", avuMatchAll:
+			cond.Match = avuMatchAll
+		case "or", avuMatchAny:
+			cond.Match = avuMatchAny
+		default:
+			return errors.Newf("unknown match %q, must be %q or %q", cond.Match, avuMatchAll, avuMatchAny)
+		}
+
+		for idx := range cond.Conditions {
+			err := normalizeAVUCondition(&cond.Conditions[idx], depth+1)
+			if err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+
+	if len(cond.Attribute) == 0 {
+		return errors.Newf("attribute is empty")
+	}
+	cond.Match = ""
+
+	cond.Operator = strings.ToLower(strings.Join(strings.Fields(cond.Operator), " "))
+	switch cond.Operator {
+	case "", "==":
+		cond.Operator = avuOperatorEqual
+	case "<>":
+		cond.Operator = avuOperatorNotEqual
+	}
+
+	switch cond.Operator {
+	case avuOperatorEqual, avuOperatorNotEqual, avuOperatorLess, avuOperatorLessEqual, avuOperatorGreater, avuOperatorGreaterEqual:
+		if len(cond.Values) > 0 {
+			return errors.Newf("operator %q takes a single value, not values", cond.Operator)
+		}
+	case avuOperatorLike, avuOperatorNotLike:
+		if len(cond.Values) > 0 {
+			return errors.Newf("operator %q takes a single value, not values", cond.Operator)
+		}
+		if cond.Numeric {
+			return errors.Newf("operator %q cannot be used for numeric comparison", cond.Operator)
+		}
+	case avuOperatorBetween:
+		if len(cond.Values) != 2 {
+			return errors.Newf("operator %q requires two values, lower and upper bounds", cond.Operator)
+		}
+	case avuOperatorIn:
+		if len(cond.Values) == 0 {
+			if len(cond.Value) == 0 {
+				return errors.Newf("operator %q requires values", cond.Operator)
+			}
+			cond.Values = []string{cond.Value}
+			cond.Value = ""
+		}
+	default:
+		return errors.Newf("unknown operator %q", cond.Operator)
+	}
+
+	if cond.Numeric {
+		values := cond.Values
+		if len(values) == 0 {
+			values = []string{cond.Value}
+		}
+
+		for _, value := range values {
+			if _, err := strconv.ParseFloat(strings.TrimSpace(value), 64); err != nil {
+				return errors.Newf("value %q of attribute %q is not a number", value, cond.Attribute)
+			}
+		}
+	}
+
+	return nil
+}
+
+// makeAVUValueCondition returns a GenQuery condition on the AVU value for string comparison
+func makeAVUValueCondition(cond *model.AVUCondition) (string, error) {
+	return irods_common.MakeGenQueryValueCondition(cond.Operator, cond.Value, cond.Values)
+}
+
+// matchAVUNumericValue compares an AVU value numerically, values that are not numbers never match
+func matchAVUNumericValue(cond *model.AVUCondition, value string) bool {
+	number, err := strconv.ParseFloat(strings.TrimSpace(value), 64)
+	if err != nil {
+		return false
+	}
+
+	parse := func(v string) float64 {
+		f, _ := strconv.ParseFloat(strings.TrimSpace(v), 64)
+		return f
+	}
+
+	switch cond.Operator {
+	case avuOperatorEqual:
+		return number == parse(cond.Value)
+	case avuOperatorNotEqual:
+		return number != parse(cond.Value)
+	case avuOperatorLess:
+		return number < parse(cond.Value)
+	case avuOperatorLessEqual:
+		return number <= parse(cond.Value)
+	case avuOperatorGreater:
+		return number > parse(cond.Value)
+	case avuOperatorGreaterEqual:
+		return number >= parse(cond.Value)
+	case avuOperatorBetween:
+		return number >= parse(cond.Values[0]) && number <= parse(cond.Values[1])
+	case avuOperatorIn:
+		for _, v := range cond.Values {
+			if number == parse(v) {
+				return true
+			}
+		}
+		return false
+	default:
+		return false
+	}
+}
+
+// avuQuery evaluates a condition tree against the catalog
+// collections come before data objects in results, each ordered by path
+type avuQuery struct {
+	fs          *irodsclient_fs.FileSystem
+	entityTypes []string
+	roots       []string // collection paths to search in, not used for resources and users
+}
+
+// search returns a page of entities matching the condition and the total number of matches
+func (q *avuQuery) search(cond *model.AVUCondition, offset int, limit int) ([]avuEntity, int, error) {
+	leaf := getSingleAVUCondition(cond)
+	if leaf != nil && !leaf.Numeric {
+		// a single string comparison can be paginated by the catalog
+		return q.searchPage(leaf, offset, limit)
+	}
+
+	matches, err := q.evaluate(cond)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	sorted := sortAVUEntities(matches)
+	total := len(sorted)
+
+	if offset >= total {
+		return []avuEntity{}, total, nil
+	}
+
+	end := offset + limit
+	if end > total {
+		end = total
+	}
+
+	return sorted[offset:end], total, nil
+}
+
+func (q *avuQuery) searchPage(cond *model.AVUCondition, offset int, limit int) ([]avuEntity, int, error) {
+	page := []avuEntity{}
+	total := 0
+
+	for _, entityType := range q.entityTypes {
+		query, err := q.makeConditionQuery(cond, entityType, true)
+		if err != nil {
+			return nil, 0, err
+		}
+
+		pageLimit := limit - len(page)
+		if pageLimit <= 0 {
+			// only the count is needed
+			pageLimit = 1
+		}
+
+		query.Options |= irods_common.GenQueryOptionReturnTotalRowCount
+		query.Offset = offset
+		query.Limit = pageLimit
+
+		result, err := irods_common.RunGenQuery(q.fs, query)
+		if err != nil {
+			return nil, 0, errors.Wrapf(err, "failed to search %s by attribute %q", strings.ReplaceAll(entityType, "_", " "), cond.Attribute)
+		}
+
+		if len(page) < limit {
+			for _, row := range result.Rows {
+				page = append(page, makeAVUEntity(entityType, row))
+			}
+		}
+
+		total += result.TotalRowCount
+
+		offset -= result.TotalRowCount
+		if offset < 0 {
+			offset = 0
+		}
+	}
+
+	return page, total, nil
+}
+
+// getSingleAVUCondition returns the only condition in a condition tree, or nil if there are more
+func getSingleAVUCondition(cond *model.AVUCondition) *model.AVUCondition {
+	for len(cond.Conditions) == 1 {
+		cond = &cond.Conditions[0]
+	}
+
+	if len(cond.Conditions) > 0 {
+		return nil
+	}
+	return cond
+}
+
+func makeAVUEntity(entityType string, row []string) avuEntity {
+	entityPath := row[0]
+	switch entityType {
+	case avuEntityDataObject:
+		entityPath = path.Join(row[0], row[1])
+	case avuEntityUser:
+		entityPath = row[0] + "#" + row[1]
+	}
+
+	return avuEntity{entityType: entityType, path: entityPath}
+}
+
+func (q *avuQuery) evaluate(cond *model.AVUCondition) (avuEntitySet, error) {
+	if len(cond.Conditions) == 0 {
+		matches := avuEntitySet{}
+		for _, entityType := range q.entityTypes {
+			err := q.queryCondition(cond, entityType, matches)
+			if err != nil {
+				return nil, err
+			}
+		}
+		return matches, nil
+	}
+
+	var matches avuEntitySet
+	for idx := range cond.Conditions {
+		childMatches, err := q.evaluate(&cond.Conditions[idx])
+		if err != nil {
+			return nil, err
+		}
+
+		if matches == nil {
+			matches = childMatches
+		} else if cond.Match == avuMatchAny {
+			for entity := range childMatches {
+				matches[entity] = struct{}{}
+			}
+		} else {
+			for entity := range matches {
+				if _, ok := childMatches[entity]; !ok {
+					delete(matches, entity)
+				}
+			}
+		}
+
+		if cond.Match == avuMatchAll && len(matches) == 0 {
+			// nothing can match anymore
+			break
+		}
+	}
+
+	return matches, nil
+}
+
+func (q *avuQuery) queryCondition(cond *model.AVUCondition, entityType string, matches avuEntitySet) error {
+	query, err := q.makeConditionQuery(cond, entityType, false)
+	if err != nil {
+		return err
+	}
+	query.Limit = maxAVUConditionMatches
+
+	result, err := irods_common.RunGenQuery(q.fs, query)
+	if err != nil {
+		return errors.Wrapf(err, "failed to search %s by attribute %q", strings.ReplaceAll(entityType, "_", " "), cond.Attribute)
+	}
+
+	if result.HasMore {
+		return errors.Newf("more than %d entries match attribute %q, narrow the search with a path or more specific conditions", maxAVUConditionMatches, cond.Attribute)
+	}
+
+	for _, row := range result.Rows {
+		if cond.Numeric && !matchAVUNumericValue(cond, row[len(row)-1]) {
+			continue
+		}
+
+		matches[makeAVUEntity(entityType, row)] = struct{}{}
+	}
+
+	return nil
+}
+
+// makeConditionQuery makes a catalog query selecting paths of entities matching a condition under the roots
+func (q *avuQuery) makeConditionQuery(cond *model.AVUCondition, entityType string, ordered bool) (*irods_common.GenQuery, error) {
+	columns := avuEntityColumns[entityType]
+
+	selectOption := irods_common.GenQuerySelectNormal
+	if ordered {
+		selectOption |= irods_common.GenQueryOrderBy
+	}
+
+	selects := []irods_common.GenQueryColumn{}
+	for _, column := range avuEntityNameColumns[entityType] {
+		selects = append(selects, irods_common.GenQueryColumn{Column: column, Option: selectOption})
+	}
+	if cond.Numeric {
+		selects = append(selects, irods_common.GenQueryColumn{Column: columns.value, Option: irods_common.GenQuerySelectNormal})
+	}
+
+	quotedAttribute, err := irods_common.QuoteGenQueryValue(cond.Attribute)
+	if err != nil {
+		return nil, err
+	}
+
+	conditions := []irods_common.GenQueryCondition{
+		{Column: columns.attribute, Condition: "= " + quotedAttribute},
+	}
+
+	if !cond.Numeric {
+		valueCondition, err := makeAVUValueCondition(cond)
+		if err != nil {
+			return nil, err
+		}
+		conditions = append(conditions, irods_common.GenQueryCondition{Column: columns.value, Condition: valueCondition})
+	}
+
+	if cond.Unit != nil {
+		quotedUnit, err := irods_common.QuoteGenQueryValue(*cond.Unit)
+		if err != nil {
+			return nil, err
+		}
+		conditions = append(conditions, irods_common.GenQueryCondition{Column: columns.unit, Condition: "= " + quotedUnit})
+	}
+
+	if entityType == avuEntityCollection || entityType == avuEntityDataObject {
+		// exact, so the catalog can count and page matches
+		rootCondition, err := irods_common.MakeGenQueryPathCondition(q.roots...)
+		if err != nil {
+			return nil, err
+		}
+		conditions = append(conditions, irods_common.GenQueryCondition{Column: irodsclient_common.ICAT_COLUMN_COLL_NAME, Condition: rootCondition})
+	}
+
+	return &irods_common.GenQuery{
+		Selects:    selects,
+		Conditions: conditions,
+	}, nil
+}
+
+// sortAVUEntities returns collections then data objects, each sorted by path, resources and users are sorted by name
+func sortAVUEntities(entities avuEntitySet) []avuEntity {
+	sorted := make([]avuEntity, 0, len(entities))
+	for entity := range entities {
+		sorted = append(sorted, entity)
+	}
+
+	sort.Slice(sorted, func(i int, j int) bool {
+		if sorted[i].entityType != sorted[j].entityType {
+			return sorted[i].entityType == avuEntityCollection
+		}
+
+		if sorted[i].entityType != avuEntityDataObject {
+			return sorted[i].path < sorted[j].path
+		}
+
+		// same order as the catalog, by parent collection then name
+		dir1, name1 := path.Split(sorted[i].path)
+		dir2, name2 := path.Split(sorted[j].path)
+		if dir1 != dir2 {
+			return dir1 < dir2
+		}
+		return name1 < name2
+	})
+	return sorted
+}
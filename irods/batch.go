@@ -0,0 +1,67 @@
+package irods
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+
+	"github.com/cockroachdb/errors"
+	irods_common "github.com/cyverse/irods-mcp-server/irods/common"
+	"github.com/cyverse/irods-mcp-server/irods/model"
+	"github.com/google/jsonschema-go/jsonschema"
+)
+
+// BatchJob processes an item of a batch, returned output is included in the item result
+type BatchJob func(idx int) (any, error)
+
+func getBatchParallelismSchema() *jsonschema.Schema {
+	return &jsonschema.Schema{
+		Type:        "number",
+		Description: fmt.Sprintf("The number of batch items to process in parallel. Maximum is %d. Default is %d.", irods_common.MaxParallelism, irods_common.GetDefaultTransferThreadNum()),
+		Default:     json.RawMessage(fmt.Sprintf("%d", irods_common.GetDefaultTransferThreadNum())),
+	}
+}
+
+func checkBatchSize(itemNum int) error {
+	if itemNum > irods_common.MaxBatchItems {
+		return errors.Newf("too many batch items (%d), maximum is %d", itemNum, irods_common.MaxBatchItems)
+	}
+	return nil
+}
+
+// runBatch runs a job for each item with bounded concurrency, a failure of an item does not stop others
+func runBatch(ctx context.Context, itemNum int, parallelism int, job BatchJob) *model.BatchOutput {
+	results := make([]model.BatchItemResult, itemNum)
+
+	irods_common.RunParallel(itemNum, irods_common.GetParallelism(parallelism), func(idx int) {
+		results[idx].Index = idx
+
+		if ctx.Err() != nil {
+			results[idx].Error = ctx.Err().Error()
+			return
+		}
+
+		output, err := job(idx)
+		if err != nil {
+			results[idx].Error = err.Error()
+			return
+		}
+
+		results[idx].Success = true
+		results[idx].Output = output
+	})
+
+	batchOutput := &model.BatchOutput{
+		Results: results,
+	}
+
+	for _, result := range results {
+		if result.Success {
+			batchOutput.Succeeded++
+		} else {
+			batchOutput.Failed++
+		}
+	}
+
+	return batchOutput
+}
@@ -0,0 +1,242 @@
+package irods
+
+import (
+	"path"
+
+	"github.com/cockroachdb/errors"
+	irodsclient_fs "github.com/cyverse/go-irodsclient/fs"
+	irodsclient_common "github.com/cyverse/go-irodsclient/irods/common"
+	irods_common "github.com/cyverse/irods-mcp-server/irods/common"
+)
+
+const (
+	catalogSortPath  = "path"
+	catalogSortName  = "name"
+	catalogSortSize  = "size"
+	catalogSortMtime = "mtime"
+	catalogSortCtime = "ctime"
+)
+
+// catalogPathColumns are positions of path columns in a result row, dataName is -1 for collections
+type catalogPathColumns struct {
+	collName int
+	dataName int
+}
+
+func (c catalogPathColumns) makePath(row []string) string {
+	if c.dataName < 0 {
+		return row[c.collName]
+	}
+	return path.Join(row[c.collName], row[c.dataName])
+}
+
+// makeCatalogSelects returns path columns of files (data-objects) or directories (collections) to select, ordered by the sort field
+// directories have no size and are ordered by path instead
+func makeCatalogSelects(isFile bool, sort string, descending bool) ([]irods_common.GenQueryColumn, catalogPathColumns) {
+	pathColumns := catalogPathColumns{collName: -1, dataName: -1}
+
+	var sortColumn irodsclient_common.ICATColumnNumber = -1
+	switch sort {
+	case catalogSortSize:
+		if isFile {
+			sortColumn = irodsclient_common.ICAT_COLUMN_DATA_SIZE
+		}
+	case catalogSortMtime:
+		if isFile {
+			sortColumn = irodsclient_common.ICAT_COLUMN_D_MODIFY_TIME
+		} else {
+			sortColumn = irodsclient_common.ICAT_COLUMN_COLL_MODIFY_TIME
+		}
+	case catalogSortCtime:
+		if isFile {
+			sortColumn = irodsclient_common.ICAT_COLUMN_D_CREATE_TIME
+		} else {
+			sortColumn = irodsclient_common.ICAT_COLUMN_COLL_CREATE_TIME
+		}
+	}
+
+	// the catalog orders by selected columns in order
+	primaryOption := irods_common.GenQuerySelectNormal | irods_common.GenQueryOrderBy
+	if descending {
+		primaryOption = irods_common.GenQuerySelectNormal | irods_common.GenQueryOrderByDesc
+	}
+	secondaryOption := irods_common.GenQuerySelectNormal | irods_common.GenQueryOrderBy
+
+	selects := []irods_common.GenQueryColumn{}
+	addSelect := func(column irodsclient_common.ICATColumnNumber) int {
+		option := secondaryOption
+		if len(selects) == 0 {
+			option = primaryOption
+		}
+		selects = append(selects, irods_common.GenQueryColumn{Column: column, Option: option})
+		return len(selects) - 1
+	}
+
+	if sortColumn >= 0 {
+		addSelect(sortColumn)
+	}
+
+	if isFile && sort == catalogSortName {
+		pathColumns.dataName = addSelect(irodsclient_common.ICAT_COLUMN_DATA_NAME)
+		pathColumns.collName = addSelect(irodsclient_common.ICAT_COLUMN_COLL_NAME)
+	} else {
+		pathColumns.collName = addSelect(irodsclient_common.ICAT_COLUMN_COLL_NAME)
+		if isFile {
+			pathColumns.dataName = addSelect(irodsclient_common.ICAT_COLUMN_DATA_NAME)
+		}
+	}
+
+	return selects, pathColumns
+}
+
+// catalogPageQuery is a catalog query selecting paths of one entry type, in the order of results
+type catalogPageQuery struct {
+	entryType   string // e.g. "file" or "directory", for messages
+	query       *irods_common.GenQuery
+	pathColumns catalogPathColumns
+}
+
+// replicaColumns are columns of data object replicas, a data object has a row per replica with different values
+var replicaColumns = map[irodsclient_common.ICATColumnNumber]bool{
+	irodsclient_common.ICAT_COLUMN_DATA_SIZE:       true,
+	irodsclient_common.ICAT_COLUMN_D_MODIFY_TIME:   true,
+	irodsclient_common.ICAT_COLUMN_D_CREATE_TIME:   true,
+	irodsclient_common.ICAT_COLUMN_DATA_REPL_NUM:   true,
+	irodsclient_common.ICAT_COLUMN_D_REPL_STATUS:   true,
+	irodsclient_common.ICAT_COLUMN_D_RESC_NAME:     true,
+	irodsclient_common.ICAT_COLUMN_D_DATA_CHECKSUM: true,
+}
+
+// hasReplicaRows returns true if the query selects replica columns, so a data object may have multiple rows
+func (q catalogPageQuery) hasReplicaRows() bool {
+	for _, selectColumn := range q.query.Selects {
+		if replicaColumns[selectColumn.Column] {
+			return true
+		}
+	}
+	return false
+}
+
+// queryCatalogPage lets the catalog paginate over queries run one after another
+// queries with a row per replica are scanned up to maxRows instead, as the catalog counts and pages rows, not data objects
+// returns paths in the page and the total number of matches
+func queryCatalogPage(fs *irodsclient_fs.FileSystem, queries []catalogPageQuery, maxRows int, offset int, limit int) ([]string, int, error) {
+	page := []string{}
+	total := 0
+
+	for _, pageQuery := range queries {
+		pageLimit := limit - len(page)
+
+		if pageQuery.hasReplicaRows() {
+			paths, err := scanCatalogPaths(fs, pageQuery, maxRows)
+			if err != nil {
+				return nil, 0, err
+			}
+
+			if pageLimit > 0 && offset < len(paths) {
+				end := offset + pageLimit
+				if end > len(paths) {
+					end = len(paths)
+				}
+				page = append(page, paths[offset:end]...)
+			}
+
+			total += len(paths)
+
+			offset -= len(paths)
+			if offset < 0 {
+				offset = 0
+			}
+			continue
+		}
+
+		if pageLimit <= 0 {
+			// only the count is needed
+			pageLimit = 1
+		}
+
+		query := *pageQuery.query
+		query.Options |= irods_common.GenQueryOptionReturnTotalRowCount
+		query.Offset = offset
+		query.Limit = pageLimit
+
+		result, err := irods_common.RunGenQuery(fs, &query)
+		if err != nil {
+			return nil, 0, errors.Wrapf(err, "failed to query %ss", pageQuery.entryType)
+		}
+
+		if len(page) < limit {
+			for _, row := range result.Rows {
+				page = append(page, pageQuery.pathColumns.makePath(row))
+			}
+		}
+
+		total += result.TotalRowCount
+
+		offset -= result.TotalRowCount
+		if offset < 0 {
+			offset = 0
+		}
+	}
+
+	return page, total, nil
+}
+
+// scanCatalogPage reads all matching rows, keeps paths accepted by match and paginates them
+// used when some filters cannot be run by the catalog
+func scanCatalogPage(fs *irodsclient_fs.FileSystem, queries []catalogPageQuery, match func(entryPath string) bool, maxRows int, offset int, limit int) ([]string, int, error) {
+	matches := []string{}
+
+	for _, pageQuery := range queries {
+		paths, err := scanCatalogPaths(fs, pageQuery, maxRows)
+		if err != nil {
+			return nil, 0, err
+		}
+
+		for _, entryPath := range paths {
+			if match == nil || match(entryPath) {
+				matches = append(matches, entryPath)
+			}
+		}
+	}
+
+	total := len(matches)
+	if offset >= total {
+		return []string{}, total, nil
+	}
+
+	end := offset + limit
+	if end > total {
+		end = total
+	}
+
+	return matches[offset:end], total, nil
+}
+
+// scanCatalogPaths returns all distinct paths of a query in the order of results, fails if there are more than maxRows rows
+// a data object with multiple replica rows is placed at its first row
+func scanCatalogPaths(fs *irodsclient_fs.FileSystem, pageQuery catalogPageQuery, maxRows int) ([]string, error) {
+	query := *pageQuery.query
+	query.Limit = maxRows
+
+	result, err := irods_common.RunGenQuery(fs, &query)
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to query %ss", pageQuery.entryType)
+	}
+
+	if result.HasMore {
+		return nil, errors.Newf("more than %d %ss to scan, narrow the search with a deeper path or more filters", maxRows, pageQuery.entryType)
+	}
+
+	paths := []string{}
+	seen := map[string]bool{}
+	for _, row := range result.Rows {
+		entryPath := pageQuery.pathColumns.makePath(row)
+		if !seen[entryPath] {
+			seen[entryPath] = true
+			paths = append(paths, entryPath)
+		}
+	}
+
+	return paths, nil
+}
@@ -0,0 +1,254 @@
+package irods
+
+import (
+	"context"
+	"encoding/json"
+
+	"github.com/cockroachdb/errors"
+	irodsclient_fs "github.com/cyverse/go-irodsclient/fs"
+	"github.com/cyverse/irods-mcp-server/common"
+	irods_common "github.com/cyverse/irods-mcp-server/irods/common"
+	"github.com/cyverse/irods-mcp-server/irods/model"
+	"github.com/google/jsonschema-go/jsonschema"
+	"github.com/modelcontextprotocol/go-sdk/mcp"
+)
+
+const (
+	ChecksumName = irods_common.IRODSAPIPrefix + "checksum"
+)
+
+type ChecksumInputArgs struct {
+	Path           string `json:"path"`
+	Force          bool   `json:"force,omitempty"`
+	VerifyReplicas bool   `json:"verify_replicas,omitempty"`
+	LocalPath      string `json:"local_path,omitempty"`
+}
+
+type Checksum struct {
+	mcpServer *IRODSMCPServer
+	config    *common.Config
+}
+
+func NewChecksum(svr *IRODSMCPServer) ToolAPI {
+	return &Checksum{
+		mcpServer: svr,
+		config:    svr.GetConfig(),
+	}
+}
+
+func (t *Checksum) GetName() string {
+	return ChecksumName
+}
+
+func (t *Checksum) GetDescription() string {
+	return `Get the checksum of a file (data-object) with the specified path, computing it on the server if missing.
+	The specified path must be an iRODS path.
+	Optionally recomputes the checksum, verifies every replica against the catalog, or compares with a local file.
+	The checksum and verification results are returned in JSON format.`
+}
+
+func (t *Checksum) GetTool() *mcp.Tool {
+	return &mcp.Tool{
+		Name:        t.GetName(),
+		Description: t.GetDescription(),
+		InputSchema: &jsonschema.Schema{
+			Type: "object",
+			Properties: map[string]*jsonschema.Schema{
+				"path": {
+					Type:        "string",
+					Description: "The path to the file (data-object).",
+				},
+				"force": {
+					Type:        "boolean",
+					Description: "Set to true to recompute the checksum of all replicas on the server even if it is already in the catalog. Default is false.",
+					Default:     json.RawMessage("false"),
+				},
+				"verify_replicas": {
+					Type:        "boolean",
+					Description: "Set to true to have the server recompute the checksum of each replica and compare it with the catalog. Default is false.",
+					Default:     json.RawMessage("false"),
+				},
+				"local_path": {
+					Type:        "string",
+					Description: "The path to a local file to compare with. The local file is hashed with the same algorithm as the catalog checksum. Only available when the server runs in STDIO mode.",
+				},
+			},
+			Required: []string{"path"},
+		},
+	}
+}
+
+func (t *Checksum) GetHandler() mcp.ToolHandler {
+	return t.Handler
+}
+
+func (t *Checksum) GetAccessiblePaths(authValue *common.AuthValue) []string {
+	account, err := t.mcpServer.GetIRODSAccountFromAuthValue(authValue)
+	if err != nil {
+		return []string{}
+	}
+
+	homePath := irods_common.GetHomePath(t.config, account)
+	sharedPath := irods_common.GetSharedPath(t.config, account)
+
+	paths := []string{
+		sharedPath + "/*",
+	}
+
+	if !account.IsAnonymousUser() {
+		paths = append(paths, homePath)
+		paths = append(paths, homePath+"/*")
+	}
+
+	return paths
+}
+
+func (t *Checksum) Handler(ctx context.Context, request *mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	// arguments
+	args := ChecksumInputArgs{}
+	err := irods_common.MarshalInputArguments(t.GetTool(), request, &args)
+	if err != nil {
+		outputErr := errors.Wrapf(err, "failed to marshal input arguments")
+		return irods_common.ToolErrorResult(outputErr), nil
+	}
+
+	// auth
+	authValue, err := common.GetAuthValue(ctx)
+	if err != nil {
+		outputErr := errors.Wrapf(err, "failed to get auth value")
+		return irods_common.ToolErrorResult(outputErr), nil
+	}
+
+	if len(args.LocalPath) > 0 && !authValue.IsSTDIO() {
+		outputErr := errors.Newf("local_path is only available when the server runs in STDIO mode")
+		return irods_common.ToolErrorResult(outputErr), nil
+	}
+
+	// make a irods filesystem client
+	fs, err := t.mcpServer.GetIRODSFSClientFromAuthValue(&authValue)
+	if err != nil {
+		outputErr := errors.Wrapf(err, "failed to create a irods fs client")
+		return irods_common.ToolErrorResult(outputErr), nil
+	}
+
+	irodsPath := irods_common.MakeIRODSPath(t.config, fs.GetAccount(), args.Path)
+
+	// check permission
+	if !irods_common.IsAccessAllowed(irodsPath, t.GetAccessiblePaths(&authValue)) {
+		outputErr := errors.Newf("%q request is not permitted for path %q", t.GetName(), irodsPath)
+		return irods_common.ToolErrorResult(outputErr), nil
+	}
+
+	// Get file info
+	entry, err := fs.Stat(irodsPath)
+	if err != nil {
+		outputErr := errors.Wrapf(err, "failed to stat file info for %q", irodsPath)
+		return irods_common.ToolErrorResult(outputErr), nil
+	}
+
+	if entry.IsDir() {
+		outputErr := errors.Newf("path %q is a directory (collection)", irodsPath)
+		return irods_common.ToolErrorResult(outputErr), nil
+	}
+
+	content, err := t.checksum(fs, entry, &args)
+	if err != nil {
+		outputErr := errors.Wrapf(err, "failed to get checksum of file (data-object) for %q", irodsPath)
+		return irods_common.ToolErrorResult(outputErr), nil
+	}
+
+	return irods_common.ToolJSONResult(*content)
+}
+
+func (t *Checksum) checksum(fs *irodsclient_fs.FileSystem, entry *irodsclient_fs.Entry, args *ChecksumInputArgs) (*model.ChecksumOutput, error) {
+	checksumString := ""
+	if args.Force {
+		checksum, err := irods_common.ComputeDataObjectChecksum(fs, entry.Path, &irods_common.ChecksumRequestOption{
+			Force:       true,
+			AllReplicas: true,
+		})
+		if err != nil {
+			return nil, err
+		}
+
+		checksumString = checksum.IRODSChecksumString
+	} else {
+		checksum, err := irods_common.GetDataObjectChecksum(fs, entry.Path)
+		if err != nil {
+			return nil, err
+		}
+
+		checksumString = checksum
+	}
+
+	checksumOutput := &model.ChecksumOutput{
+		Path:     entry.Path,
+		Size:     entry.Size,
+		Checksum: checksumString,
+		Computed: args.Force || len(entry.CheckSum) == 0,
+		Replicas: []model.ReplicaChecksum{},
+	}
+
+	// replicas
+	replicas, err := irods_common.GetDataObjectReplicas(fs, entry.Path)
+	if err != nil {
+		return nil, err
+	}
+
+	allMatch := true
+	for _, replica := range replicas {
+		replicaChecksum := model.ReplicaChecksum{
+			Number:            replica.Number,
+			ResourceName:      replica.ResourceName,
+			ResourceHierarchy: replica.ResourceHierarchy,
+			Status:            replica.Status,
+		}
+
+		if replica.Checksum != nil {
+			replicaChecksum.Checksum = replica.Checksum.IRODSChecksumString
+		}
+
+		replicaChecksum.MatchesCatalog = irods_common.IsSameChecksum(replicaChecksum.Checksum, checksumString)
+
+		if args.VerifyReplicas {
+			replicaNumber := replica.Number
+			_, verifyErr := irods_common.ComputeDataObjectChecksum(fs, entry.Path, &irods_common.ChecksumRequestOption{
+				Verify:        true,
+				ReplicaNumber: &replicaNumber,
+			})
+
+			verified := verifyErr == nil
+			replicaChecksum.Verified = &verified
+			if verifyErr != nil {
+				replicaChecksum.Error = verifyErr.Error()
+			}
+
+			if !verified {
+				allMatch = false
+			}
+		}
+
+		if !replicaChecksum.MatchesCatalog {
+			allMatch = false
+		}
+
+		checksumOutput.Replicas = append(checksumOutput.Replicas, replicaChecksum)
+	}
+
+	checksumOutput.AllReplicasMatch = allMatch
+
+	// local file
+	if len(args.LocalPath) > 0 {
+		localChecksum, err := irods_common.HashLocalFile(args.LocalPath, checksumString)
+		if err != nil {
+			return nil, err
+		}
+
+		localMatch := irods_common.IsSameChecksum(localChecksum, checksumString)
+		checksumOutput.LocalPath = args.LocalPath
+		checksumOutput.LocalChecksum = localChecksum
+		checksumOutput.LocalMatch = &localMatch
+	}
+
+	return checksumOutput, nil
+}
@@ -0,0 +1,343 @@
+package common
+
+import (
+	"archive/tar"
+	"archive/zip"
+	"bufio"
+	"compress/gzip"
+	"io"
+	"io/fs"
+	"path"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/cockroachdb/errors"
+	irodsclient_fs "github.com/cyverse/go-irodsclient/fs"
+)
+
+// ArchiveFormat is a format of archive files
+type ArchiveFormat string
+
+const (
+	ArchiveFormatTar   ArchiveFormat = "tar"
+	ArchiveFormatTarGz ArchiveFormat = "tar.gz"
+	ArchiveFormatZip   ArchiveFormat = "zip"
+)
+
+const (
+	ArchiveMaxTotalSizeDefault int64 = 10 * 1024 * 1024 * 1024  // 10GB
+	ArchiveMaxTotalSize        int64 = 100 * 1024 * 1024 * 1024 // 100GB
+	ArchiveMaxFilesDefault     int   = 10000
+	ArchiveMaxFiles            int   = 100000
+	ArchiveIOBufferSize        int   = 4 * 1024 * 1024 // 4MB
+)
+
+// GetArchiveFormats returns all supported archive formats
+func GetArchiveFormats() []ArchiveFormat {
+	return []ArchiveFormat{ArchiveFormatTar, ArchiveFormatTarGz, ArchiveFormatZip}
+}
+
+// GetArchiveFormatFromPath returns the archive format for the file extension, or empty string if unknown
+func GetArchiveFormatFromPath(p string) ArchiveFormat {
+	name := strings.ToLower(path.Base(p))
+
+	switch {
+	case strings.HasSuffix(name, ".tar.gz"), strings.HasSuffix(name, ".tgz"):
+		return ArchiveFormatTarGz
+	case strings.HasSuffix(name, ".tar"):
+		return ArchiveFormatTar
+	case strings.HasSuffix(name, ".zip"):
+		return ArchiveFormatZip
+	default:
+		return ""
+	}
+}
+
+// SanitizeArchiveMemberPath cleans a member path of an archive, paths escaping the extraction root are rejected
+// returns an empty path for the extraction root itself, e.g., "./" in archives made with "tar -C dir ."
+func SanitizeArchiveMemberPath(name string) (string, error) {
+	name = strings.ReplaceAll(name, "\\", "/")
+	if strings.HasPrefix(name, "/") || strings.Contains("/"+name+"/", "/../") {
+		return "", errors.Newf("archive member path %q escapes the destination", name)
+	}
+
+	cleaned := path.Clean("/" + name)
+	return strings.TrimPrefix(cleaned, "/"), nil
+}
+
+// CountingWriter counts bytes written through it
+type CountingWriter struct {
+	Writer io.Writer
+	Count  int64
+}
+
+func (writer *CountingWriter) Write(p []byte) (int, error) {
+	n, err := writer.Writer.Write(p)
+	writer.Count += int64(n)
+	return n, err
+}
+
+// BlockReaderAt wraps io.ReaderAt to read in large blocks, small reads of a format reader are served from the cached block
+type BlockReaderAt struct {
+	reader      io.ReaderAt
+	size        int64
+	blockSize   int
+	block       []byte
+	blockOffset int64
+	mutex       sync.Mutex
+}
+
+// NewBlockReaderAt creates a BlockReaderAt for a data of the given size
+func NewBlockReaderAt(reader io.ReaderAt, size int64, blockSize int) *BlockReaderAt {
+	return &BlockReaderAt{
+		reader:      reader,
+		size:        size,
+		blockSize:   blockSize,
+		blockOffset: -1,
+	}
+}
+
+func (reader *BlockReaderAt) ReadAt(p []byte, off int64) (int, error) {
+	if off >= reader.size {
+		return 0, io.EOF
+	}
+
+	// large reads bypass the block
+	if len(p) >= reader.blockSize {
+		return reader.reader.ReadAt(p, off)
+	}
+
+	reader.mutex.Lock()
+	defer reader.mutex.Unlock()
+
+	read := 0
+	for read < len(p) && off < reader.size {
+		if reader.blockOffset < 0 || off < reader.blockOffset || off >= reader.blockOffset+int64(len(reader.block)) {
+			err := reader.loadBlock(off)
+			if err != nil {
+				return read, err
+			}
+		}
+
+		n := copy(p[read:], reader.block[off-reader.blockOffset:])
+		read += n
+		off += int64(n)
+	}
+
+	if read < len(p) {
+		return read, io.EOF
+	}
+	return read, nil
+}
+
+func (reader *BlockReaderAt) loadBlock(off int64) error {
+	blockOffset := off - (off % int64(reader.blockSize))
+	blockLen := int64(reader.blockSize)
+	if blockOffset+blockLen > reader.size {
+		blockLen = reader.size - blockOffset
+	}
+
+	if cap(reader.block) < int(blockLen) {
+		reader.block = make([]byte, reader.blockSize)
+	}
+	block := reader.block[:blockLen]
+
+	n, err := reader.reader.ReadAt(block, blockOffset)
+	if n < len(block) {
+		if err == nil || err == io.EOF {
+			err = io.ErrUnexpectedEOF
+		}
+		reader.blockOffset = -1
+		return err
+	}
+
+	reader.block = block
+	reader.blockOffset = blockOffset
+	return nil
+}
+
+// ArchiveMember is a member of an archive file
+type ArchiveMember struct {
+	Name       string
+	Size       int64
+	ModifyTime time.Time
+	Type       string // file, dir, symlink, or other
+}
+
+func getTarMemberType(header *tar.Header) string {
+	switch header.Typeflag {
+	case tar.TypeReg:
+		return "file"
+	case tar.TypeDir:
+		return "dir"
+	case tar.TypeSymlink, tar.TypeLink:
+		return "symlink"
+	default:
+		return "other"
+	}
+}
+
+func getZipMemberType(file *zip.File) string {
+	mode := file.Mode()
+	switch {
+	case mode.IsDir():
+		return "dir"
+	case mode.IsRegular():
+		return "file"
+	case mode&fs.ModeSymlink != 0:
+		return "symlink"
+	default:
+		return "other"
+	}
+}
+
+// archiveMemberHandler is called for each member of an archive, return ErrStopWalk to stop
+type archiveMemberHandler func(member *ArchiveMember, open func() (io.ReadCloser, error)) error
+
+// walkArchive calls handler for each member of an archive data object, the member content can be opened only within the call
+func walkArchive(filesystem *irodsclient_fs.FileSystem, entry *irodsclient_fs.Entry, format ArchiveFormat, handler archiveMemberHandler) error {
+	handle, err := filesystem.OpenFile(entry.Path, "", "r")
+	if err != nil {
+		return errors.Wrapf(err, "failed to open file %q", entry.Path)
+	}
+	defer handle.Close()
+
+	// small reads of format readers are served from cached blocks
+	readerAt := NewBlockReaderAt(handle, entry.Size, ArchiveIOBufferSize)
+
+	switch format {
+	case ArchiveFormatZip:
+		zipReader, err := zip.NewReader(readerAt, entry.Size)
+		if err != nil {
+			return errors.Wrapf(err, "failed to read zip archive %q", entry.Path)
+		}
+
+		for _, file := range zipReader.File {
+			member := &ArchiveMember{
+				Name:       file.Name,
+				Size:       int64(file.UncompressedSize64),
+				ModifyTime: file.Modified,
+				Type:       getZipMemberType(file),
+			}
+
+			err = handler(member, file.Open)
+			if err != nil {
+				if err == ErrStopWalk {
+					return nil
+				}
+				return err
+			}
+		}
+		return nil
+	case ArchiveFormatTar, ArchiveFormatTarGz:
+		// section reader is seekable, so tar reader skips member data without reading it
+		var reader io.Reader = io.NewSectionReader(readerAt, 0, entry.Size)
+		if format == ArchiveFormatTarGz {
+			gzipReader, err := gzip.NewReader(bufio.NewReaderSize(reader, ArchiveIOBufferSize))
+			if err != nil {
+				return errors.Wrapf(err, "failed to read gzip stream of %q", entry.Path)
+			}
+			defer gzipReader.Close()
+
+			reader = gzipReader
+		}
+
+		tarReader := tar.NewReader(reader)
+		for {
+			header, err := tarReader.Next()
+			if err != nil {
+				if err == io.EOF {
+					return nil
+				}
+				return errors.Wrapf(err, "failed to read tar archive %q", entry.Path)
+			}
+
+			if header.Typeflag == tar.TypeXGlobalHeader {
+				continue
+			}
+
+			member := &ArchiveMember{
+				Name:       header.Name,
+				Size:       header.Size,
+				ModifyTime: header.ModTime,
+				Type:       getTarMemberType(header),
+			}
+
+			err = handler(member, func() (io.ReadCloser, error) {
+				return io.NopCloser(tarReader), nil
+			})
+			if err != nil {
+				if err == ErrStopWalk {
+					return nil
+				}
+				return err
+			}
+		}
+	default:
+		return errors.Newf("unknown archive format %q", format)
+	}
+}
+
+// ListArchiveMembers lists members of an archive data object, returns true if the list is truncated at maxMembers
+func ListArchiveMembers(filesystem *irodsclient_fs.FileSystem, entry *irodsclient_fs.Entry, format ArchiveFormat, maxMembers int) ([]ArchiveMember, bool, error) {
+	members := []ArchiveMember{}
+	truncated := false
+
+	err := walkArchive(filesystem, entry, format, func(member *ArchiveMember, open func() (io.ReadCloser, error)) error {
+		if len(members) >= maxMembers {
+			truncated = true
+			return ErrStopWalk
+		}
+
+		members = append(members, *member)
+		return nil
+	})
+	if err != nil {
+		return nil, false, err
+	}
+
+	return members, truncated, nil
+}
+
+// ReadArchiveMember calls reader for the content of the member of an archive data object
+func ReadArchiveMember(filesystem *irodsclient_fs.FileSystem, entry *irodsclient_fs.Entry, format ArchiveFormat, memberName string, read func(member *ArchiveMember, reader io.Reader) error) error {
+	memberPath, err := SanitizeArchiveMemberPath(memberName)
+	if err != nil {
+		return err
+	}
+
+	found := false
+	err = walkArchive(filesystem, entry, format, func(member *ArchiveMember, open func() (io.ReadCloser, error)) error {
+		name, nameErr := SanitizeArchiveMemberPath(member.Name)
+		if nameErr != nil || name != memberPath {
+			return nil
+		}
+
+		if member.Type != "file" {
+			return errors.Newf("archive member %q is not a regular file", member.Name)
+		}
+
+		found = true
+		reader, openErr := open()
+		if openErr != nil {
+			return errors.Wrapf(openErr, "failed to open archive member %q", member.Name)
+		}
+		defer reader.Close()
+
+		readErr := read(member, reader)
+		if readErr != nil {
+			return readErr
+		}
+		return ErrStopWalk
+	})
+	if err != nil {
+		return err
+	}
+
+	if !found {
+		return errors.Newf("archive member %q is not found in %q", memberName, entry.Path)
+	}
+
+	return nil
+}
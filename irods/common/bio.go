@@ -0,0 +1,88 @@
+package common
+
+import (
+	"bytes"
+	"path"
+	"strings"
+)
+
+// BioFormat is a format of bioinformatics files
+type BioFormat string
+
+const (
+	BioFormatFASTA BioFormat = "fasta"
+	BioFormatFASTQ BioFormat = "fastq"
+	BioFormatVCF   BioFormat = "vcf"
+	BioFormatSAM   BioFormat = "sam"
+)
+
+var (
+	bioFormatExtensions map[string]BioFormat = map[string]BioFormat{
+		".fasta": BioFormatFASTA,
+		".fa":    BioFormatFASTA,
+		".fna":   BioFormatFASTA,
+		".faa":   BioFormatFASTA,
+		".ffn":   BioFormatFASTA,
+		".fas":   BioFormatFASTA,
+		".fastq": BioFormatFASTQ,
+		".fq":    BioFormatFASTQ,
+		".vcf":   BioFormatVCF,
+		".sam":   BioFormatSAM,
+	}
+
+	bioFormatMimeTypes map[BioFormat]string = map[BioFormat]string{
+		BioFormatFASTA: "text/x-fasta",
+		BioFormatFASTQ: "text/x-fastq",
+		BioFormatVCF:   "text/x-vcf",
+		BioFormatSAM:   "text/x-sam",
+	}
+)
+
+// GetBioFormats returns all supported bioinformatics file formats
+func GetBioFormats() []BioFormat {
+	return []BioFormat{BioFormatFASTA, BioFormatFASTQ, BioFormatVCF, BioFormatSAM}
+}
+
+// GetBioFormatFromPath returns the bioinformatics file format for the file extension, compression extensions are ignored
+func GetBioFormatFromPath(p string) BioFormat {
+	ext := strings.ToLower(path.Ext(TrimCompressionExtension(p)))
+	return bioFormatExtensions[ext]
+}
+
+// GetBioFormatMimeType returns the mime type of the bioinformatics file format
+func GetBioFormatMimeType(format BioFormat) string {
+	return bioFormatMimeTypes[format]
+}
+
+// DetectBioFormat detects the bioinformatics file format from the leading content, the file extension is used if the content is not conclusive
+func DetectBioFormat(p string, head []byte) BioFormat {
+	head = bytes.TrimLeft(head, "\r\n")
+
+	switch {
+	case bytes.HasPrefix(head, []byte("##fileformat=VCF")):
+		return BioFormatVCF
+	case bytes.HasPrefix(head, []byte("@HD\t")), bytes.HasPrefix(head, []byte("@SQ\t")), bytes.HasPrefix(head, []byte("@RG\t")), bytes.HasPrefix(head, []byte("@PG\t")):
+		return BioFormatSAM
+	case bytes.HasPrefix(head, []byte(">")):
+		return BioFormatFASTA
+	case bytes.HasPrefix(head, []byte("@")):
+		// FASTQ records have a '+' separator line as the third line
+		lines := bytes.SplitN(head, []byte("\n"), 4)
+		if len(lines) >= 3 && bytes.HasPrefix(lines[2], []byte("+")) {
+			return BioFormatFASTQ
+		}
+	}
+
+	format := GetBioFormatFromPath(p)
+	if len(format) > 0 {
+		return format
+	}
+
+	// SAM without header has 11 or more tab-separated fields
+	firstLine, _, _ := bytes.Cut(head, []byte("\n"))
+	if bytes.Count(firstLine, []byte("\t")) >= 10 {
+		return BioFormatSAM
+	}
+
+	return ""
+}
@@ -0,0 +1,296 @@
+package common
+
+import (
+	"bytes"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/cockroachdb/errors"
+	irodsclient_fs "github.com/cyverse/go-irodsclient/fs"
+	"golang.org/x/text/encoding/unicode"
+)
+
+const (
+	TextEncodingUTF8        string = "utf-8"
+	TextEncodingUTF16LE     string = "utf-16le"
+	TextEncodingUTF16BE     string = "utf-16be"
+	TextEncodingWindows1252 string = "windows-1252"
+
+	charsetDetectSize int = 4096
+)
+
+var (
+	utf8BOM    []byte = []byte{0xef, 0xbb, 0xbf}
+	utf16LEBOM []byte = []byte{0xff, 0xfe}
+	utf16BEBOM []byte = []byte{0xfe, 0xff}
+)
+
+// TextChunk is a chunk of text decoded to UTF-8
+type TextChunk struct {
+	Text      string
+	Encoding  string
+	Detected  bool  // true if the encoding is detected, false if given
+	HasBOM    bool  // true if the byte order mark is found and removed
+	Offset    int64 // offset of the first decoded byte
+	Length    int64 // number of bytes decoded
+	Converted bool  // true if the text is converted from non UTF-8 encoding
+}
+
+// DetectTextEncoding detects the encoding of text from the byte order mark or heuristics, returns empty string if content does not look like text.
+// offset is the position of content in the file, the byte order mark is checked only at offset 0.
+func DetectTextEncoding(content []byte, offset int64) string {
+	if offset == 0 {
+		switch {
+		case bytes.HasPrefix(content, utf8BOM):
+			return TextEncodingUTF8
+		case bytes.HasPrefix(content, utf16LEBOM):
+			return TextEncodingUTF16LE
+		case bytes.HasPrefix(content, utf16BEBOM):
+			return TextEncodingUTF16BE
+		}
+	}
+
+	sample := content
+	if len(sample) > charsetDetectSize {
+		sample = sample[:charsetDetectSize]
+	}
+
+	if len(sample) == 0 {
+		return TextEncodingUTF8
+	}
+
+	// UTF-16 text in latin scripts has zeros in every other byte
+	if len(sample) >= 4 {
+		evenZeros := 0
+		oddZeros := 0
+		for idx, b := range sample {
+			if b == 0 {
+				if (int64(idx)+offset)%2 == 0 {
+					evenZeros++
+				} else {
+					oddZeros++
+				}
+			}
+		}
+
+		half := len(sample) / 2
+		if oddZeros*10 > half*7 && evenZeros*10 < half {
+			return TextEncodingUTF16LE
+		}
+		if evenZeros*10 > half*7 && oddZeros*10 < half {
+			return TextEncodingUTF16BE
+		}
+
+		if evenZeros+oddZeros > 0 {
+			// binary
+			return ""
+		}
+	}
+
+	if isValidUTF8Chunk(sample) {
+		return TextEncodingUTF8
+	}
+
+	// single-byte text, control characters other than whitespace indicate binary
+	for _, b := range sample {
+		if b < 0x20 && b != '\t' && b != '\n' && b != '\r' && b != '\f' {
+			return ""
+		}
+	}
+
+	// windows-1252 is a superset of printable iso-8859-1
+	return TextEncodingWindows1252
+}
+
+// isValidUTF8Chunk checks if content is valid UTF-8, ignoring runes split at chunk edges
+func isValidUTF8Chunk(content []byte) bool {
+	start := 0
+	for start < len(content) && start < utf8.UTFMax && !utf8.RuneStart(content[start]) {
+		start++
+	}
+
+	end := len(content) - incompleteRuneSuffixLength(content)
+	if start > end {
+		return false
+	}
+	return utf8.Valid(content[start:end])
+}
+
+// incompleteRuneSuffixLength returns the length of an incomplete UTF-8 rune at the end of content
+func incompleteRuneSuffixLength(content []byte) int {
+	for i := 1; i <= utf8.UTFMax && i <= len(content); i++ {
+		b := content[len(content)-i]
+		if utf8.RuneStart(b) {
+			if b < utf8.RuneSelf {
+				// ASCII
+				return 0
+			}
+
+			if utf8.FullRune(content[len(content)-i:]) {
+				return 0
+			}
+			return i
+		}
+	}
+	return 0
+}
+
+// DecodeTextChunk decodes a chunk of text read at offset to UTF-8, encodingName is detected if empty.
+// Edges of the chunk are aligned to character boundaries, a split character at the end is left out unless atEOF is set.
+func DecodeTextChunk(content []byte, offset int64, encodingName string, atEOF bool) (*TextChunk, error) {
+	chunk := &TextChunk{
+		Encoding: strings.ToLower(strings.TrimSpace(encodingName)),
+		Offset:   offset,
+	}
+
+	if len(chunk.Encoding) == 0 {
+		chunk.Encoding = DetectTextEncoding(content, offset)
+		chunk.Detected = true
+		if len(chunk.Encoding) == 0 {
+			return nil, errors.Newf("content is not text in a known encoding")
+		}
+	} else if chunk.Encoding == "utf8" {
+		chunk.Encoding = TextEncodingUTF8
+	}
+
+	start := 0
+	end := len(content)
+
+	switch chunk.Encoding {
+	case TextEncodingUTF8:
+		if offset == 0 && bytes.HasPrefix(content, utf8BOM) {
+			start = len(utf8BOM)
+			chunk.HasBOM = true
+		} else if offset > 0 {
+			for start < len(content) && start < utf8.UTFMax && !utf8.RuneStart(content[start]) {
+				start++
+			}
+		}
+
+		if !atEOF {
+			end -= incompleteRuneSuffixLength(content[start:])
+		}
+	case TextEncodingUTF16LE, TextEncodingUTF16BE, "utf-16":
+		if offset == 0 && (bytes.HasPrefix(content, utf16LEBOM) || bytes.HasPrefix(content, utf16BEBOM)) {
+			if chunk.Encoding == "utf-16" {
+				chunk.Encoding = TextEncodingUTF16BE
+				if bytes.HasPrefix(content, utf16LEBOM) {
+					chunk.Encoding = TextEncodingUTF16LE
+				}
+			}
+			start = 2
+			chunk.HasBOM = true
+		} else if offset%2 == 1 {
+			// align to code units
+			start = 1
+		}
+
+		if (end-start)%2 == 1 {
+			end--
+		}
+
+		// leave out a high surrogate split from its low surrogate
+		if !atEOF && end-start >= 2 {
+			unit := uint16(content[end-2]) | uint16(content[end-1])<<8
+			if chunk.Encoding == TextEncodingUTF16BE {
+				unit = uint16(content[end-2])<<8 | uint16(content[end-1])
+			}
+			if unit >= 0xd800 && unit < 0xdc00 {
+				end -= 2
+			}
+		}
+	}
+
+	if start > end {
+		start = end
+	}
+
+	chunk.Offset = offset + int64(start)
+	chunk.Length = int64(end - start)
+	body := content[start:end]
+
+	if chunk.Encoding == TextEncodingUTF8 {
+		chunk.Text = string(body)
+		return chunk, nil
+	}
+
+	var decoded []byte
+	var err error
+	switch chunk.Encoding {
+	case TextEncodingUTF16LE:
+		decoded, err = unicode.UTF16(unicode.LittleEndian, unicode.IgnoreBOM).NewDecoder().Bytes(body)
+	case TextEncodingUTF16BE:
+		decoded, err = unicode.UTF16(unicode.BigEndian, unicode.IgnoreBOM).NewDecoder().Bytes(body)
+	default:
+		enc, encErr := GetTextEncoding(chunk.Encoding)
+		if encErr != nil {
+			return nil, encErr
+		}
+		decoded, err = enc.NewDecoder().Bytes(body)
+	}
+
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to decode text in %q", chunk.Encoding)
+	}
+
+	chunk.Text = string(decoded)
+	chunk.Converted = true
+	return chunk, nil
+}
+
+// NextOffset returns the offset to read the following chunk from
+func (chunk *TextChunk) NextOffset() int64 {
+	return chunk.Offset + chunk.Length
+}
+
+// MakeTextChunkMeta returns metadata of the decoded text chunk for MCP contents
+func MakeTextChunkMeta(chunk *TextChunk) map[string]any {
+	return map[string]any{
+		"encoding":    chunk.Encoding,
+		"offset":      chunk.Offset,
+		"length":      chunk.Length,
+		"next_offset": chunk.NextOffset(),
+	}
+}
+
+// DetectDataObjectTextEncoding detects the text encoding of a data object from its leading content
+func DetectDataObjectTextEncoding(filesystem *irodsclient_fs.FileSystem, sourcePath string) (string, error) {
+	head, err := ReadDataObject(filesystem, sourcePath, 0, int64(charsetDetectSize))
+	if err != nil {
+		return "", err
+	}
+
+	return DetectTextEncoding(head, 0), nil
+}
+
+// DecodeTextLines converts lines in the encoding to UTF-8, lines split by '\n' bytes are not valid in UTF-16 so it is not supported
+func DecodeTextLines(lines []string, encodingName string, firstLine bool) ([]string, error) {
+	encodingName = strings.ToLower(strings.TrimSpace(encodingName))
+
+	switch encodingName {
+	case "", "utf8", TextEncodingUTF8:
+		if firstLine && len(lines) > 0 {
+			lines[0] = strings.TrimPrefix(lines[0], string(utf8BOM))
+		}
+		return lines, nil
+	case TextEncodingUTF16LE, TextEncodingUTF16BE, "utf-16":
+		return nil, errors.Newf("reading lines is not supported for %q encoding, read bytes instead", encodingName)
+	}
+
+	enc, err := GetTextEncoding(encodingName)
+	if err != nil {
+		return nil, err
+	}
+
+	decoder := enc.NewDecoder()
+	decodedLines := make([]string, 0, len(lines))
+	for _, line := range lines {
+		decoded, err := decoder.String(line)
+		if err != nil {
+			return nil, errors.Wrapf(err, "failed to decode text in %q", encodingName)
+		}
+		decodedLines = append(decodedLines, decoded)
+	}
+
+	return decodedLines, nil
+}
@@ -0,0 +1,257 @@
+package common
+
+import (
+	"bufio"
+	"bytes"
+	"compress/bzip2"
+	"compress/gzip"
+	"io"
+	"path"
+	"strings"
+
+	"github.com/cockroachdb/errors"
+	"github.com/klauspost/compress/zstd"
+)
+
+// CompressionFormat is a format of compressed files
+type CompressionFormat string
+
+const (
+	CompressionFormatGzip  CompressionFormat = "gzip"
+	CompressionFormatBzip2 CompressionFormat = "bzip2"
+	CompressionFormatZstd  CompressionFormat = "zstd"
+)
+
+const (
+	// CompressionMagicReadSize is the size of header to read to detect compression formats
+	CompressionMagicReadSize int = 4
+	// MaxDecompressedScanSize is the maximum size of decompressed data to scan when reading lines from the end
+	MaxDecompressedScanSize int64 = 1024 * 1024 * 1024 // 1GB
+
+	zstdMaxWindowSize uint64 = 128 * 1024 * 1024 // 128MB
+)
+
+var (
+	gzipMagic  []byte = []byte{0x1f, 0x8b}
+	bzip2Magic []byte = []byte("BZh")
+	zstdMagic  []byte = []byte{0x28, 0xb5, 0x2f, 0xfd}
+)
+
+// DetectCompressionFormat returns the compression format from the header of content, or the file extension if header is not given
+func DetectCompressionFormat(p string, header []byte) CompressionFormat {
+	if len(header) > 0 {
+		switch {
+		case bytes.HasPrefix(header, gzipMagic):
+			return CompressionFormatGzip
+		case bytes.HasPrefix(header, bzip2Magic):
+			return CompressionFormatBzip2
+		case bytes.HasPrefix(header, zstdMagic):
+			return CompressionFormatZstd
+		default:
+			return ""
+		}
+	}
+
+	switch strings.ToLower(path.Ext(p)) {
+	case ".gz", ".tgz":
+		return CompressionFormatGzip
+	case ".bz2":
+		return CompressionFormatBzip2
+	case ".zst":
+		return CompressionFormatZstd
+	default:
+		return ""
+	}
+}
+
+// TrimCompressionExtension returns the path without the extension of compression, used to detect the type of decompressed content
+func TrimCompressionExtension(p string) string {
+	ext := strings.ToLower(path.Ext(p))
+	switch ext {
+	case ".gz", ".bz2", ".zst":
+		return p[:len(p)-len(ext)]
+	case ".tgz":
+		return p[:len(p)-len(ext)] + ".tar"
+	default:
+		return p
+	}
+}
+
+// NewDecompressionReader returns a reader decompressing the content of reader
+func NewDecompressionReader(format CompressionFormat, reader io.Reader) (io.ReadCloser, error) {
+	switch format {
+	case CompressionFormatGzip:
+		gzipReader, err := gzip.NewReader(reader)
+		if err != nil {
+			return nil, errors.Wrapf(err, "failed to read gzip stream")
+		}
+		return gzipReader, nil
+	case CompressionFormatBzip2:
+		return io.NopCloser(bzip2.NewReader(reader)), nil
+	case CompressionFormatZstd:
+		// limit the window size so a crafted frame cannot allocate too much memory
+		zstdReader, err := zstd.NewReader(reader, zstd.WithDecoderConcurrency(1), zstd.WithDecoderMaxWindow(zstdMaxWindowSize))
+		if err != nil {
+			return nil, errors.Wrapf(err, "failed to read zstd stream")
+		}
+		return zstdReader.IOReadCloser(), nil
+	default:
+		return nil, errors.Newf("unknown compression format %q", format)
+	}
+}
+
+// BudgetReader reads up to the byte budget from the reader and counts bytes read
+type BudgetReader struct {
+	reader    io.Reader
+	bytesRead int64
+	maxBytes  int64
+}
+
+// NewBudgetReader creates a BudgetReader
+func NewBudgetReader(reader io.Reader, maxBytes int64) *BudgetReader {
+	return &BudgetReader{
+		reader:   reader,
+		maxBytes: maxBytes,
+	}
+}
+
+func (reader *BudgetReader) Read(p []byte) (int, error) {
+	if reader.bytesRead >= reader.maxBytes {
+		return 0, io.EOF
+	}
+
+	if int64(len(p)) > reader.maxBytes-reader.bytesRead {
+		p = p[:reader.maxBytes-reader.bytesRead]
+	}
+
+	n, err := reader.reader.Read(p)
+	reader.bytesRead += int64(n)
+	return n, err
+}
+
+// BytesRead returns the number of bytes read
+func (reader *BudgetReader) BytesRead() int64 {
+	return reader.bytesRead
+}
+
+// IsExhausted returns true if the budget is used up before reading all of the content of the given size
+func (reader *BudgetReader) IsExhausted(size int64) bool {
+	return reader.bytesRead >= reader.maxBytes && size > reader.maxBytes
+}
+
+// EstimateTotal estimates the total count for the content of the given size from the count within bytes read
+func (reader *BudgetReader) EstimateTotal(count int64, size int64) int64 {
+	if !reader.IsExhausted(size) || reader.bytesRead == 0 {
+		return count
+	}
+	return int64(float64(count) * float64(size) / float64(reader.bytesRead))
+}
+
+// ReadStream reads up to maxReadLen bytes from the reader after skipping offset bytes
+func ReadStream(reader io.Reader, offset int64, maxReadLen int64) ([]byte, error) {
+	if offset > 0 {
+		_, err := io.CopyN(io.Discard, reader, offset)
+		if err != nil {
+			if err == io.EOF {
+				return []byte{}, nil
+			}
+			return nil, errors.Wrapf(err, "failed to skip %d bytes", offset)
+		}
+	}
+
+	buffer := make([]byte, maxReadLen)
+	n, err := io.ReadFull(reader, buffer)
+	if err != nil && err != io.EOF && err != io.ErrUnexpectedEOF {
+		return nil, errors.Wrapf(err, "failed to read %d bytes at offset %d", maxReadLen, offset)
+	}
+
+	return buffer[:n], nil
+}
+
+// ReadStreamLines reads lines from startLine to endLine (1-based, inclusive) from the reader, or the last tail lines if tail is given.
+// Total size of lines is limited to maxReadLen. TotalLines is set only if the reader is read to the end.
+func ReadStreamLines(reader io.Reader, startLine int64, endLine int64, tail int64, maxReadLen int64) (*TextLines, error) {
+	if startLine < 1 {
+		startLine = 1
+	}
+
+	textLines := &TextLines{
+		StartLine: startLine,
+		EndLine:   startLine - 1,
+		Lines:     []string{},
+	}
+
+	bufferedReader := bufio.NewReaderSize(reader, lineIndexReadSize)
+	currentLine := int64(0)
+	scanned := int64(0)
+	readLen := int64(0)
+	head := 0 // index of the first kept line in tail mode
+
+	for {
+		if tail <= 0 && endLine > 0 && currentLine >= endLine {
+			// no need to read further, total line count is unknown
+			return textLines, nil
+		}
+
+		line, readErr := bufferedReader.ReadString('\n')
+		if len(line) == 0 && readErr != nil {
+			if readErr == io.EOF {
+				break
+			}
+
+			return nil, errors.Wrapf(readErr, "failed to read line %d", currentLine+1)
+		}
+
+		currentLine++
+		scanned += int64(len(line))
+		line = strings.TrimRight(line, "\r\n")
+
+		if tail > 0 {
+			if scanned > MaxDecompressedScanSize {
+				return nil, errors.Newf("content is too large to read lines from the end, maximum is %d bytes", MaxDecompressedScanSize)
+			}
+
+			// keep the last lines within the count and size limits
+			textLines.Lines = append(textLines.Lines, line)
+			readLen += int64(len(line))
+			for int64(len(textLines.Lines)-head) > tail || (readLen > maxReadLen && len(textLines.Lines)-head > 1) {
+				readLen -= int64(len(textLines.Lines[head]))
+				head++
+			}
+
+			if head > len(textLines.Lines)/2 {
+				textLines.Lines = append([]string{}, textLines.Lines[head:]...)
+				head = 0
+			}
+			continue
+		}
+
+		if currentLine >= startLine && !textLines.Truncated {
+			if readLen+int64(len(line)) > maxReadLen {
+				// stop here, total line count is unknown
+				textLines.Truncated = true
+				return textLines, nil
+			}
+
+			readLen += int64(len(line))
+			textLines.Lines = append(textLines.Lines, line)
+			textLines.EndLine = currentLine
+		}
+	}
+
+	textLines.TotalLines = currentLine
+
+	if tail > 0 {
+		textLines.Lines = textLines.Lines[head:]
+		if readLen > maxReadLen {
+			// a single line exceeds the size limit
+			textLines.Lines = []string{}
+		}
+
+		textLines.Truncated = int64(len(textLines.Lines)) < tail && int64(len(textLines.Lines)) < currentLine
+		textLines.StartLine = currentLine - int64(len(textLines.Lines)) + 1
+		textLines.EndLine = currentLine
+	}
+
+	return textLines, nil
+}
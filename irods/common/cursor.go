@@ -0,0 +1,55 @@
+package common
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"hash/crc32"
+
+	"github.com/cockroachdb/errors"
+)
+
+// PageCursor is the position of the next page of a paginated search, encoded as an opaque string
+type PageCursor struct {
+	Offset      int    `json:"o"`
+	Fingerprint string `json:"f"`
+}
+
+// MakePageFingerprint returns a short fingerprint of search parameters, so a cursor is not reused for a different search
+func MakePageFingerprint(params ...interface{}) string {
+	paramsJSON, _ := json.Marshal(params)
+	return fmt.Sprintf("%08x", crc32.ChecksumIEEE(paramsJSON))
+}
+
+// EncodePageCursor encodes the offset of the next page
+func EncodePageCursor(offset int, fingerprint string) string {
+	cursorJSON, _ := json.Marshal(PageCursor{
+		Offset:      offset,
+		Fingerprint: fingerprint,
+	})
+	return base64.RawURLEncoding.EncodeToString(cursorJSON)
+}
+
+// DecodePageCursor decodes a cursor and returns the offset of the next page
+func DecodePageCursor(cursorString string, fingerprint string) (int, error) {
+	cursorJSON, err := base64.RawURLEncoding.DecodeString(cursorString)
+	if err != nil {
+		return 0, errors.Wrapf(err, "invalid cursor")
+	}
+
+	cursor := PageCursor{}
+	err = json.Unmarshal(cursorJSON, &cursor)
+	if err != nil {
+		return 0, errors.Wrapf(err, "invalid cursor")
+	}
+
+	if cursor.Fingerprint != fingerprint {
+		return 0, errors.Newf("cursor was made for a different search")
+	}
+
+	if cursor.Offset < 0 {
+		return 0, errors.Newf("invalid cursor offset %d", cursor.Offset)
+	}
+
+	return cursor.Offset, nil
+}
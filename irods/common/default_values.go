@@ -20,6 +20,9 @@ const (
 	DefaultTreeScanMaxDepth int    = 3
 	MaxTreeScanDepth        int    = 10
 	IRODSScheme             string = "irods"
+	SavedQueryScheme        string = "irods-query"
+	MaxParallelism          int    = 10
+	MaxBatchItems           int    = 1000
 )
 
 func GetDefaultTCPBufferSize() int {
@@ -0,0 +1,525 @@
+package common
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/cockroachdb/errors"
+)
+
+const (
+	DefaultDiffContextLines int = 3
+	maxDiffEditDistance     int = 2000 // the trace of Myers' algorithm takes O(D^2) memory
+)
+
+// DiffOp is an operation on a line in diff
+type DiffOp int
+
+const (
+	DiffOpEqual DiffOp = iota
+	DiffOpDelete
+	DiffOpInsert
+)
+
+// DiffLine is a line with diff operation
+type DiffLine struct {
+	Op   DiffOp
+	Text string // includes the line ending if present
+}
+
+// SplitLines splits text into lines, each line keeps its line ending
+func SplitLines(text string) []string {
+	if len(text) == 0 {
+		return []string{}
+	}
+
+	lines := strings.SplitAfter(text, "\n")
+	if len(lines[len(lines)-1]) == 0 {
+		lines = lines[:len(lines)-1]
+	}
+	return lines
+}
+
+// DiffLines computes line diff between two lists of lines
+func DiffLines(oldLines []string, newLines []string) []DiffLine {
+	// common prefix
+	prefix := 0
+	for prefix < len(oldLines) && prefix < len(newLines) && oldLines[prefix] == newLines[prefix] {
+		prefix++
+	}
+
+	// common suffix
+	suffix := 0
+	for suffix < len(oldLines)-prefix && suffix < len(newLines)-prefix && oldLines[len(oldLines)-1-suffix] == newLines[len(newLines)-1-suffix] {
+		suffix++
+	}
+
+	diff := []DiffLine{}
+	for i := 0; i < prefix; i++ {
+		diff = append(diff, DiffLine{Op: DiffOpEqual, Text: oldLines[i]})
+	}
+
+	diff = append(diff, diffLinesMyers(oldLines[prefix:len(oldLines)-suffix], newLines[prefix:len(newLines)-suffix])...)
+
+	for i := len(oldLines) - suffix; i < len(oldLines); i++ {
+		diff = append(diff, DiffLine{Op: DiffOpEqual, Text: oldLines[i]})
+	}
+
+	return diff
+}
+
+// diffLinesMyers implements Myers' O(ND) diff algorithm, falls back to replacing all lines if the edit distance is too large
+func diffLinesMyers(a []string, b []string) []DiffLine {
+	n := len(a)
+	m := len(b)
+
+	if n == 0 || m == 0 {
+		return replaceAllLines(a, b)
+	}
+
+	maxD := n + m
+	if maxD > maxDiffEditDistance {
+		maxD = maxDiffEditDistance
+	}
+
+	offset := maxD + 1
+	v := make([]int, 2*maxD+3)
+	// trace[d] keeps v for k in [-d-1, d+1], the only diagonals read when backtracking step d
+	trace := [][]int32{}
+
+	found := false
+	for d := 0; d <= maxD && !found; d++ {
+		window := make([]int32, 2*d+3)
+		for i := range window {
+			window[i] = int32(v[offset-d-1+i])
+		}
+		trace = append(trace, window)
+
+		for k := -d; k <= d; k += 2 {
+			x := 0
+			if k == -d || (k != d && v[offset+k-1] < v[offset+k+1]) {
+				x = v[offset+k+1]
+			} else {
+				x = v[offset+k-1] + 1
+			}
+
+			y := x - k
+			for x < n && y < m && a[x] == b[y] {
+				x++
+				y++
+			}
+
+			v[offset+k] = x
+
+			if x >= n && y >= m {
+				found = true
+				break
+			}
+		}
+	}
+
+	if !found {
+		return replaceAllLines(a, b)
+	}
+
+	// backtrack
+	reversed := []DiffLine{}
+	x := n
+	y := m
+	for d := len(trace) - 1; d >= 0; d-- {
+		vd := trace[d]
+		base := d + 1 // index of diagonal 0 in the window
+		k := x - y
+
+		prevK := 0
+		if k == -d || (k != d && vd[base+k-1] < vd[base+k+1]) {
+			prevK = k + 1
+		} else {
+			prevK = k - 1
+		}
+
+		prevX := int(vd[base+prevK])
+		prevY := prevX - prevK
+
+		for x > prevX && y > prevY {
+			reversed = append(reversed, DiffLine{Op: DiffOpEqual, Text: a[x-1]})
+			x--
+			y--
+		}
+
+		if d > 0 {
+			if x == prevX {
+				reversed = append(reversed, DiffLine{Op: DiffOpInsert, Text: b[y-1]})
+			} else {
+				reversed = append(reversed, DiffLine{Op: DiffOpDelete, Text: a[x-1]})
+			}
+		}
+
+		x = prevX
+		y = prevY
+	}
+
+	diff := make([]DiffLine, len(reversed))
+	for i, line := range reversed {
+		diff[len(reversed)-1-i] = line
+	}
+
+	return diff
+}
+
+func replaceAllLines(a []string, b []string) []DiffLine {
+	diff := []DiffLine{}
+	for _, line := range a {
+		diff = append(diff, DiffLine{Op: DiffOpDelete, Text: line})
+	}
+	for _, line := range b {
+		diff = append(diff, DiffLine{Op: DiffOpInsert, Text: line})
+	}
+	return diff
+}
+
+// UnifiedDiff returns a unified diff between two texts, returns empty string if they are the same
+func UnifiedDiff(oldName string, newName string, oldText string, newText string, contextLines int) string {
+	if oldText == newText {
+		return ""
+	}
+
+	if contextLines < 0 {
+		contextLines = DefaultDiffContextLines
+	}
+
+	diff := DiffLines(SplitLines(oldText), SplitLines(newText))
+
+	sb := strings.Builder{}
+	sb.WriteString(fmt.Sprintf("--- %s\n", oldName))
+	sb.WriteString(fmt.Sprintf("+++ %s\n", newName))
+
+	// find changed line indices
+	changed := []int{}
+	for i, line := range diff {
+		if line.Op != DiffOpEqual {
+			changed = append(changed, i)
+		}
+	}
+
+	// line numbers before each diff line
+	oldLineNums := make([]int, len(diff)+1)
+	newLineNums := make([]int, len(diff)+1)
+	for i, line := range diff {
+		oldLineNums[i+1] = oldLineNums[i]
+		newLineNums[i+1] = newLineNums[i]
+		if line.Op != DiffOpInsert {
+			oldLineNums[i+1]++
+		}
+		if line.Op != DiffOpDelete {
+			newLineNums[i+1]++
+		}
+	}
+
+	idx := 0
+	for idx < len(changed) {
+		start := changed[idx] - contextLines
+		if start < 0 {
+			start = 0
+		}
+
+		end := changed[idx] + 1 + contextLines
+		idx++
+		for idx < len(changed) && changed[idx]-contextLines <= end {
+			end = changed[idx] + 1 + contextLines
+			idx++
+		}
+
+		if end > len(diff) {
+			end = len(diff)
+		}
+
+		oldStart := oldLineNums[start]
+		oldCount := oldLineNums[end] - oldLineNums[start]
+		newStart := newLineNums[start]
+		newCount := newLineNums[end] - newLineNums[start]
+
+		if oldCount > 0 {
+			oldStart++
+		}
+		if newCount > 0 {
+			newStart++
+		}
+
+		sb.WriteString(fmt.Sprintf("@@ -%d,%d +%d,%d @@\n", oldStart, oldCount, newStart, newCount))
+
+		for _, line := range diff[start:end] {
+			prefix := " "
+			switch line.Op {
+			case DiffOpDelete:
+				prefix = "-"
+			case DiffOpInsert:
+				prefix = "+"
+			}
+
+			sb.WriteString(prefix)
+			sb.WriteString(line.Text)
+			if !strings.HasSuffix(line.Text, "\n") {
+				sb.WriteString("\n\\ No newline at end of file\n")
+			}
+		}
+	}
+
+	return sb.String()
+}
+
+type diffHunk struct {
+	oldStart int
+	oldLines []string
+	newLines []string
+}
+
+// ApplyUnifiedDiff applies a unified diff to text, hunk start lines are not required to be accurate but line counts are
+func ApplyUnifiedDiff(text string, patch string) (string, error) {
+	hunks, err := parseUnifiedDiff(patch)
+	if err != nil {
+		return "", err
+	}
+
+	if len(hunks) == 0 {
+		return "", errors.Newf("no hunks found in the patch")
+	}
+
+	lines := SplitLines(text)
+
+	newline := "\n"
+	if len(lines) > 0 && strings.HasSuffix(lines[0], "\r\n") {
+		newline = "\r\n"
+	}
+
+	delta := 0
+	searchFrom := 0
+	for hunkIdx, hunk := range hunks {
+		expected := hunk.oldStart - 1 + delta
+		if expected < searchFrom {
+			expected = searchFrom
+		}
+
+		pos := findHunkPosition(lines, hunk.oldLines, expected, searchFrom)
+		if pos < 0 {
+			return "", errors.Newf("hunk #%d (starting at line %d) does not apply", hunkIdx+1, hunk.oldStart)
+		}
+
+		newLines := make([]string, len(hunk.newLines))
+		for i, line := range hunk.newLines {
+			newLines[i] = strings.ReplaceAll(line, "\n", newline)
+		}
+
+		if pos+len(hunk.oldLines) == len(lines) && len(hunk.oldLines) > 0 && len(newLines) > 0 {
+			// keep the end of text without line ending if the patch does not mark it
+			if !strings.HasSuffix(lines[len(lines)-1], "\n") && strings.HasSuffix(hunk.oldLines[len(hunk.oldLines)-1], "\n") {
+				newLines[len(newLines)-1] = strings.TrimRight(newLines[len(newLines)-1], "\r\n")
+			}
+		}
+
+		// replace the matched lines
+		replaced := []string{}
+		replaced = append(replaced, lines[:pos]...)
+		replaced = append(replaced, newLines...)
+		replaced = append(replaced, lines[pos+len(hunk.oldLines):]...)
+
+		delta += len(hunk.newLines) - len(hunk.oldLines)
+		searchFrom = pos + len(hunk.newLines)
+		lines = replaced
+	}
+
+	return strings.Join(lines, ""), nil
+}
+
+func findHunkPosition(lines []string, oldLines []string, expected int, minPos int) int {
+	maxPos := len(lines) - len(oldLines)
+	if maxPos < minPos {
+		return -1
+	}
+
+	if expected > maxPos {
+		expected = maxPos
+	}
+
+	for dist := 0; expected-dist >= minPos || expected+dist <= maxPos; dist++ {
+		if pos := expected - dist; pos >= minPos && pos <= maxPos && matchLines(lines[pos:], oldLines) {
+			return pos
+		}
+		if pos := expected + dist; dist > 0 && pos >= minPos && pos <= maxPos && matchLines(lines[pos:], oldLines) {
+			return pos
+		}
+	}
+
+	return -1
+}
+
+func matchLines(lines []string, expected []string) bool {
+	if len(lines) < len(expected) {
+		return false
+	}
+
+	for i, line := range expected {
+		if strings.TrimRight(lines[i], "\r\n") != strings.TrimRight(line, "\r\n") {
+			return false
+		}
+	}
+	return true
+}
+
+// parseUnifiedDiff reads hunks by the line counts in their headers
+// lines starting with "---", "+++" or "diff" are file headers only outside hunks, e.g., "-- comment" removed in a hunk is "--- comment"
+func parseUnifiedDiff(patch string) ([]diffHunk, error) {
+	hunks := []diffHunk{}
+	patchLines := strings.Split(strings.ReplaceAll(patch, "\r\n", "\n"), "\n")
+
+	var current *diffHunk
+	header := ""
+	oldRemaining := 0
+	newRemaining := 0
+	lastOp := byte(0)
+
+	for _, patchLine := range patchLines {
+		if strings.HasPrefix(patchLine, "\\") {
+			if current == nil || lastOp == 0 {
+				continue
+			}
+
+			// no newline at end of file, applies to the previous line
+			switch lastOp {
+			case ' ':
+				current.oldLines[len(current.oldLines)-1] = strings.TrimSuffix(current.oldLines[len(current.oldLines)-1], "\n")
+				current.newLines[len(current.newLines)-1] = strings.TrimSuffix(current.newLines[len(current.newLines)-1], "\n")
+			case '-':
+				current.oldLines[len(current.oldLines)-1] = strings.TrimSuffix(current.oldLines[len(current.oldLines)-1], "\n")
+			case '+':
+				current.newLines[len(current.newLines)-1] = strings.TrimSuffix(current.newLines[len(current.newLines)-1], "\n")
+			}
+			continue
+		}
+
+		if oldRemaining == 0 && newRemaining == 0 {
+			if !strings.HasPrefix(patchLine, "@@") {
+				// file headers and other lines between hunks
+				continue
+			}
+
+			oldStart, oldCount, newCount, err := parseHunkHeader(patchLine)
+			if err != nil {
+				return nil, err
+			}
+
+			if current != nil {
+				hunks = append(hunks, *current)
+			}
+
+			current = &diffHunk{
+				oldStart: oldStart,
+				oldLines: []string{},
+				newLines: []string{},
+			}
+			header = patchLine
+			oldRemaining = oldCount
+			newRemaining = newCount
+			lastOp = 0
+			continue
+		}
+
+		// an empty line is an empty context line whose leading space was stripped
+		op := byte(' ')
+		content := patchLine
+		if len(patchLine) > 0 {
+			op = patchLine[0]
+			content = patchLine[1:]
+		}
+
+		switch op {
+		case ' ':
+			if oldRemaining == 0 || newRemaining == 0 {
+				return nil, errors.Newf("hunk %q has more lines than its header counts", header)
+			}
+			current.oldLines = append(current.oldLines, content+"\n")
+			current.newLines = append(current.newLines, content+"\n")
+			oldRemaining--
+			newRemaining--
+		case '-':
+			if oldRemaining == 0 {
+				return nil, errors.Newf("hunk %q has more removed lines than its header counts", header)
+			}
+			current.oldLines = append(current.oldLines, content+"\n")
+			oldRemaining--
+		case '+':
+			if newRemaining == 0 {
+				return nil, errors.Newf("hunk %q has more added lines than its header counts", header)
+			}
+			current.newLines = append(current.newLines, content+"\n")
+			newRemaining--
+		default:
+			return nil, errors.Newf("invalid line in hunk %q", patchLine)
+		}
+		lastOp = op
+	}
+
+	if oldRemaining > 0 || newRemaining > 0 {
+		return nil, errors.Newf("hunk %q has fewer lines than its header counts", header)
+	}
+
+	if current != nil {
+		hunks = append(hunks, *current)
+	}
+
+	return hunks, nil
+}
+
+// parseHunkHeader returns the old start line and the old and new line counts of a hunk header
+func parseHunkHeader(header string) (int, int, int, error) {
+	// @@ -l,s +l,s @@
+	fields := strings.Fields(header)
+	if len(fields) < 3 || !strings.HasPrefix(fields[1], "-") || !strings.HasPrefix(fields[2], "+") {
+		return 0, 0, 0, errors.Newf("invalid hunk header %q", header)
+	}
+
+	oldStart, oldCount, err := parseHunkRange(strings.TrimPrefix(fields[1], "-"))
+	if err != nil {
+		return 0, 0, 0, errors.Wrapf(err, "invalid hunk header %q", header)
+	}
+
+	_, newCount, err := parseHunkRange(strings.TrimPrefix(fields[2], "+"))
+	if err != nil {
+		return 0, 0, 0, errors.Wrapf(err, "invalid hunk header %q", header)
+	}
+
+	if oldCount == 0 && newCount == 0 {
+		return 0, 0, 0, errors.Newf("empty hunk header %q", header)
+	}
+
+	if oldStart < 1 {
+		oldStart = 1
+	}
+
+	return oldStart, oldCount, newCount, nil
+}
+
+// parseHunkRange parses "l,s" of a hunk header, the count is 1 if omitted
+func parseHunkRange(hunkRange string) (int, int, error) {
+	startString, countString, hasCount := strings.Cut(hunkRange, ",")
+
+	start, err := strconv.Atoi(startString)
+	if err != nil {
+		return 0, 0, err
+	}
+
+	count := 1
+	if hasCount {
+		count, err = strconv.Atoi(countString)
+		if err != nil {
+			return 0, 0, err
+		}
+
+		if count < 0 {
+			return 0, 0, errors.Newf("negative line count %d", count)
+		}
+	}
+
+	return start, count, nil
+}
@@ -0,0 +1,561 @@
+package common
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/cockroachdb/errors"
+	irodsclient_fs "github.com/cyverse/go-irodsclient/fs"
+	irodsclient_common "github.com/cyverse/go-irodsclient/irods/common"
+	irodsclient_conn "github.com/cyverse/go-irodsclient/irods/connection"
+	irodsclient_message "github.com/cyverse/go-irodsclient/irods/message"
+	irodsclient_types "github.com/cyverse/go-irodsclient/irods/types"
+)
+
+// GenQuery select options, go-irodsclient does not define these
+const (
+	GenQuerySelectNormal int = 1
+	GenQuerySelectMin    int = 2
+	GenQuerySelectMax    int = 3
+	GenQuerySelectSum    int = 4
+	GenQuerySelectAvg    int = 5
+	GenQuerySelectCount  int = 6
+
+	GenQueryOrderBy     int = 0x400
+	GenQueryOrderByDesc int = 0x800
+)
+
+// GenQuery request options
+const (
+	GenQueryOptionReturnTotalRowCount int = 0x20
+	GenQueryOptionNoDistinct          int = 0x40
+	GenQueryOptionUpperCaseWhere      int = 0x200
+)
+
+// genQueryColumnNames maps column names, as used by iquest, to catalog column numbers
+var genQueryColumnNames = map[string]irodsclient_common.ICATColumnNumber{
+	"USER_NAME": irodsclient_common.ICAT_COLUMN_USER_NAME,
+	"USER_TYPE": irodsclient_common.ICAT_COLUMN_USER_TYPE,
+	"USER_ZONE": irodsclient_common.ICAT_COLUMN_USER_ZONE,
+
+	"RESC_NAME":       irodsclient_common.ICAT_COLUMN_R_RESC_NAME,
+	"RESC_ZONE_NAME":  irodsclient_common.ICAT_COLUMN_R_ZONE_NAME,
+	"RESC_TYPE_NAME":  irodsclient_common.ICAT_COLUMN_R_TYPE_NAME,
+	"RESC_CLASS_NAME": irodsclient_common.ICAT_COLUMN_R_CLASS_NAME,
+	"RESC_LOC":        irodsclient_common.ICAT_COLUMN_R_LOC,
+	"RESC_VAULT_PATH": irodsclient_common.ICAT_COLUMN_R_VAULT_PATH,
+	"RESC_STATUS":     irodsclient_common.ICAT_COLUMN_R_RESC_STATUS,
+	"RESC_PARENT":     irodsclient_common.ICAT_COLUMN_R_RESC_PARENT,
+
+	"DATA_ID":          irodsclient_common.ICAT_COLUMN_D_DATA_ID,
+	"DATA_NAME":        irodsclient_common.ICAT_COLUMN_DATA_NAME,
+	"DATA_REPL_NUM":    irodsclient_common.ICAT_COLUMN_DATA_REPL_NUM,
+	"DATA_TYPE_NAME":   irodsclient_common.ICAT_COLUMN_DATA_TYPE_NAME,
+	"DATA_SIZE":        irodsclient_common.ICAT_COLUMN_DATA_SIZE,
+	"DATA_RESC_NAME":   irodsclient_common.ICAT_COLUMN_D_RESC_NAME,
+	"DATA_PATH":        irodsclient_common.ICAT_COLUMN_D_DATA_PATH,
+	"DATA_OWNER_NAME":  irodsclient_common.ICAT_COLUMN_D_OWNER_NAME,
+	"DATA_OWNER_ZONE":  irodsclient_common.ICAT_COLUMN_D_OWNER_ZONE,
+	"DATA_REPL_STATUS": irodsclient_common.ICAT_COLUMN_D_REPL_STATUS,
+	"DATA_CHECKSUM":    irodsclient_common.ICAT_COLUMN_D_DATA_CHECKSUM,
+	"DATA_CREATE_TIME": irodsclient_common.ICAT_COLUMN_D_CREATE_TIME,
+	"DATA_MODIFY_TIME": irodsclient_common.ICAT_COLUMN_D_MODIFY_TIME,
+	"DATA_RESC_HIER":   irodsclient_common.ICAT_COLUMN_D_RESC_HIER,
+
+	"COLL_ID":          irodsclient_common.ICAT_COLUMN_COLL_ID,
+	"COLL_NAME":        irodsclient_common.ICAT_COLUMN_COLL_NAME,
+	"COLL_PARENT_NAME": irodsclient_common.ICAT_COLUMN_COLL_PARENT_NAME,
+	"COLL_OWNER_NAME":  irodsclient_common.ICAT_COLUMN_COLL_OWNER_NAME,
+	"COLL_OWNER_ZONE":  irodsclient_common.ICAT_COLUMN_COLL_OWNER_ZONE,
+	"COLL_INHERITANCE": irodsclient_common.ICAT_COLUMN_COLL_INHERITANCE,
+	"COLL_CREATE_TIME": irodsclient_common.ICAT_COLUMN_COLL_CREATE_TIME,
+	"COLL_MODIFY_TIME": irodsclient_common.ICAT_COLUMN_COLL_MODIFY_TIME,
+
+	"META_DATA_ATTR_NAME":  irodsclient_common.ICAT_COLUMN_META_DATA_ATTR_NAME,
+	"META_DATA_ATTR_VALUE": irodsclient_common.ICAT_COLUMN_META_DATA_ATTR_VALUE,
+	"META_DATA_ATTR_UNITS": irodsclient_common.ICAT_COLUMN_META_DATA_ATTR_UNITS,
+	"META_COLL_ATTR_NAME":  irodsclient_common.ICAT_COLUMN_META_COLL_ATTR_NAME,
+	"META_COLL_ATTR_VALUE": irodsclient_common.ICAT_COLUMN_META_COLL_ATTR_VALUE,
+	"META_COLL_ATTR_UNITS": irodsclient_common.ICAT_COLUMN_META_COLL_ATTR_UNITS,
+	"META_RESC_ATTR_NAME":  irodsclient_common.ICAT_COLUMN_META_RESC_ATTR_NAME,
+	"META_RESC_ATTR_VALUE": irodsclient_common.ICAT_COLUMN_META_RESC_ATTR_VALUE,
+	"META_RESC_ATTR_UNITS": irodsclient_common.ICAT_COLUMN_META_RESC_ATTR_UNITS,
+	"META_USER_ATTR_NAME":  irodsclient_common.ICAT_COLUMN_META_USER_ATTR_NAME,
+	"META_USER_ATTR_VALUE": irodsclient_common.ICAT_COLUMN_META_USER_ATTR_VALUE,
+	"META_USER_ATTR_UNITS": irodsclient_common.ICAT_COLUMN_META_USER_ATTR_UNITS,
+}
+
+// DefaultGenQueryColumns are columns of data objects, collections and their metadata that users may query
+// physical paths, hosts and ids are left out
+var DefaultGenQueryColumns = []string{
+	"DATA_NAME",
+	"DATA_REPL_NUM",
+	"DATA_TYPE_NAME",
+	"DATA_SIZE",
+	"DATA_RESC_NAME",
+	"DATA_OWNER_NAME",
+	"DATA_OWNER_ZONE",
+	"DATA_REPL_STATUS",
+	"DATA_CHECKSUM",
+	"DATA_CREATE_TIME",
+	"DATA_MODIFY_TIME",
+	"DATA_RESC_HIER",
+	"COLL_NAME",
+	"COLL_PARENT_NAME",
+	"COLL_OWNER_NAME",
+	"COLL_OWNER_ZONE",
+	"COLL_INHERITANCE",
+	"COLL_CREATE_TIME",
+	"COLL_MODIFY_TIME",
+	"META_DATA_ATTR_NAME",
+	"META_DATA_ATTR_VALUE",
+	"META_DATA_ATTR_UNITS",
+	"META_COLL_ATTR_NAME",
+	"META_COLL_ATTR_VALUE",
+	"META_COLL_ATTR_UNITS",
+}
+
+// GetGenQueryColumn returns the catalog column number of a column name, e.g. "DATA_SIZE"
+func GetGenQueryColumn(name string) (irodsclient_common.ICATColumnNumber, bool) {
+	column, ok := genQueryColumnNames[strings.ToUpper(strings.TrimSpace(name))]
+	return column, ok
+}
+
+// GenQueryColumn is a column to select with its select option (e.g. GenQuerySelectNormal|GenQueryOrderBy)
+type GenQueryColumn struct {
+	Column irodsclient_common.ICATColumnNumber
+	Option int
+}
+
+// GenQueryCondition is a condition on a column, e.g. "= 'value'" or "like '/zone/home/%'"
+type GenQueryCondition struct {
+	Column    irodsclient_common.ICATColumnNumber
+	Condition string
+}
+
+// GenQuery is a catalog query
+type GenQuery struct {
+	Selects    []GenQueryColumn
+	Conditions []GenQueryCondition
+	Options    int
+	Offset     int // number of rows to skip
+	Limit      int // maximum number of rows to return, 0 for no limit
+}
+
+// GenQueryResult is the result of a catalog query, values in a row follow the order of selects
+type GenQueryResult struct {
+	Rows          [][]string
+	TotalRowCount int // -1 if not requested
+	HasMore       bool
+}
+
+// QuoteGenQueryValue quotes a value for a GenQuery condition, GenQuery has no escape for single quotes
+func QuoteGenQueryValue(value string) (string, error) {
+	if strings.Contains(value, "'") {
+		return "", errors.Newf("value %q contains a single quote which is not supported in catalog queries", value)
+	}
+
+	return "'" + value + "'", nil
+}
+
+// EscapeGenQueryLike escapes wildcards of a like pattern with backslashes, so the value matches only itself
+func EscapeGenQueryLike(value string) string {
+	var sb strings.Builder
+	for _, c := range value {
+		switch c {
+		case '\\', '%', '_':
+			sb.WriteRune('\\')
+		}
+		sb.WriteRune(c)
+	}
+	return sb.String()
+}
+
+// MakeGenQueryPathCondition returns a condition matching collection paths and everything under them
+func MakeGenQueryPathCondition(collPaths ...string) (string, error) {
+	conditions := []string{}
+	for _, collPath := range collPaths {
+		collPath = strings.TrimSuffix(collPath, "/")
+		if len(collPath) == 0 {
+			return "like '/%'", nil
+		}
+
+		quotedPath, err := QuoteGenQueryValue(collPath)
+		if err != nil {
+			return "", err
+		}
+
+		// '_' in a path, e.g. /zone/home/john_doe, must not match any character
+		quotedPrefix, err := QuoteGenQueryValue(EscapeGenQueryLike(collPath) + "/%")
+		if err != nil {
+			return "", err
+		}
+
+		conditions = append(conditions, fmt.Sprintf("= %s || like %s", quotedPath, quotedPrefix))
+	}
+
+	if len(conditions) == 0 {
+		return "", errors.Newf("no collection path is given")
+	}
+
+	return strings.Join(conditions, " || "), nil
+}
+
+// MakeGenQueryValueCondition returns a condition comparing a column with values, e.g. "between '1' '9'"
+// operator is one of =, !=, <, <=, >, >=, like, not like, between (two values) and in (one or more values)
+func MakeGenQueryValueCondition(operator string, value string, values []string) (string, error) {
+	switch operator {
+	case "between":
+		if len(values) != 2 {
+			return "", errors.Newf("operator %q requires two values, lower and upper bounds", operator)
+		}
+
+		lower, err := QuoteGenQueryValue(values[0])
+		if err != nil {
+			return "", err
+		}
+		upper, err := QuoteGenQueryValue(values[1])
+		if err != nil {
+			return "", err
+		}
+		return fmt.Sprintf("between %s %s", lower, upper), nil
+	case "in":
+		if len(values) == 0 {
+			return "", errors.Newf("operator %q requires values", operator)
+		}
+
+		quotedValues := make([]string, 0, len(values))
+		for _, v := range values {
+			quotedValue, err := QuoteGenQueryValue(v)
+			if err != nil {
+				return "", err
+			}
+			quotedValues = append(quotedValues, quotedValue)
+		}
+		return fmt.Sprintf("in (%s)", strings.Join(quotedValues, ", ")), nil
+	case "=", "!=", "<", "<=", ">", ">=", "like", "not like":
+		quotedValue, err := QuoteGenQueryValue(value)
+		if err != nil {
+			return "", err
+		}
+
+		if operator == "!=" {
+			operator = "<>"
+		}
+		return operator + " " + quotedValue, nil
+	default:
+		return "", errors.Newf("unknown operator %q", operator)
+	}
+}
+
+// MakeGenQueryTime returns a time value as stored in the catalog, zero-padded seconds since epoch
+func MakeGenQueryTime(t time.Time) string {
+	return fmt.Sprintf("%011d", t.Unix())
+}
+
+// GlobToGenQueryLike converts a unix wildcard pattern to a like pattern, exact is false if the like pattern matches more than the glob
+// literal '%', '_' and '\' are escaped, '\' in the glob escapes the next character
+// '%' and '_' in the like pattern also match '/', use MakeGenQueryDepthCondition to match within path segments like the glob
+func GlobToGenQueryLike(glob string) (string, bool) {
+	var sb strings.Builder
+	exact := true
+
+	inClass := false
+	escaped := false
+	for _, c := range glob {
+		if inClass {
+			if c == ']' {
+				inClass = false
+			}
+			continue
+		}
+
+		if escaped {
+			sb.WriteString(EscapeGenQueryLike(string(c)))
+			escaped = false
+			continue
+		}
+
+		switch c {
+		case '\\':
+			escaped = true
+		case '*':
+			sb.WriteRune('%')
+		case '?':
+			sb.WriteRune('_')
+		case '[':
+			// character class matches a single character
+			sb.WriteRune('_')
+			inClass = true
+			exact = false
+		default:
+			sb.WriteString(EscapeGenQueryLike(string(c)))
+		}
+	}
+
+	if escaped {
+		// trailing backslash
+		sb.WriteString(EscapeGenQueryLike("\\"))
+	}
+
+	return sb.String(), exact
+}
+
+// MakeGenQueryDepthCondition returns a condition rejecting paths with more '/' than the like pattern of a path
+// with the like condition, wildcards match within path segments, as fnmatch with FNM_PATHNAME
+func MakeGenQueryDepthCondition(likePattern string) (string, error) {
+	deeperPattern := "%" + strings.Repeat("/%", strings.Count(likePattern, "/")+1)
+
+	quotedPattern, err := QuoteGenQueryValue(deeperPattern)
+	if err != nil {
+		return "", err
+	}
+
+	return "not like " + quotedPattern, nil
+}
+
+// GlobToRegexp converts a unix wildcard pattern to a regular expression, '*', '?' and character classes do not match '/'
+func GlobToRegexp(glob string, caseInsensitive bool) (*regexp.Regexp, error) {
+	var sb strings.Builder
+	if caseInsensitive {
+		sb.WriteString("(?i)")
+	}
+	sb.WriteString("^")
+
+	inClass := false
+	classStart := false
+	escaped := false
+	for _, c := range glob {
+		if inClass {
+			if classStart && (c == '!' || c == '^') {
+				// negated class
+				sb.WriteString("^/")
+				classStart = false
+				continue
+			}
+			classStart = false
+
+			switch c {
+			case ']':
+				inClass = false
+				sb.WriteRune(c)
+			case '\\':
+				sb.WriteString(`\\`)
+			default:
+				sb.WriteRune(c)
+			}
+			continue
+		}
+
+		if escaped {
+			sb.WriteString(regexp.QuoteMeta(string(c)))
+			escaped = false
+			continue
+		}
+
+		switch c {
+		case '\\':
+			escaped = true
+		case '*':
+			sb.WriteString("[^/]*")
+		case '?':
+			sb.WriteString("[^/]")
+		case '[':
+			sb.WriteRune(c)
+			inClass = true
+			classStart = true
+		default:
+			sb.WriteString(regexp.QuoteMeta(string(c)))
+		}
+	}
+
+	if inClass {
+		return nil, errors.Newf("unterminated character class in pattern %q", glob)
+	}
+
+	if escaped {
+		// trailing backslash
+		sb.WriteString(`\\`)
+	}
+
+	sb.WriteString("$")
+
+	re, err := regexp.Compile(sb.String())
+	if err != nil {
+		return nil, errors.Wrapf(err, "invalid pattern %q", glob)
+	}
+
+	return re, nil
+}
+
+// RunGenQuery runs a catalog query and returns rows within offset and limit
+func RunGenQuery(filesystem *irodsclient_fs.FileSystem, query *GenQuery) (*GenQueryResult, error) {
+	if len(query.Selects) == 0 {
+		return nil, errors.Newf("no column to select")
+	}
+
+	columnIndex := map[int]int{}
+	for idx, sel := range query.Selects {
+		columnIndex[int(sel.Column)] = idx
+	}
+
+	conn, err := filesystem.GetMetadataConnection(true)
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to get connection")
+	}
+	defer filesystem.ReturnMetadataConnection(conn)
+
+	conn.Lock()
+	defer conn.Unlock()
+
+	result := &GenQueryResult{
+		Rows:          [][]string{},
+		TotalRowCount: -1,
+	}
+
+	continueIndex := 0
+	first := true
+	for {
+		maxRows := irodsclient_common.MaxQueryRows
+		if query.Limit > 0 {
+			// ask for one more row to tell if there are more
+			remaining := query.Limit - len(result.Rows) + 1
+			if remaining < maxRows {
+				maxRows = remaining
+			}
+		}
+
+		offset := 0
+		if first {
+			offset = query.Offset
+		}
+
+		request := irodsclient_message.NewIRODSMessageQueryRequest(maxRows, continueIndex, offset, query.Options)
+		request.AddKeyVal(irodsclient_common.ZONE_KW, conn.GetAccount().ClientZone)
+		for _, sel := range query.Selects {
+			request.AddSelect(sel.Column, sel.Option)
+		}
+		for _, cond := range query.Conditions {
+			request.AddCondition(cond.Column, cond.Condition)
+		}
+
+		response := irodsclient_message.IRODSMessageQueryResponse{}
+		err = conn.Request(request, &response, nil, conn.GetLongResponseOperationTimeout())
+		if err == nil {
+			err = response.CheckError()
+		}
+
+		if err != nil {
+			if irodsclient_types.GetIRODSErrorCode(err) == irodsclient_common.CAT_NO_ROWS_FOUND {
+				if first && query.Options&GenQueryOptionReturnTotalRowCount != 0 {
+					if offset == 0 {
+						result.TotalRowCount = 0
+					} else {
+						// the offset is past the last row, count from the start
+						totalRowCount, err := countGenQueryRows(conn, query)
+						if err != nil {
+							return nil, err
+						}
+						result.TotalRowCount = totalRowCount
+					}
+				}
+				return result, nil
+			}
+			return nil, errors.Wrapf(err, "failed to run catalog query")
+		}
+
+		if first && query.Options&GenQueryOptionReturnTotalRowCount != 0 {
+			result.TotalRowCount = response.TotalRowCount
+		}
+		first = false
+
+		if response.AttributeCount > len(response.SQLResult) {
+			return nil, errors.Newf("failed to receive query attributes - requires %d, but received %d attributes", response.AttributeCount, len(response.SQLResult))
+		}
+
+		rows := make([][]string, response.RowCount)
+		for row := range rows {
+			rows[row] = make([]string, len(query.Selects))
+		}
+
+		for attr := 0; attr < response.AttributeCount; attr++ {
+			sqlResult := response.SQLResult[attr]
+			if len(sqlResult.Values) != response.RowCount {
+				return nil, errors.Newf("failed to receive query rows - requires %d, but received %d rows", response.RowCount, len(sqlResult.Values))
+			}
+
+			idx, ok := columnIndex[sqlResult.AttributeIndex]
+			if !ok {
+				continue
+			}
+
+			for row := 0; row < response.RowCount; row++ {
+				rows[row][idx] = sqlResult.Values[row]
+			}
+		}
+
+		result.Rows = append(result.Rows, rows...)
+		continueIndex = response.ContinueIndex
+
+		if query.Limit > 0 && len(result.Rows) > query.Limit {
+			result.Rows = result.Rows[:query.Limit]
+			result.HasMore = true
+			break
+		}
+
+		if continueIndex == 0 || response.RowCount == 0 {
+			break
+		}
+	}
+
+	if continueIndex != 0 {
+		closeGenQuery(conn, query, continueIndex)
+	}
+
+	return result, nil
+}
+
+// countGenQueryRows returns the total row count of a query by reading its first row
+func countGenQueryRows(conn *irodsclient_conn.IRODSConnection, query *GenQuery) (int, error) {
+	request := irodsclient_message.NewIRODSMessageQueryRequest(1, 0, 0, query.Options|GenQueryOptionReturnTotalRowCount)
+	request.AddKeyVal(irodsclient_common.ZONE_KW, conn.GetAccount().ClientZone)
+	for _, sel := range query.Selects {
+		request.AddSelect(sel.Column, sel.Option)
+	}
+	for _, cond := range query.Conditions {
+		request.AddCondition(cond.Column, cond.Condition)
+	}
+
+	response := irodsclient_message.IRODSMessageQueryResponse{}
+	err := conn.Request(request, &response, nil, conn.GetLongResponseOperationTimeout())
+	if err == nil {
+		err = response.CheckError()
+	}
+
+	if err != nil {
+		if irodsclient_types.GetIRODSErrorCode(err) == irodsclient_common.CAT_NO_ROWS_FOUND {
+			return 0, nil
+		}
+		return 0, errors.Wrapf(err, "failed to count catalog query rows")
+	}
+
+	if response.ContinueIndex != 0 {
+		closeGenQuery(conn, query, response.ContinueIndex)
+	}
+
+	return response.TotalRowCount, nil
+}
+
+// closeGenQuery closes the statement on the server, otherwise it stays open until the connection ends
+func closeGenQuery(conn *irodsclient_conn.IRODSConnection, query *GenQuery, continueIndex int) {
+	request := irodsclient_message.NewIRODSMessageQueryRequest(0, continueIndex, 0, 0)
+	request.AddKeyVal(irodsclient_common.ZONE_KW, conn.GetAccount().ClientZone)
+	for _, sel := range query.Selects {
+		request.AddSelect(sel.Column, sel.Option)
+	}
+
+	response := irodsclient_message.IRODSMessageQueryResponse{}
+	_ = conn.Request(request, &response, nil, conn.GetLongResponseOperationTimeout())
+}
@@ -0,0 +1,179 @@
+package common
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/cockroachdb/errors"
+	irodsclient_fs "github.com/cyverse/go-irodsclient/fs"
+	irodsclient_common "github.com/cyverse/go-irodsclient/irods/common"
+	irodsclient_irodsfs "github.com/cyverse/go-irodsclient/irods/fs"
+	irodsclient_message "github.com/cyverse/go-irodsclient/irods/message"
+	irodsclient_types "github.com/cyverse/go-irodsclient/irods/types"
+	irodsclient_util "github.com/cyverse/go-irodsclient/irods/util"
+)
+
+// ChecksumRequestOption is an option for computing checksum on the server
+type ChecksumRequestOption struct {
+	Force         bool   // recompute checksum even if it is already in the catalog
+	Verify        bool   // recompute checksum and compare with the catalog, without updating
+	AllReplicas   bool   // apply to all replicas
+	ReplicaNumber *int64 // apply to a specific replica
+}
+
+// GetEntryChecksum returns the iRODS checksum string recorded in the entry, or empty string if not available
+func GetEntryChecksum(entry *irodsclient_fs.Entry) string {
+	if entry == nil || len(entry.CheckSum) == 0 {
+		return ""
+	}
+
+	checksumString, err := irodsclient_types.MakeIRODSChecksumString(entry.CheckSumAlgorithm, entry.CheckSum)
+	if err != nil {
+		return ""
+	}
+
+	return checksumString
+}
+
+// GetDataObjectChecksum returns the iRODS checksum string of a data object, the server computes it if missing
+func GetDataObjectChecksum(filesystem *irodsclient_fs.FileSystem, irodsPath string) (string, error) {
+	conn, err := filesystem.GetMetadataConnection(true)
+	if err != nil {
+		return "", errors.Wrapf(err, "failed to get connection")
+	}
+	defer filesystem.ReturnMetadataConnection(conn)
+
+	checksum, err := irodsclient_irodsfs.GetDataObjectChecksum(conn, irodsPath, "")
+	if err != nil {
+		return "", errors.Wrapf(err, "failed to get checksum of file %q", irodsPath)
+	}
+
+	return checksum.IRODSChecksumString, nil
+}
+
+// IsSameChecksum checks if two iRODS checksum strings are the same, the algorithm prefix is optional
+func IsSameChecksum(checksum1 string, checksum2 string) bool {
+	if len(checksum1) == 0 || len(checksum2) == 0 {
+		return false
+	}
+
+	if checksum1 == checksum2 {
+		return true
+	}
+
+	return trimChecksumAlgorithm(checksum1) == trimChecksumAlgorithm(checksum2)
+}
+
+func trimChecksumAlgorithm(checksum string) string {
+	idx := strings.Index(checksum, ":")
+	if idx >= 0 {
+		return checksum[idx+1:]
+	}
+	return checksum
+}
+
+// ComputeDataObjectChecksum asks the server to compute, or verify, the checksum of a data object
+func ComputeDataObjectChecksum(filesystem *irodsclient_fs.FileSystem, irodsPath string, option *ChecksumRequestOption) (*irodsclient_types.IRODSChecksum, error) {
+	conn, err := filesystem.GetMetadataConnection(true)
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to get connection")
+	}
+	defer filesystem.ReturnMetadataConnection(conn)
+
+	request := irodsclient_message.NewIRODSMessageChecksumRequest(irodsPath, "")
+	if option != nil {
+		if option.Force {
+			request.AddKeyVal(irodsclient_common.FORCE_CHKSUM_KW, "")
+		}
+
+		if option.Verify {
+			request.AddKeyVal(irodsclient_common.VERIFY_CHKSUM_KW, "")
+		}
+
+		if option.AllReplicas {
+			request.AddKeyVal(irodsclient_common.CHKSUM_ALL_KW, "")
+		} else if option.ReplicaNumber != nil {
+			request.AddKeyVal(irodsclient_common.REPL_NUM_KW, fmt.Sprintf("%d", *option.ReplicaNumber))
+		}
+	}
+
+	conn.Lock()
+	defer conn.Unlock()
+
+	response := irodsclient_message.IRODSMessageChecksumResponse{}
+	err = conn.RequestAndCheck(request, &response, nil, conn.GetLongResponseOperationTimeout())
+	if err != nil {
+		if irodsclient_types.GetIRODSErrorCode(err) == irodsclient_common.USER_CHKSUM_MISMATCH {
+			return nil, errors.Wrapf(err, "checksum mismatch for file %q", irodsPath)
+		}
+		return nil, errors.Wrapf(err, "failed to compute checksum of file %q", irodsPath)
+	}
+
+	checksum, err := irodsclient_types.CreateIRODSChecksum(response.Checksum)
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to parse checksum of file %q", irodsPath)
+	}
+
+	return checksum, nil
+}
+
+// GetDataObjectReplicas returns replicas of a data object with their catalog checksums
+func GetDataObjectReplicas(filesystem *irodsclient_fs.FileSystem, irodsPath string) ([]*irodsclient_types.IRODSReplica, error) {
+	dataObject, err := GetDataObjectNoCache(filesystem, irodsPath)
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to get replicas of file %q", irodsPath)
+	}
+
+	return dataObject.Replicas, nil
+}
+
+// GetDataObjectNoCache returns the data object from the catalog, bypassing the cache of the filesystem
+func GetDataObjectNoCache(filesystem *irodsclient_fs.FileSystem, irodsPath string) (*irodsclient_types.IRODSDataObject, error) {
+	conn, err := filesystem.GetMetadataConnection(true)
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to get connection")
+	}
+	defer filesystem.ReturnMetadataConnection(conn)
+
+	dataObject, err := irodsclient_irodsfs.GetDataObject(conn, irodsPath)
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to get data object %q", irodsPath)
+	}
+
+	return dataObject, nil
+}
+
+// StatFileNoCache returns the entry of a data object from the catalog, bypassing the cache of the filesystem
+func StatFileNoCache(filesystem *irodsclient_fs.FileSystem, irodsPath string) (*irodsclient_fs.Entry, error) {
+	dataObject, err := GetDataObjectNoCache(filesystem, irodsPath)
+	if err != nil {
+		return nil, err
+	}
+
+	if dataObject.ID <= 0 || len(dataObject.Replicas) == 0 {
+		newErr := irodsclient_types.NewFileNotFoundError(irodsPath)
+		return nil, errors.Wrapf(newErr, "failed to find file %q", irodsPath)
+	}
+
+	return irodsclient_fs.NewEntryFromDataObject(dataObject), nil
+}
+
+// HashLocalFile computes the iRODS checksum string of a local file with the algorithm of the given iRODS checksum string
+func HashLocalFile(localPath string, irodsChecksum string) (string, error) {
+	algorithm, _, err := irodsclient_types.ParseIRODSChecksumString(irodsChecksum)
+	if err != nil {
+		return "", errors.Wrapf(err, "failed to parse checksum %q", irodsChecksum)
+	}
+
+	hash, err := irodsclient_util.HashLocalFile(localPath, string(algorithm), nil)
+	if err != nil {
+		return "", errors.Wrapf(err, "failed to hash local file %q", localPath)
+	}
+
+	checksumString, err := irodsclient_types.MakeIRODSChecksumString(algorithm, hash)
+	if err != nil {
+		return "", errors.Wrapf(err, "failed to make checksum string for local file %q", localPath)
+	}
+
+	return checksumString, nil
+}
@@ -1,10 +1,16 @@
 package common
 
 import (
+	"fmt"
 	"io"
+	"path"
+	"time"
 
 	"github.com/cockroachdb/errors"
 	irodsclient_fs "github.com/cyverse/go-irodsclient/fs"
+	irodsclient_common "github.com/cyverse/go-irodsclient/irods/common"
+	irodsclient_irodsfs "github.com/cyverse/go-irodsclient/irods/fs"
+	irodsclient_types "github.com/cyverse/go-irodsclient/irods/types"
 )
 
 const (
@@ -37,14 +43,139 @@ func ReadDataObject(filesystem *irodsclient_fs.FileSystem, sourcePath string, of
 	return buffer[:n], nil
 }
 
-func WriteDataObject(filesystem *irodsclient_fs.FileSystem, destPath string, offset int64, content []byte) error {
-	handle, err := filesystem.OpenFile(destPath, "", "w")
+// WriteMode determines how content is written to a data object
+type WriteMode string
+
+const (
+	// WriteModeCreateNew creates a new data object, fails if it already exists
+	WriteModeCreateNew WriteMode = "create_new"
+	// WriteModeOverwrite replaces the entire content of a data object, creates if it does not exist
+	WriteModeOverwrite WriteMode = "overwrite"
+	// WriteModeAppend writes content at the end of a data object, creates if it does not exist
+	WriteModeAppend WriteMode = "append"
+	// WriteModePatch overwrites bytes at the offset without changing the rest of a data object
+	WriteModePatch WriteMode = "patch"
+	// WriteModeTruncate discards content after the offset, then writes content at the offset
+	WriteModeTruncate WriteMode = "truncate"
+)
+
+// GetWriteModes returns all write modes
+func GetWriteModes() []WriteMode {
+	return []WriteMode{
+		WriteModeCreateNew,
+		WriteModeOverwrite,
+		WriteModeAppend,
+		WriteModePatch,
+		WriteModeTruncate,
+	}
+}
+
+// WritePrecondition is a set of conditions that must hold for an existing data object before writing
+type WritePrecondition struct {
+	Checksum   string `json:"checksum,omitempty"`
+	ModifyTime string `json:"modify_time,omitempty"`
+}
+
+// IsEmpty returns true if no condition is set
+func (precondition *WritePrecondition) IsEmpty() bool {
+	return precondition == nil || (len(precondition.Checksum) == 0 && len(precondition.ModifyTime) == 0)
+}
+
+// CheckWritePrecondition checks if the data object satisfies the precondition
+// the data object is read from the catalog, as the cached entry may miss writes by other clients
+func CheckWritePrecondition(filesystem *irodsclient_fs.FileSystem, irodsPath string, precondition *WritePrecondition) error {
+	if precondition.IsEmpty() {
+		return nil
+	}
+
+	entry, err := StatFileNoCache(filesystem, irodsPath)
+	if err != nil {
+		if irodsclient_types.IsFileNotFoundError(err) {
+			return errors.Newf("precondition failed, file %q does not exist", irodsPath)
+		}
+		return err
+	}
+
+	if len(precondition.ModifyTime) > 0 {
+		modifyTime, err := time.Parse(time.RFC3339, precondition.ModifyTime)
+		if err != nil {
+			return errors.Wrapf(err, "failed to parse modify time %q, must be in RFC3339 format", precondition.ModifyTime)
+		}
+
+		if !entry.ModifyTime.Truncate(time.Second).Equal(modifyTime.Truncate(time.Second)) {
+			return errors.Newf("precondition failed, file %q was modified at %s, expected %s", entry.Path, entry.ModifyTime.Format(time.RFC3339), precondition.ModifyTime)
+		}
+	}
+
+	if len(precondition.Checksum) > 0 {
+		checksum := GetEntryChecksum(entry)
+		if len(checksum) == 0 {
+			// not computed yet
+			serverChecksum, err := GetDataObjectChecksum(filesystem, entry.Path)
+			if err != nil {
+				return err
+			}
+
+			checksum = serverChecksum
+		}
+
+		if !IsSameChecksum(checksum, precondition.Checksum) {
+			return errors.Newf("precondition failed, file %q has checksum %q, expected %q", entry.Path, checksum, precondition.Checksum)
+		}
+	}
+
+	return nil
+}
+
+// WriteDataObject writes content to a data object in the given mode, offset is only used in patch and truncate modes
+func WriteDataObject(filesystem *irodsclient_fs.FileSystem, destPath string, mode WriteMode, offset int64, content []byte) error {
+	openMode := irodsclient_types.FileOpenModeWriteOnly
+
+	switch mode {
+	case WriteModeCreateNew:
+		err := createDataObjectExclusive(filesystem, destPath)
+		if err != nil {
+			return err
+		}
+		offset = 0
+	case WriteModeOverwrite:
+		openMode = irodsclient_types.FileOpenModeWriteTruncate
+		offset = 0
+	case WriteModeAppend:
+		openMode = irodsclient_types.FileOpenModeAppend
+	case WriteModePatch, WriteModeTruncate:
+		openMode = irodsclient_types.FileOpenModeReadWrite
+	default:
+		return errors.Newf("unknown write mode %q", mode)
+	}
+
+	handle, err := filesystem.OpenFile(destPath, "", string(openMode))
 	if err != nil {
 		return errors.Wrapf(err, "failed to open file %q", destPath)
 	}
 	defer handle.Close()
 
+	if mode == WriteModeTruncate {
+		err = handle.Truncate(offset)
+		if err != nil {
+			return errors.Wrapf(err, "failed to truncate file %q at offset %d", destPath, offset)
+		}
+	}
+
+	if len(content) == 0 {
+		return nil
+	}
+
 	// write the file content
+	if mode == WriteModeAppend {
+		_, err = handle.Write(content)
+		if err != nil {
+			return errors.Wrapf(err, "failed to append to file %q length %d", destPath, len(content))
+		}
+
+		return nil
+	}
+
 	_, err = handle.WriteAt(content, offset)
 	if err != nil {
 		return errors.Wrapf(err, "failed to write file %q at offset %d length %d", destPath, offset, len(content))
@@ -52,3 +183,76 @@ func WriteDataObject(filesystem *irodsclient_fs.FileSystem, destPath string, off
 
 	return nil
 }
+
+// createDataObjectExclusive creates an empty data object, fails if it already exists
+// the catalog checks the existence when creating, so concurrent writers cannot both create the data object
+func createDataObjectExclusive(filesystem *irodsclient_fs.FileSystem, destPath string) error {
+	conn, err := filesystem.GetIOConnection(false)
+	if err != nil {
+		return errors.Wrapf(err, "failed to get connection")
+	}
+	defer filesystem.ReturnIOConnection(conn)
+
+	handle, err := irodsclient_irodsfs.CreateDataObject(conn, destPath, "", string(irodsclient_types.FileOpenModeWriteOnly), false, map[irodsclient_common.KeyWord]string{})
+	if err != nil {
+		switch irodsclient_types.GetIRODSErrorCode(err) {
+		case irodsclient_common.OVERWRITE_WITHOUT_FORCE_FLAG, irodsclient_common.CAT_NAME_EXISTS_AS_DATAOBJ:
+			return errors.Newf("file %q already exists", destPath)
+		}
+		return errors.Wrapf(err, "failed to create file %q", destPath)
+	}
+
+	err = irodsclient_irodsfs.CloseDataObject(conn, handle)
+	if err != nil {
+		return errors.Wrapf(err, "failed to close file %q", destPath)
+	}
+
+	return nil
+}
+
+// MakeTempDataObjectPath returns a path for a temporary data object in the same directory (collection) of the given path
+func MakeTempDataObjectPath(irodsPath string, suffix string) string {
+	dir := GetIRODSPathDirname(irodsPath)
+	name := GetIRODSPathBasename(irodsPath)
+	return path.Join(dir, fmt.Sprintf(".%s.%s-%d", name, suffix, time.Now().UnixNano()))
+}
+
+// ReplaceDataObject replaces a data object with another data object by renaming, AVUs and ACLs of the replaced data object are carried over
+func ReplaceDataObject(filesystem *irodsclient_fs.FileSystem, srcPath string, destPath string) error {
+	// carry over AVUs
+	err := CopyMetadata(filesystem, destPath, srcPath, true)
+	if err != nil {
+		return err
+	}
+
+	// carry over ACLs
+	err = CopyACLs(filesystem, destPath, srcPath)
+	if err != nil {
+		return err
+	}
+
+	// swap
+	backupPath := MakeTempDataObjectPath(destPath, "backup")
+	err = filesystem.RenameFileToFile(destPath, backupPath)
+	if err != nil {
+		return errors.Wrapf(err, "failed to rename file %q to %q", destPath, backupPath)
+	}
+
+	err = filesystem.RenameFileToFile(srcPath, destPath)
+	if err != nil {
+		// restore
+		restoreErr := filesystem.RenameFileToFile(backupPath, destPath)
+		if restoreErr != nil {
+			return errors.Wrapf(err, "failed to rename file %q to %q, original file is left at %q", srcPath, destPath, backupPath)
+		}
+
+		return errors.Wrapf(err, "failed to rename file %q to %q", srcPath, destPath)
+	}
+
+	err = filesystem.RemoveFile(backupPath, true)
+	if err != nil {
+		return errors.Wrapf(err, "failed to remove backup file %q", backupPath)
+	}
+
+	return nil
+}
@@ -1,24 +1,216 @@
 package common
 
+import (
+	"github.com/cockroachdb/errors"
+	irodsclient_fs "github.com/cyverse/go-irodsclient/fs"
+	irodsclient_types "github.com/cyverse/go-irodsclient/irods/types"
+)
+
 var (
-	systemAttributes   []string        = []string{"ipc_UUID"}
-	systemAttributeMap map[string]bool = map[string]bool{}
+	systemAttributes []string = []string{"ipc_UUID"}
+	// filled before use, read concurrently by parallel workers
+	systemAttributeMap map[string]bool = makeSystemAttributeMap()
 )
 
+func makeSystemAttributeMap() map[string]bool {
+	attributeMap := map[string]bool{}
+	for _, a := range systemAttributes {
+		attributeMap[a] = true
+	}
+	return attributeMap
+}
+
 func GetSystemAttributes() []string {
 	return systemAttributes
 }
 
 func IsSystemAttribute(attr string) bool {
-	if len(systemAttributeMap) != len(systemAttributes) {
-		for _, a := range systemAttributes {
-			systemAttributeMap[a] = true
-		}
-	}
-
 	if _, ok := systemAttributeMap[attr]; ok {
 		// has it
 		return true
 	}
 	return false
 }
+
+func makeMetaKey(meta *irodsclient_types.IRODSMeta) string {
+	return meta.Name + "\x00" + meta.Value + "\x00" + meta.Units
+}
+
+// CopyMetadata adds AVUs of the source to the destination, system attributes are copied only if includeSystem is set
+// AVUs of the destination are kept, the ones it already has are skipped
+func CopyMetadata(filesystem *irodsclient_fs.FileSystem, srcPath string, destPath string, includeSystem bool) error {
+	metadata, err := filesystem.ListMetadata(srcPath)
+	if err != nil {
+		return errors.Wrapf(err, "failed to list AVUs of %q", srcPath)
+	}
+
+	destMetadata, err := filesystem.ListMetadata(destPath)
+	if err != nil {
+		return errors.Wrapf(err, "failed to list AVUs of %q", destPath)
+	}
+
+	existing := map[string]bool{}
+	for _, meta := range destMetadata {
+		existing[makeMetaKey(meta)] = true
+	}
+
+	for _, meta := range metadata {
+		if !includeSystem && IsSystemAttribute(meta.Name) {
+			continue
+		}
+
+		if existing[makeMetaKey(meta)] {
+			continue
+		}
+
+		err = filesystem.AddMetadata(destPath, meta.Name, meta.Value, meta.Units)
+		if err != nil {
+			return errors.Wrapf(err, "failed to copy AVU %q to %q", meta.Name, destPath)
+		}
+	}
+
+	return nil
+}
+
+// SyncMetadata makes AVUs of the destination the same as the source, system attributes are left untouched
+func SyncMetadata(filesystem *irodsclient_fs.FileSystem, srcPath string, destPath string) error {
+	srcMetadata, err := filesystem.ListMetadata(srcPath)
+	if err != nil {
+		return errors.Wrapf(err, "failed to list AVUs of %q", srcPath)
+	}
+
+	destMetadata, err := filesystem.ListMetadata(destPath)
+	if err != nil {
+		return errors.Wrapf(err, "failed to list AVUs of %q", destPath)
+	}
+
+	srcMetaMap := map[string]bool{}
+	for _, meta := range srcMetadata {
+		srcMetaMap[makeMetaKey(meta)] = true
+	}
+
+	destMetaMap := map[string]bool{}
+	for _, meta := range destMetadata {
+		if IsSystemAttribute(meta.Name) {
+			continue
+		}
+
+		key := makeMetaKey(meta)
+		destMetaMap[key] = true
+
+		if !srcMetaMap[key] {
+			err = filesystem.DeleteMetadataByAVU(destPath, meta.Name, meta.Value, meta.Units)
+			if err != nil {
+				return errors.Wrapf(err, "failed to delete AVU %q from %q", meta.Name, destPath)
+			}
+		}
+	}
+
+	for _, meta := range srcMetadata {
+		if IsSystemAttribute(meta.Name) || destMetaMap[makeMetaKey(meta)] {
+			continue
+		}
+
+		err = filesystem.AddMetadata(destPath, meta.Name, meta.Value, meta.Units)
+		if err != nil {
+			return errors.Wrapf(err, "failed to copy AVU %q to %q", meta.Name, destPath)
+		}
+	}
+
+	return nil
+}
+
+// CopyACLs grants ACLs of the source to the destination
+func CopyACLs(filesystem *irodsclient_fs.FileSystem, srcPath string, destPath string) error {
+	accesses, err := filesystem.ListACLs(srcPath)
+	if err != nil {
+		return errors.Wrapf(err, "failed to list ACLs of %q", srcPath)
+	}
+
+	for _, access := range accesses {
+		err = filesystem.ChangeACLs(destPath, access.AccessLevel, access.UserName, access.UserZone, false, false)
+		if err != nil {
+			return errors.Wrapf(err, "failed to copy ACL for user %q to %q", access.UserName, destPath)
+		}
+	}
+
+	return nil
+}
+
+// accessLevelOrder lists access levels from the lowest to the highest, as ordered by the iRODS server
+var accessLevelOrder = []irodsclient_types.IRODSAccessLevelType{
+	irodsclient_types.IRODSAccessLevelNull,
+	irodsclient_types.IRODSAccessLevelExecute,
+	irodsclient_types.IRODSAccessLevelReadAnnotation,
+	irodsclient_types.IRODSAccessLevelReadSystemMetadata,
+	irodsclient_types.IRODSAccessLevelReadMetadata,
+	irodsclient_types.IRODSAccessLevelReadObject,
+	irodsclient_types.IRODSAccessLevelWriteAnnotation,
+	irodsclient_types.IRODSAccessLevelCreateMetadata,
+	irodsclient_types.IRODSAccessLevelModifyMetadata,
+	irodsclient_types.IRODSAccessLevelDeleteMetadata,
+	irodsclient_types.IRODSAccessLevelAdministerObject,
+	irodsclient_types.IRODSAccessLevelCreateObject,
+	irodsclient_types.IRODSAccessLevelModifyObject,
+	irodsclient_types.IRODSAccessLevelDeleteObject,
+	irodsclient_types.IRODSAccessLevelCreateToken,
+	irodsclient_types.IRODSAccessLevelDeleteToken,
+	irodsclient_types.IRODSAccessLevelCurate,
+	irodsclient_types.IRODSAccessLevelOwner,
+}
+
+func getAccessLevelRank(accessLevel irodsclient_types.IRODSAccessLevelType) int {
+	for rank, level := range accessLevelOrder {
+		if level == accessLevel {
+			return rank
+		}
+	}
+	return 0
+}
+
+// GetAccessUserNames returns the user of the client and the groups the user belongs to, as "name#zone" keys
+func GetAccessUserNames(filesystem *irodsclient_fs.FileSystem) (map[string]bool, error) {
+	account := filesystem.GetAccount()
+
+	// every user is a member of the public group
+	userNames := map[string]bool{
+		makeAccessUserKey(account.ClientUser, account.ClientZone): true,
+		makeAccessUserKey("public", account.ClientZone):           true,
+	}
+
+	groups, err := filesystem.ListUserGroups(account.ClientZone, account.ClientUser)
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to list groups of user %q", account.ClientUser)
+	}
+
+	for _, group := range groups {
+		userNames[makeAccessUserKey(group.Name, group.Zone)] = true
+	}
+
+	return userNames, nil
+}
+
+func makeAccessUserKey(name string, zone string) string {
+	return name + "#" + zone
+}
+
+// HasReadAccess checks that the users, given by GetAccessUserNames, are granted read or higher access to the path
+func HasReadAccess(filesystem *irodsclient_fs.FileSystem, irodsPath string, userNames map[string]bool) (bool, error) {
+	accesses, err := filesystem.ListACLs(irodsPath)
+	if err != nil {
+		return false, errors.Wrapf(err, "failed to list ACLs of %q", irodsPath)
+	}
+
+	readRank := getAccessLevelRank(irodsclient_types.IRODSAccessLevelReadObject)
+	for _, access := range accesses {
+		if !userNames[makeAccessUserKey(access.UserName, access.UserZone)] {
+			continue
+		}
+
+		if getAccessLevelRank(access.AccessLevel) >= readRank {
+			return true, nil
+		}
+	}
+
+	return false, nil
+}
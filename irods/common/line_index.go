@@ -0,0 +1,274 @@
+package common
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+
+	"github.com/cockroachdb/errors"
+	irodsclient_fs "github.com/cyverse/go-irodsclient/fs"
+	gocache "github.com/patrickmn/go-cache"
+)
+
+const (
+	lineIndexInterval     int64         = 1000 // record offset every 1000 lines
+	lineIndexCacheTimeout time.Duration = 30 * time.Minute
+	lineIndexReadSize     int           = 1 * 1024 * 1024 // 1MB
+)
+
+var (
+	lineIndexCache *gocache.Cache = gocache.New(lineIndexCacheTimeout, lineIndexCacheTimeout)
+)
+
+// LineIndex is a sparse index of line offsets in a data object, built up to the lines read so far
+type LineIndex struct {
+	Path       string
+	Size       int64
+	ModifyTime time.Time
+	TotalLines int64   // valid only if Complete
+	Offsets    []int64 // Offsets[i] is the byte offset of line (i * lineIndexInterval), 0-based
+	Complete   bool    // true if the whole data object has been scanned
+}
+
+// TextLines is a range of lines read from a data object
+type TextLines struct {
+	StartLine  int64    // 1-based, inclusive
+	EndLine    int64    // 1-based, inclusive
+	TotalLines int64    // 0 if not known, the data object was not read to the end
+	Lines      []string // without line endings
+	Truncated  bool     // true if lines are truncated due to the size limit
+}
+
+func makeLineIndexKey(entry *irodsclient_fs.Entry) string {
+	return fmt.Sprintf("%s:%d:%d", entry.Path, entry.Size, entry.ModifyTime.UnixNano())
+}
+
+// GetLineIndex returns a sparse line index of a data object covering at least lines up to untilLine (1-based), or all lines if untilLine is 0
+// the index is cached per data object version and extended on demand, so reading the first lines does not scan the whole data object
+func GetLineIndex(filesystem *irodsclient_fs.FileSystem, entry *irodsclient_fs.Entry, untilLine int64) (*LineIndex, error) {
+	key := makeLineIndexKey(entry)
+
+	index := &LineIndex{
+		Path:       entry.Path,
+		Size:       entry.Size,
+		ModifyTime: entry.ModifyTime,
+		TotalLines: 0,
+		Offsets:    []int64{0},
+		Complete:   entry.Size == 0,
+	}
+
+	if indexObj, ok := lineIndexCache.Get(key); ok {
+		if cachedIndex, ok2 := indexObj.(*LineIndex); ok2 {
+			index = cachedIndex
+		}
+	}
+
+	if index.covers(untilLine) {
+		return index, nil
+	}
+
+	// cached indexes are shared, extend a copy
+	extendedIndex, err := extendLineIndex(filesystem, index, untilLine)
+	if err != nil {
+		return nil, err
+	}
+
+	lineIndexCache.SetDefault(key, extendedIndex)
+	return extendedIndex, nil
+}
+
+// covers returns true if the index has the offset to seek to untilLine
+func (index *LineIndex) covers(untilLine int64) bool {
+	if index.Complete {
+		return true
+	}
+
+	if untilLine <= 0 {
+		return false
+	}
+
+	return (untilLine-1)/lineIndexInterval < int64(len(index.Offsets))
+}
+
+// extendLineIndex scans the data object from the last indexed offset until the index covers untilLine
+func extendLineIndex(filesystem *irodsclient_fs.FileSystem, index *LineIndex, untilLine int64) (*LineIndex, error) {
+	extendedIndex := &LineIndex{
+		Path:       index.Path,
+		Size:       index.Size,
+		ModifyTime: index.ModifyTime,
+		TotalLines: 0,
+		Offsets:    append([]int64{}, index.Offsets...),
+		Complete:   false,
+	}
+
+	handle, err := filesystem.OpenFile(index.Path, "", "r")
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to open file %q", index.Path)
+	}
+	defer handle.Close()
+
+	// lines before the last indexed offset are already counted
+	offset := extendedIndex.Offsets[len(extendedIndex.Offsets)-1]
+	newlines := int64(len(extendedIndex.Offsets)-1) * lineIndexInterval
+
+	_, err = handle.Seek(offset, io.SeekStart)
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to seek file %q to offset %d", index.Path, offset)
+	}
+
+	buffer := make([]byte, lineIndexReadSize)
+	lastByte := byte('\n')
+
+	for !extendedIndex.covers(untilLine) {
+		n, readErr := handle.Read(buffer)
+		if n > 0 {
+			chunk := buffer[:n]
+			pos := 0
+			for {
+				idx := bytes.IndexByte(chunk[pos:], '\n')
+				if idx < 0 {
+					break
+				}
+
+				pos += idx + 1
+				newlines++
+				if newlines%lineIndexInterval == 0 {
+					extendedIndex.Offsets = append(extendedIndex.Offsets, offset+int64(pos))
+				}
+			}
+
+			offset += int64(n)
+			lastByte = chunk[n-1]
+		}
+
+		if readErr != nil && readErr != io.EOF {
+			return nil, errors.Wrapf(readErr, "failed to read file %q at offset %d", index.Path, offset)
+		}
+
+		if readErr == io.EOF || n == 0 {
+			extendedIndex.Complete = true
+			break
+		}
+	}
+
+	if !extendedIndex.Complete {
+		return extendedIndex, nil
+	}
+
+	extendedIndex.TotalLines = newlines
+	if offset > 0 && lastByte != '\n' {
+		// last line without line ending
+		extendedIndex.TotalLines++
+	} else if newlines%lineIndexInterval == 0 && len(extendedIndex.Offsets) > 1 {
+		// offset recorded for the line after the last
+		extendedIndex.Offsets = extendedIndex.Offsets[:len(extendedIndex.Offsets)-1]
+	}
+
+	return extendedIndex, nil
+}
+
+// ReadDataObjectLines reads lines from startLine to endLine (1-based, inclusive), total size of lines is limited to maxReadLen
+func ReadDataObjectLines(filesystem *irodsclient_fs.FileSystem, entry *irodsclient_fs.Entry, startLine int64, endLine int64, maxReadLen int64) (*TextLines, error) {
+	if startLine < 1 {
+		startLine = 1
+	}
+
+	index, err := GetLineIndex(filesystem, entry, startLine)
+	if err != nil {
+		return nil, err
+	}
+
+	if index.Complete && (endLine <= 0 || endLine > index.TotalLines) {
+		endLine = index.TotalLines
+	}
+
+	textLines := &TextLines{
+		StartLine:  startLine,
+		EndLine:    startLine - 1,
+		TotalLines: 0,
+		Lines:      []string{},
+		Truncated:  false,
+	}
+
+	if index.Complete {
+		textLines.TotalLines = index.TotalLines
+	}
+
+	if endLine > 0 && startLine > endLine {
+		return textLines, nil
+	}
+
+	// find the nearest offset
+	slot := (startLine - 1) / lineIndexInterval
+	if slot >= int64(len(index.Offsets)) {
+		slot = int64(len(index.Offsets)) - 1
+	}
+	currentLine := slot*lineIndexInterval + 1
+
+	handle, err := filesystem.OpenFile(entry.Path, "", "r")
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to open file %q", entry.Path)
+	}
+	defer handle.Close()
+
+	_, err = handle.Seek(index.Offsets[slot], io.SeekStart)
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to seek file %q to offset %d", entry.Path, index.Offsets[slot])
+	}
+
+	reader := bufio.NewReaderSize(handle, lineIndexReadSize)
+	readLen := int64(0)
+
+	for endLine <= 0 || currentLine <= endLine {
+		line, readErr := reader.ReadString('\n')
+		if len(line) == 0 && readErr != nil {
+			if readErr == io.EOF {
+				// all lines are counted when the end is reached
+				textLines.TotalLines = currentLine - 1
+				break
+			}
+
+			return nil, errors.Wrapf(readErr, "failed to read line %d of file %q", currentLine, entry.Path)
+		}
+
+		if currentLine >= startLine {
+			if readLen+int64(len(line)) > maxReadLen {
+				textLines.Truncated = true
+				break
+			}
+
+			readLen += int64(len(line))
+			textLines.Lines = append(textLines.Lines, strings.TrimRight(line, "\r\n"))
+			textLines.EndLine = currentLine
+		}
+
+		currentLine++
+
+		if readErr != nil {
+			if readErr == io.EOF {
+				textLines.TotalLines = currentLine - 1
+				break
+			}
+
+			return nil, errors.Wrapf(readErr, "failed to read line %d of file %q", currentLine, entry.Path)
+		}
+	}
+
+	return textLines, nil
+}
+
+// FormatLines joins lines with line endings, prefixes line numbers if requested
+func FormatLines(startLine int64, lines []string, withLineNumbers bool) string {
+	sb := strings.Builder{}
+	for idx, line := range lines {
+		if withLineNumbers {
+			sb.WriteString(fmt.Sprintf("%6d\t", startLine+int64(idx)))
+		}
+		sb.WriteString(line)
+		sb.WriteString("\n")
+	}
+	return sb.String()
+}
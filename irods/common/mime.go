@@ -14,6 +14,10 @@ const (
 // DetectMimeTypeWithExtension detects the mime type of a file based on its extension
 func DetectMimeTypeWithExtension(sourcePath string) string {
 	ext := path.Ext(sourcePath)
+	if bioFormat, ok := bioFormatExtensions[strings.ToLower(ext)]; ok {
+		return GetBioFormatMimeType(bioFormat)
+	}
+
 	if len(ext) > 0 {
 		mimeType := mime.TypeByExtension(ext)
 		if len(mimeType) > 0 {
@@ -27,6 +31,10 @@ func DetectMimeTypeWithExtension(sourcePath string) string {
 // DetectMimeTypeWithContent detects the mime type of a file based on its extension and content
 func DetectMimeTypeWithContent(sourcePath string, offset int64, content []byte) string {
 	ext := path.Ext(sourcePath)
+	if bioFormat, ok := bioFormatExtensions[strings.ToLower(ext)]; ok {
+		return GetBioFormatMimeType(bioFormat)
+	}
+
 	if len(ext) > 0 {
 		mimeType := mime.TypeByExtension(ext)
 		if len(mimeType) > 0 {
@@ -0,0 +1,41 @@
+package common
+
+import (
+	"sync"
+)
+
+// RunParallel runs jobs with bounded concurrency and waits for all to finish
+func RunParallel(jobNum int, concurrency int, job func(idx int)) {
+	if concurrency <= 0 {
+		concurrency = 1
+	}
+
+	semaphore := make(chan struct{}, concurrency)
+	wg := sync.WaitGroup{}
+
+	for idx := 0; idx < jobNum; idx++ {
+		semaphore <- struct{}{}
+		wg.Add(1)
+
+		go func(jobIdx int) {
+			defer func() {
+				<-semaphore
+				wg.Done()
+			}()
+
+			job(jobIdx)
+		}(idx)
+	}
+
+	wg.Wait()
+}
+
+// GetParallelism returns the number of parallel jobs within the allowed range
+func GetParallelism(parallelism int) int {
+	if parallelism <= 0 {
+		return GetDefaultTransferThreadNum()
+	} else if parallelism > MaxParallelism {
+		return MaxParallelism
+	}
+	return parallelism
+}
@@ -2,6 +2,7 @@ package common
 
 import (
 	"path"
+	"sort"
 	"strings"
 )
 
@@ -29,3 +30,45 @@ func IsAccessAllowed(irodsPath string, allowedPaths []string) bool {
 
 	return false
 }
+
+// GetAccessibleRoots returns collection paths covering all allowed paths, for pushing access checks into catalog queries
+// the roots may cover more than allowed, so results still need to be checked with IsAccessAllowed
+func GetAccessibleRoots(allowedPaths []string) []string {
+	roots := []string{}
+	for _, allowedPath := range allowedPaths {
+		root := strings.TrimSuffix(allowedPath, "/*")
+
+		// cut at the first wildcard
+		wildcardIdx := strings.IndexAny(root, "*?[\\")
+		if wildcardIdx >= 0 {
+			root = path.Dir(root[:wildcardIdx+1])
+		}
+
+		root = path.Clean(root)
+		if len(root) == 0 || root == "." {
+			continue
+		}
+
+		roots = append(roots, root)
+	}
+
+	sort.Strings(roots)
+
+	// drop roots under other roots
+	dedupRoots := []string{}
+	for _, root := range roots {
+		covered := false
+		for _, dedupRoot := range dedupRoots {
+			if root == dedupRoot || dedupRoot == "/" || strings.HasPrefix(root, dedupRoot+"/") {
+				covered = true
+				break
+			}
+		}
+
+		if !covered {
+			dedupRoots = append(dedupRoots, root)
+		}
+	}
+
+	return dedupRoots
+}
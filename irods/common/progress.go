@@ -0,0 +1,93 @@
+package common
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/modelcontextprotocol/go-sdk/mcp"
+)
+
+const (
+	progressNotifyInterval time.Duration = 500 * time.Millisecond
+)
+
+// ProgressReporter sends MCP progress notifications for a tool call, it does nothing if the client did not request progress
+type ProgressReporter struct {
+	ctx      context.Context
+	session  *mcp.ServerSession
+	token    any
+	total    float64
+	progress float64
+	lastSent time.Time
+	mutex    sync.Mutex
+}
+
+// NewProgressReporter creates a ProgressReporter for the tool call request
+func NewProgressReporter(ctx context.Context, request *mcp.CallToolRequest) *ProgressReporter {
+	reporter := &ProgressReporter{
+		ctx: ctx,
+	}
+
+	if request != nil && request.Params != nil {
+		reporter.session = request.Session
+		reporter.token = request.Params.GetProgressToken()
+	}
+
+	return reporter
+}
+
+// IsEnabled returns true if the client requested progress notifications
+func (reporter *ProgressReporter) IsEnabled() bool {
+	return reporter.session != nil && reporter.token != nil
+}
+
+// SetTotal sets the total amount of work, 0 means unknown
+func (reporter *ProgressReporter) SetTotal(total float64) {
+	reporter.mutex.Lock()
+	defer reporter.mutex.Unlock()
+
+	reporter.total = total
+}
+
+// Add increases progress and sends a notification, notifications are throttled
+func (reporter *ProgressReporter) Add(delta float64, message string) {
+	reporter.mutex.Lock()
+	defer reporter.mutex.Unlock()
+
+	reporter.progress += delta
+
+	if time.Since(reporter.lastSent) < progressNotifyInterval {
+		return
+	}
+
+	reporter.notify(message)
+}
+
+// Done sends the final notification
+func (reporter *ProgressReporter) Done(message string) {
+	reporter.mutex.Lock()
+	defer reporter.mutex.Unlock()
+
+	if reporter.total > 0 {
+		reporter.progress = reporter.total
+	}
+
+	reporter.notify(message)
+}
+
+func (reporter *ProgressReporter) notify(message string) {
+	if !reporter.IsEnabled() {
+		return
+	}
+
+	reporter.lastSent = time.Now()
+
+	// progress notifications are best-effort
+	reporter.session.NotifyProgress(reporter.ctx, &mcp.ProgressNotificationParams{ //nolint
+		ProgressToken: reporter.token,
+		Progress:      reporter.progress,
+		Total:         reporter.total,
+		Message:       message,
+	})
+}
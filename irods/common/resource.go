@@ -5,3 +5,7 @@ import "fmt"
 func MakeResourceURI(irodsPath string) string {
 	return fmt.Sprintf("%s://%s", IRODSScheme, irodsPath)
 }
+
+func MakeSavedQueryURI(name string) string {
+	return fmt.Sprintf("%s://%s", SavedQueryScheme, name)
+}
@@ -0,0 +1,359 @@
+package common
+
+import (
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"path"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// TableFormat is a format of tabular data
+type TableFormat string
+
+const (
+	TableFormatCSV   TableFormat = "csv"
+	TableFormatTSV   TableFormat = "tsv"
+	TableFormatJSONL TableFormat = "jsonl"
+)
+
+// ColumnType is an inferred type of a table column
+type ColumnType string
+
+const (
+	ColumnTypeEmpty   ColumnType = "empty"
+	ColumnTypeInteger ColumnType = "integer"
+	ColumnTypeFloat   ColumnType = "float"
+	ColumnTypeBoolean ColumnType = "boolean"
+	ColumnTypeDate    ColumnType = "date"
+	ColumnTypeString  ColumnType = "string"
+)
+
+const (
+	tableSniffLines       int = 20
+	tableMaxDistinctCount int = 1000
+)
+
+var (
+	tableDelimiterCandidates []rune   = []rune{',', '\t', ';', '|'}
+	tableNullValues          []string = []string{"", "na", "n/a", "nan", "null", "none"}
+	tableDateLayouts         []string = []string{time.RFC3339, "2006-01-02", "2006-01-02 15:04:05", "2006/01/02"}
+)
+
+// GetTableFormats returns all supported table formats
+func GetTableFormats() []TableFormat {
+	return []TableFormat{TableFormatCSV, TableFormatTSV, TableFormatJSONL}
+}
+
+// SniffTableFormat detects the format and the delimiter of tabular data from the leading content
+func SniffTableFormat(p string, head []byte) (TableFormat, rune) {
+	ext := strings.ToLower(path.Ext(p))
+	if ext == ".jsonl" || ext == ".ndjson" {
+		return TableFormatJSONL, 0
+	}
+
+	lines := splitSniffLines(head)
+
+	// JSON lines have a JSON object in each line
+	if len(lines) > 0 {
+		isJSONL := true
+		for _, line := range lines {
+			trimmed := bytes.TrimSpace(line)
+			if len(trimmed) == 0 {
+				continue
+			}
+
+			if trimmed[0] != '{' || !json.Valid(trimmed) {
+				isJSONL = false
+				break
+			}
+		}
+
+		if isJSONL {
+			return TableFormatJSONL, 0
+		}
+	}
+
+	delimiter := SniffDelimiter(lines)
+	if delimiter == 0 {
+		switch ext {
+		case ".tsv", ".tab":
+			delimiter = '\t'
+		default:
+			delimiter = ','
+		}
+	}
+
+	if delimiter == '\t' {
+		return TableFormatTSV, delimiter
+	}
+	return TableFormatCSV, delimiter
+}
+
+func splitSniffLines(head []byte) [][]byte {
+	lines := bytes.Split(head, []byte("\n"))
+	if len(lines) > 1 {
+		// last line may be partial
+		lines = lines[:len(lines)-1]
+	}
+
+	if len(lines) > tableSniffLines {
+		lines = lines[:tableSniffLines]
+	}
+
+	nonEmptyLines := [][]byte{}
+	for _, line := range lines {
+		line = bytes.TrimRight(line, "\r")
+		if len(line) > 0 {
+			nonEmptyLines = append(nonEmptyLines, line)
+		}
+	}
+	return nonEmptyLines
+}
+
+// SniffDelimiter returns the delimiter that splits lines into the most consistent number of fields, or 0 if none is found
+func SniffDelimiter(lines [][]byte) rune {
+	bestDelimiter := rune(0)
+	bestScore := 0
+
+	for _, delimiter := range tableDelimiterCandidates {
+		counts := map[int]int{}
+		for _, line := range lines {
+			reader := csv.NewReader(bytes.NewReader(line))
+			reader.Comma = delimiter
+			reader.LazyQuotes = true
+			reader.FieldsPerRecord = -1
+
+			record, err := reader.Read()
+			if err != nil {
+				continue
+			}
+			counts[len(record)]++
+		}
+
+		// score is the number of lines agreeing on the most common field count, weighted by the field count
+		for fields, lineCount := range counts {
+			if fields < 2 {
+				continue
+			}
+
+			score := lineCount*1000 + fields
+			if score > bestScore {
+				bestScore = score
+				bestDelimiter = delimiter
+			}
+		}
+	}
+
+	return bestDelimiter
+}
+
+// IsNullValue returns true if the value represents a missing value
+func IsNullValue(value string) bool {
+	value = strings.ToLower(strings.TrimSpace(value))
+	for _, nullValue := range tableNullValues {
+		if value == nullValue {
+			return true
+		}
+	}
+	return false
+}
+
+// InferValueType returns the type of a table cell value
+func InferValueType(value string) ColumnType {
+	value = strings.TrimSpace(value)
+	if IsNullValue(value) {
+		return ColumnTypeEmpty
+	}
+
+	if _, err := strconv.ParseInt(value, 10, 64); err == nil {
+		return ColumnTypeInteger
+	}
+
+	if _, err := strconv.ParseFloat(value, 64); err == nil {
+		return ColumnTypeFloat
+	}
+
+	switch strings.ToLower(value) {
+	case "true", "false":
+		return ColumnTypeBoolean
+	}
+
+	for _, layout := range tableDateLayouts {
+		if _, err := time.Parse(layout, value); err == nil {
+			return ColumnTypeDate
+		}
+	}
+
+	return ColumnTypeString
+}
+
+// LooksLikeHeader returns true if the first row of a table looks like a header
+func LooksLikeHeader(firstRow []string, dataRows [][]string) bool {
+	if len(firstRow) == 0 {
+		return false
+	}
+
+	seen := map[string]bool{}
+	for _, value := range firstRow {
+		value = strings.TrimSpace(value)
+		if len(value) == 0 || seen[value] || InferValueType(value) != ColumnTypeString {
+			return false
+		}
+		seen[value] = true
+	}
+
+	if len(dataRows) == 0 {
+		return true
+	}
+
+	// a header differs in type from the data in at least one column, or the data is all strings
+	for idx := range firstRow {
+		for _, row := range dataRows {
+			if idx < len(row) && InferValueType(row[idx]) != ColumnTypeString && InferValueType(row[idx]) != ColumnTypeEmpty {
+				return true
+			}
+		}
+	}
+
+	// all columns are strings, the header has no repeated value in the data
+	for idx, value := range firstRow {
+		for _, row := range dataRows {
+			if idx < len(row) && strings.TrimSpace(row[idx]) == strings.TrimSpace(value) {
+				return false
+			}
+		}
+	}
+	return true
+}
+
+// ColumnStats accumulates statistics of a table column
+type ColumnStats struct {
+	Name              string
+	Count             int64 // non-null values
+	Nulls             int64
+	typeCounts        map[ColumnType]int64
+	distinct          map[string]bool
+	DistinctTruncated bool
+	minNumber         float64
+	maxNumber         float64
+	sum               float64
+	numbers           int64
+	minString         string
+	maxString         string
+}
+
+// NewColumnStats creates a ColumnStats
+func NewColumnStats(name string) *ColumnStats {
+	return &ColumnStats{
+		Name:       name,
+		typeCounts: map[ColumnType]int64{},
+		distinct:   map[string]bool{},
+	}
+}
+
+// Add adds a cell value to the statistics
+func (stats *ColumnStats) Add(value string) {
+	valueType := InferValueType(value)
+	if valueType == ColumnTypeEmpty {
+		stats.Nulls++
+		return
+	}
+
+	value = strings.TrimSpace(value)
+	stats.Count++
+	stats.typeCounts[valueType]++
+
+	if !stats.DistinctTruncated {
+		stats.distinct[value] = true
+		if len(stats.distinct) > tableMaxDistinctCount {
+			stats.DistinctTruncated = true
+		}
+	}
+
+	if valueType == ColumnTypeInteger || valueType == ColumnTypeFloat {
+		number, _ := strconv.ParseFloat(value, 64)
+		if stats.numbers == 0 || number < stats.minNumber {
+			stats.minNumber = number
+		}
+		if stats.numbers == 0 || number > stats.maxNumber {
+			stats.maxNumber = number
+		}
+		stats.sum += number
+		stats.numbers++
+	}
+
+	if stats.Count == 1 || value < stats.minString {
+		stats.minString = value
+	}
+	if stats.Count == 1 || value > stats.maxString {
+		stats.maxString = value
+	}
+}
+
+// AddNull adds a missing value to the statistics
+func (stats *ColumnStats) AddNull() {
+	stats.Nulls++
+}
+
+// AddNulls adds missing values of count rows to the statistics
+func (stats *ColumnStats) AddNulls(count int64) {
+	stats.Nulls += count
+}
+
+// Type returns the inferred type of the column
+func (stats *ColumnStats) Type() ColumnType {
+	if stats.Count == 0 {
+		return ColumnTypeEmpty
+	}
+
+	types := []ColumnType{}
+	for valueType := range stats.typeCounts {
+		types = append(types, valueType)
+	}
+	sort.Slice(types, func(i, j int) bool { return types[i] < types[j] })
+
+	switch {
+	case len(types) == 1:
+		return types[0]
+	case len(types) == 2 && types[0] == ColumnTypeFloat && types[1] == ColumnTypeInteger:
+		return ColumnTypeFloat
+	default:
+		return ColumnTypeString
+	}
+}
+
+// Distinct returns the number of distinct values, the number is a lower bound if DistinctTruncated is set
+func (stats *ColumnStats) Distinct() int {
+	return len(stats.distinct)
+}
+
+// Min returns the minimum value in string form, numerically for numeric columns
+func (stats *ColumnStats) Min() string {
+	columnType := stats.Type()
+	if columnType == ColumnTypeInteger || columnType == ColumnTypeFloat {
+		return strconv.FormatFloat(stats.minNumber, 'g', -1, 64)
+	}
+	return stats.minString
+}
+
+// Max returns the maximum value in string form, numerically for numeric columns
+func (stats *ColumnStats) Max() string {
+	columnType := stats.Type()
+	if columnType == ColumnTypeInteger || columnType == ColumnTypeFloat {
+		return strconv.FormatFloat(stats.maxNumber, 'g', -1, 64)
+	}
+	return stats.maxString
+}
+
+// Mean returns the mean of numeric columns
+func (stats *ColumnStats) Mean() (float64, bool) {
+	columnType := stats.Type()
+	if (columnType == ColumnTypeInteger || columnType == ColumnTypeFloat) && stats.numbers > 0 {
+		return stats.sum / float64(stats.numbers), true
+	}
+	return 0, false
+}
@@ -0,0 +1,105 @@
+package common
+
+import (
+	"strings"
+
+	"github.com/cockroachdb/errors"
+	"golang.org/x/text/encoding"
+	"golang.org/x/text/encoding/htmlindex"
+	"golang.org/x/text/encoding/unicode"
+)
+
+// NewlineStyle determines how line endings are written
+type NewlineStyle string
+
+const (
+	// NewlineStyleKeep keeps line endings as given
+	NewlineStyleKeep NewlineStyle = "keep"
+	// NewlineStyleLF converts all line endings to LF
+	NewlineStyleLF NewlineStyle = "lf"
+	// NewlineStyleCRLF converts all line endings to CRLF
+	NewlineStyleCRLF NewlineStyle = "crlf"
+)
+
+// TrailingNewlinePolicy determines how a trailing newline at the end of text is handled
+type TrailingNewlinePolicy string
+
+const (
+	// TrailingNewlineKeep keeps the end of text as given
+	TrailingNewlineKeep TrailingNewlinePolicy = "keep"
+	// TrailingNewlineEnsure adds a trailing newline if missing
+	TrailingNewlineEnsure TrailingNewlinePolicy = "ensure"
+	// TrailingNewlineRemove removes all trailing newlines
+	TrailingNewlineRemove TrailingNewlinePolicy = "remove"
+)
+
+const (
+	DefaultTextEncoding string = "utf-8"
+)
+
+// GetTextEncoding returns the text encoding for the name, such as 'utf-8', 'utf-16le', 'iso-8859-1', or 'windows-1252'
+func GetTextEncoding(name string) (encoding.Encoding, error) {
+	name = strings.ToLower(strings.TrimSpace(name))
+	if len(name) == 0 || name == "utf-8" || name == "utf8" {
+		return unicode.UTF8, nil
+	}
+
+	enc, err := htmlindex.Get(name)
+	if err != nil {
+		return nil, errors.Wrapf(err, "unknown text encoding %q", name)
+	}
+
+	return enc, nil
+}
+
+// EncodeText converts UTF-8 text to bytes in the given encoding
+func EncodeText(text string, encodingName string) ([]byte, error) {
+	enc, err := GetTextEncoding(encodingName)
+	if err != nil {
+		return nil, err
+	}
+
+	if enc == unicode.UTF8 {
+		return []byte(text), nil
+	}
+
+	encoded, err := enc.NewEncoder().Bytes([]byte(text))
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to encode text in %q", encodingName)
+	}
+
+	return encoded, nil
+}
+
+// NormalizeNewlines converts line endings in text to the given style
+func NormalizeNewlines(text string, style NewlineStyle) string {
+	switch style {
+	case NewlineStyleLF:
+		text = strings.ReplaceAll(text, "\r\n", "\n")
+		return strings.ReplaceAll(text, "\r", "\n")
+	case NewlineStyleCRLF:
+		text = NormalizeNewlines(text, NewlineStyleLF)
+		return strings.ReplaceAll(text, "\n", "\r\n")
+	default:
+		return text
+	}
+}
+
+// ApplyTrailingNewlinePolicy adds or removes a newline at the end of text
+func ApplyTrailingNewlinePolicy(text string, policy TrailingNewlinePolicy, style NewlineStyle) string {
+	switch policy {
+	case TrailingNewlineEnsure:
+		if len(text) == 0 || strings.HasSuffix(text, "\n") || strings.HasSuffix(text, "\r") {
+			return text
+		}
+
+		if style == NewlineStyleCRLF || (style != NewlineStyleLF && strings.Contains(text, "\r\n")) {
+			return text + "\r\n"
+		}
+		return text + "\n"
+	case TrailingNewlineRemove:
+		return strings.TrimRight(text, "\r\n")
+	default:
+		return text
+	}
+}
@@ -0,0 +1,246 @@
+package common
+
+import (
+	"bytes"
+	"encoding/base64"
+	"image"
+	"image/color"
+	"image/gif"
+	"image/jpeg"
+	"image/png"
+	"io"
+
+	"github.com/cockroachdb/errors"
+	irodsclient_fs "github.com/cyverse/go-irodsclient/fs"
+	"github.com/modelcontextprotocol/go-sdk/mcp"
+)
+
+const (
+	ThumbnailMaxDimensionDefault int   = 512
+	ThumbnailMaxDimension        int   = 2048
+	ThumbnailMinDimension        int   = 16
+	MaxImageDecodeSize           int64 = 256 * 1024 * 1024 // 256MB
+	MaxImageDecodePixels         int64 = 100 * 1000 * 1000 // 100 mega pixels
+	thumbnailJPEGQuality         int   = 85
+)
+
+// ImageThumbnail is a downscaled image
+type ImageThumbnail struct {
+	Data           []byte
+	MIMEType       string
+	Width          int
+	Height         int
+	OriginalFormat string
+	OriginalWidth  int
+	OriginalHeight int
+}
+
+// MakeThumbnailMeta returns metadata of the thumbnail and the original image for MCP contents
+func MakeThumbnailMeta(thumbnail *ImageThumbnail) mcp.Meta {
+	return mcp.Meta{
+		"thumbnail":       true,
+		"width":           thumbnail.Width,
+		"height":          thumbnail.Height,
+		"original_format": thumbnail.OriginalFormat,
+		"original_width":  thumbnail.OriginalWidth,
+		"original_height": thumbnail.OriginalHeight,
+	}
+}
+
+// IsThumbnailSupported checks if thumbnails can be made for the mimetype
+func IsThumbnailSupported(mimeType string) bool {
+	switch mimeType {
+	case "image/png", "image/jpeg", "image/gif":
+		return true
+	default:
+		return false
+	}
+}
+
+// MakeImageThumbnail decodes a PNG, JPEG, or GIF image data object and downscales it to fit in maxDimension,
+// the thumbnail is shrunk further until its base64 encoding fits in maxSize bytes
+func MakeImageThumbnail(filesystem *irodsclient_fs.FileSystem, entry *irodsclient_fs.Entry, maxDimension int, maxSize int64) (*ImageThumbnail, error) {
+	if entry.Size > MaxImageDecodeSize {
+		return nil, errors.Newf("image file %q is too large to decode (%d bytes), maximum is %d bytes", entry.Path, entry.Size, MaxImageDecodeSize)
+	}
+
+	if maxDimension <= 0 {
+		maxDimension = ThumbnailMaxDimensionDefault
+	} else if maxDimension > ThumbnailMaxDimension {
+		maxDimension = ThumbnailMaxDimension
+	} else if maxDimension < ThumbnailMinDimension {
+		maxDimension = ThumbnailMinDimension
+	}
+
+	handle, err := filesystem.OpenFile(entry.Path, "", "r")
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to open file %q", entry.Path)
+	}
+	defer handle.Close()
+
+	reader := io.NewSectionReader(NewBlockReaderAt(handle, entry.Size, ArchiveIOBufferSize), 0, entry.Size)
+
+	// check dimensions before decoding to avoid decompression bombs
+	config, format, err := image.DecodeConfig(reader)
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to decode image header of %q", entry.Path)
+	}
+
+	if int64(config.Width)*int64(config.Height) > MaxImageDecodePixels {
+		return nil, errors.Newf("image %q is too large to decode (%dx%d pixels), maximum is %d pixels", entry.Path, config.Width, config.Height, MaxImageDecodePixels)
+	}
+
+	_, err = reader.Seek(0, io.SeekStart)
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to seek file %q", entry.Path)
+	}
+
+	var img image.Image
+	switch format {
+	case "gif":
+		// first frame only
+		img, err = gif.Decode(reader)
+	default:
+		img, _, err = image.Decode(reader)
+	}
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to decode image %q", entry.Path)
+	}
+
+	thumbnail := &ImageThumbnail{
+		OriginalFormat: format,
+		OriginalWidth:  config.Width,
+		OriginalHeight: config.Height,
+	}
+
+	dimension := maxDimension
+	for {
+		scaled := DownscaleImage(img, dimension)
+
+		data, mimeType, err := encodeThumbnail(scaled, format)
+		if err != nil {
+			return nil, errors.Wrapf(err, "failed to encode thumbnail of %q", entry.Path)
+		}
+
+		if int64(base64.StdEncoding.EncodedLen(len(data))) <= maxSize || dimension <= ThumbnailMinDimension {
+			thumbnail.Data = data
+			thumbnail.MIMEType = mimeType
+			thumbnail.Width = scaled.Bounds().Dx()
+			thumbnail.Height = scaled.Bounds().Dy()
+			break
+		}
+
+		// shrink until the encoded thumbnail fits
+		dimension = dimension * 3 / 4
+		if dimension < ThumbnailMinDimension {
+			dimension = ThumbnailMinDimension
+		}
+	}
+
+	if encodedSize := base64.StdEncoding.EncodedLen(len(thumbnail.Data)); int64(encodedSize) > maxSize {
+		return nil, errors.Newf("thumbnail of %q is too large (%d bytes in base64)", entry.Path, encodedSize)
+	}
+
+	return thumbnail, nil
+}
+
+func encodeThumbnail(img image.Image, originalFormat string) ([]byte, string, error) {
+	buffer := bytes.Buffer{}
+
+	if originalFormat == "jpeg" {
+		err := jpeg.Encode(&buffer, img, &jpeg.Options{Quality: thumbnailJPEGQuality})
+		if err != nil {
+			return nil, "", err
+		}
+		return buffer.Bytes(), "image/jpeg", nil
+	}
+
+	// PNG keeps transparency of PNG and GIF images
+	err := png.Encode(&buffer, img)
+	if err != nil {
+		return nil, "", err
+	}
+	return buffer.Bytes(), "image/png", nil
+}
+
+// DownscaleImage scales the image down to fit in maxDimension by averaging source pixels (box filter),
+// the image is returned as is if it already fits
+func DownscaleImage(img image.Image, maxDimension int) image.Image {
+	bounds := img.Bounds()
+	srcWidth := bounds.Dx()
+	srcHeight := bounds.Dy()
+
+	if srcWidth <= maxDimension && srcHeight <= maxDimension {
+		return img
+	}
+
+	dstWidth := maxDimension
+	dstHeight := maxDimension
+	if srcWidth >= srcHeight {
+		dstHeight = int(int64(srcHeight) * int64(maxDimension) / int64(srcWidth))
+	} else {
+		dstWidth = int(int64(srcWidth) * int64(maxDimension) / int64(srcHeight))
+	}
+
+	if dstWidth < 1 {
+		dstWidth = 1
+	}
+	if dstHeight < 1 {
+		dstHeight = 1
+	}
+
+	rgba64Img, isRGBA64 := img.(image.RGBA64Image)
+
+	dst := image.NewNRGBA(image.Rect(0, 0, dstWidth, dstHeight))
+	for dy := 0; dy < dstHeight; dy++ {
+		sy0 := bounds.Min.Y + dy*srcHeight/dstHeight
+		sy1 := bounds.Min.Y + (dy+1)*srcHeight/dstHeight
+		if sy1 <= sy0 {
+			sy1 = sy0 + 1
+		}
+
+		for dx := 0; dx < dstWidth; dx++ {
+			sx0 := bounds.Min.X + dx*srcWidth/dstWidth
+			sx1 := bounds.Min.X + (dx+1)*srcWidth/dstWidth
+			if sx1 <= sx0 {
+				sx1 = sx0 + 1
+			}
+
+			var r, g, b, a, count uint64
+			for sy := sy0; sy < sy1; sy++ {
+				for sx := sx0; sx < sx1; sx++ {
+					var c color.RGBA64
+					if isRGBA64 {
+						c = rgba64Img.RGBA64At(sx, sy)
+					} else {
+						cr, cg, cb, ca := img.At(sx, sy).RGBA()
+						c = color.RGBA64{R: uint16(cr), G: uint16(cg), B: uint16(cb), A: uint16(ca)}
+					}
+
+					r += uint64(c.R)
+					g += uint64(c.G)
+					b += uint64(c.B)
+					a += uint64(c.A)
+					count++
+				}
+			}
+
+			// colors are alpha-premultiplied, convert to non-premultiplied for NRGBA
+			pixel := color.NRGBA64Model.Convert(color.RGBA64{
+				R: uint16(r / count),
+				G: uint16(g / count),
+				B: uint16(b / count),
+				A: uint16(a / count),
+			}).(color.NRGBA64)
+
+			dst.SetNRGBA(dx, dy, color.NRGBA{
+				R: uint8(pixel.R >> 8),
+				G: uint8(pixel.G >> 8),
+				B: uint8(pixel.B >> 8),
+				A: uint8(pixel.A >> 8),
+			})
+		}
+	}
+
+	return dst
+}
@@ -3,6 +3,7 @@ package common
 import (
 	"strconv"
 	"strings"
+	"time"
 
 	"github.com/cockroachdb/errors"
 )
@@ -22,6 +23,9 @@ func ParseSize(size string) (int64, error) {
 	size = strings.TrimSpace(size)
 	size = strings.ToUpper(size)
 	size = strings.TrimSuffix(size, "B")
+	if len(size) == 0 {
+		return 0, errors.Newf("empty size")
+	}
 
 	sizeNum := int64(0)
 	var err error
@@ -86,3 +90,26 @@ func ParseTime(t string) (int, error) {
 		return int(tNum), nil
 	}
 }
+
+// ParseTimeBound parses an absolute date/time (RFC3339, "2006-01-02 15:04:05" or "2006-01-02"), or a duration before now (e.g. "7d", "12h")
+func ParseTimeBound(t string, now time.Time) (time.Time, error) {
+	t = strings.TrimSpace(t)
+	if len(t) == 0 {
+		return time.Time{}, errors.Newf("empty time")
+	}
+
+	layouts := []string{time.RFC3339, "2006-01-02T15:04:05", "2006-01-02 15:04:05", "2006-01-02"}
+	for _, layout := range layouts {
+		parsed, err := time.ParseInLocation(layout, t, time.Local)
+		if err == nil {
+			return parsed, nil
+		}
+	}
+
+	seconds, err := ParseTime(t)
+	if err != nil {
+		return time.Time{}, errors.Wrapf(err, "failed to parse time %q, must be a date, date-time or duration", t)
+	}
+
+	return now.Add(-time.Duration(seconds) * time.Second), nil
+}
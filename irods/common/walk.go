@@ -0,0 +1,100 @@
+package common
+
+import (
+	"path"
+	"strings"
+
+	"github.com/cockroachdb/errors"
+	irodsclient_fs "github.com/cyverse/go-irodsclient/fs"
+)
+
+// ErrSkipDir is returned by WalkFunc to skip the directory (collection)
+var ErrSkipDir = errors.New("skip this directory")
+
+// ErrStopWalk is returned by WalkFunc to stop walking without error
+var ErrStopWalk = errors.New("stop walking")
+
+// WalkFunc is called for each entry under the root, depth is 1 for direct children of the root
+type WalkFunc func(entry *irodsclient_fs.Entry, depth int) error
+
+// WalkCollection walks entries under the root directory (collection) recursively, maxDepth <= 0 means unlimited
+func WalkCollection(filesystem *irodsclient_fs.FileSystem, rootPath string, maxDepth int, walkFunc WalkFunc) error {
+	err := walkCollectionInternal(filesystem, rootPath, 1, maxDepth, walkFunc)
+	if err != nil && errors.Is(err, ErrStopWalk) {
+		return nil
+	}
+	return err
+}
+
+func walkCollectionInternal(filesystem *irodsclient_fs.FileSystem, dirPath string, curDepth int, maxDepth int, walkFunc WalkFunc) error {
+	entries, err := filesystem.List(dirPath)
+	if err != nil {
+		return errors.Wrapf(err, "failed to list directory (collection) %q", dirPath)
+	}
+
+	for _, entry := range entries {
+		err = walkFunc(entry, curDepth)
+		if err != nil {
+			if errors.Is(err, ErrSkipDir) {
+				continue
+			}
+			return err
+		}
+
+		if entry.IsDir() && (maxDepth <= 0 || curDepth+1 <= maxDepth) {
+			err = walkCollectionInternal(filesystem, entry.Path, curDepth+1, maxDepth, walkFunc)
+			if err != nil {
+				return err
+			}
+		}
+	}
+
+	return nil
+}
+
+// GetRelativeIRODSPath returns the path relative to the root path
+func GetRelativeIRODSPath(rootPath string, irodsPath string) string {
+	rootPath = strings.TrimRight(rootPath, "/")
+	if irodsPath == rootPath {
+		return "."
+	}
+	return strings.TrimPrefix(irodsPath, rootPath+"/")
+}
+
+// MatchGlob checks if the relative path matches the glob pattern, patterns without '/' are matched against the base name
+func MatchGlob(pattern string, relPath string) bool {
+	target := relPath
+	if !strings.Contains(pattern, "/") {
+		target = path.Base(relPath)
+	}
+
+	matched, _ := path.Match(pattern, target)
+	return matched
+}
+
+// MatchGlobs checks if the relative path matches any of include patterns and none of exclude patterns, empty includes match all
+func MatchGlobs(relPath string, includes []string, excludes []string) bool {
+	for _, exclude := range excludes {
+		if MatchGlob(exclude, relPath) {
+			return false
+		}
+	}
+
+	if len(includes) == 0 {
+		return true
+	}
+
+	for _, include := range includes {
+		if MatchGlob(include, relPath) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// IsIRODSSubPath checks if the path is the parent path itself or under it
+func IsIRODSSubPath(parentPath string, irodsPath string) bool {
+	parentPath = strings.TrimRight(parentPath, "/")
+	return irodsPath == parentPath || strings.HasPrefix(irodsPath, parentPath+"/")
+}
@@ -2,10 +2,14 @@ package irods
 
 import (
 	"context"
+	"encoding/json"
+	"fmt"
 	"path"
+	"strings"
 
 	"github.com/cockroachdb/errors"
 	irodsclient_fs "github.com/cyverse/go-irodsclient/fs"
+	irodsclient_types "github.com/cyverse/go-irodsclient/irods/types"
 	"github.com/cyverse/irods-mcp-server/common"
 	irods_common "github.com/cyverse/irods-mcp-server/irods/common"
 	"github.com/cyverse/irods-mcp-server/irods/model"
@@ -17,11 +21,35 @@ const (
 	CopyFileName = irods_common.IRODSAPIPrefix + "copy_file"
 )
 
-type CopyFileInputArgs struct {
+type CopyFileItem struct {
 	SourcePath      string `json:"source_path"`
 	DestinationPath string `json:"destination_path"`
 }
 
+// CopyConflictPolicy determines what to do when a destination already exists
+type CopyConflictPolicy string
+
+const (
+	CopyConflictFail      CopyConflictPolicy = "fail"
+	CopyConflictSkip      CopyConflictPolicy = "skip"
+	CopyConflictOverwrite CopyConflictPolicy = "overwrite"
+	CopyConflictRename    CopyConflictPolicy = "rename"
+)
+
+const (
+	copyMaxRenameAttempts int = 1000
+)
+
+type CopyFileInputArgs struct {
+	SourcePath      string         `json:"source_path,omitempty"`
+	DestinationPath string         `json:"destination_path,omitempty"`
+	Items           []CopyFileItem `json:"items,omitempty"`
+	OnConflict      string         `json:"on_conflict,omitempty"`
+	PreserveAVUs    bool           `json:"preserve_avus,omitempty"`
+	PreserveACLs    bool           `json:"preserve_acls,omitempty"`
+	Parallelism     int            `json:"parallelism,omitempty"`
+}
+
 type CopyFile struct {
 	mcpServer *IRODSMCPServer
 	config    *common.Config
@@ -39,7 +67,9 @@ func (t *CopyFile) GetName() string {
 }
 
 func (t *CopyFile) GetDescription() string {
-	return `Copy a file (data-object) or directory (collection) to a new location.`
+	return `Copy a file (data-object) or directory (collection) to a new location.
+	Files in a directory are copied on the server side in parallel. Use 'on_conflict' to skip, overwrite, or rename existing destinations, or to merge into an existing directory.
+	To copy many files and directories in a single call, give 'items' instead of 'source_path' and 'destination_path'. Batch items are processed in parallel and each item reports its own result.`
 }
 
 func (t *CopyFile) GetTool() *mcp.Tool {
@@ -55,10 +85,48 @@ func (t *CopyFile) GetTool() *mcp.Tool {
 				},
 				"destination_path": {
 					Type:        "string",
-					Description: "The new, complete path to copy the file (data-object) or directory (collection) to, including its new name. The path must not already exist.",
+					Description: "The new, complete path to copy the file (data-object) or directory (collection) to, including its new name. The path must not already exist unless 'on_conflict' allows it.",
+				},
+				"items": {
+					Type:        "array",
+					Description: fmt.Sprintf("The list of copies to run as a batch, used instead of 'source_path' and 'destination_path'. Maximum is %d items.", irods_common.MaxBatchItems),
+					Items: &jsonschema.Schema{
+						Type: "object",
+						Properties: map[string]*jsonschema.Schema{
+							"source_path": {
+								Type:        "string",
+								Description: "The path to the source file (data-object) or directory (collection).",
+							},
+							"destination_path": {
+								Type:        "string",
+								Description: "The new, complete path to copy to. The path must not already exist unless 'on_conflict' allows it.",
+							},
+						},
+						Required: []string{"source_path", "destination_path"},
+					},
+				},
+				"on_conflict": {
+					Type:        "string",
+					Enum:        []interface{}{string(CopyConflictFail), string(CopyConflictSkip), string(CopyConflictOverwrite), string(CopyConflictRename)},
+					Description: "What to do when the destination already exists. 'fail' returns an error, 'skip' keeps existing files, 'overwrite' replaces existing files, and 'rename' copies to a new name such as 'name_1.txt'. With 'skip' and 'overwrite', a directory is merged into an existing directory. Default is 'fail'.",
+					Default:     json.RawMessage(fmt.Sprintf("%q", CopyConflictFail)),
+				},
+				"preserve_avus": {
+					Type:        "boolean",
+					Description: "Set to true to copy AVUs of the source files and directories to the copies, AVUs the destination already has are kept. Default is false.",
+					Default:     json.RawMessage("false"),
+				},
+				"preserve_acls": {
+					Type:        "boolean",
+					Description: "Set to true to grant the same access permissions as the source files and directories to the copies. Default is false.",
+					Default:     json.RawMessage("false"),
+				},
+				"parallelism": {
+					Type:        "number",
+					Description: fmt.Sprintf("The number of files or batch items to copy in parallel. Maximum is %d. Default is %d.", irods_common.MaxParallelism, irods_common.GetDefaultTransferThreadNum()),
+					Default:     json.RawMessage(fmt.Sprintf("%d", irods_common.GetDefaultTransferThreadNum())),
 				},
 			},
-			Required: []string{"source_path", "destination_path"},
 		},
 	}
 }
@@ -111,98 +179,350 @@ func (t *CopyFile) Handler(ctx context.Context, request *mcp.CallToolRequest) (*
 		return irods_common.ToolErrorResult(outputErr), nil
 	}
 
-	irodsSourcePath := irods_common.MakeIRODSPath(t.config, fs.GetAccount(), args.SourcePath)
-	irodsDestinationPath := irods_common.MakeIRODSPath(t.config, fs.GetAccount(), args.DestinationPath)
-
-	// check permission
-	if !irods_common.IsAccessAllowed(irodsSourcePath, t.GetAccessiblePaths(&authValue)) {
-		outputErr := errors.Newf("%q request is not permitted for path %q", t.GetName(), irodsSourcePath)
+	switch CopyConflictPolicy(args.OnConflict) {
+	case "", CopyConflictFail, CopyConflictSkip, CopyConflictOverwrite, CopyConflictRename:
+	default:
+		outputErr := errors.Newf("unknown on_conflict policy %q", args.OnConflict)
 		return irods_common.ToolErrorResult(outputErr), nil
 	}
-	if !irods_common.IsAccessAllowed(irodsDestinationPath, t.GetAccessiblePaths(&authValue)) {
-		outputErr := errors.Newf("%q request is not permitted for path %q", t.GetName(), irodsDestinationPath)
+
+	// batch
+	if len(args.Items) > 0 {
+		if len(args.SourcePath) > 0 || len(args.DestinationPath) > 0 {
+			outputErr := errors.Newf("items cannot be given together with source_path and destination_path")
+			return irods_common.ToolErrorResult(outputErr), nil
+		}
+
+		err = checkBatchSize(len(args.Items))
+		if err != nil {
+			return irods_common.ToolErrorResult(err), nil
+		}
+
+		// progress is not reported per batch item
+		progress := irods_common.NewProgressReporter(ctx, nil)
+
+		content := runBatch(ctx, len(args.Items), args.Parallelism, func(idx int) (any, error) {
+			return t.copyFileWithCheck(ctx, fs, &authValue, args.Items[idx].SourcePath, args.Items[idx].DestinationPath, progress, &args)
+		})
+
+		return irods_common.ToolJSONResult(*content)
+	}
+
+	if len(args.SourcePath) == 0 || len(args.DestinationPath) == 0 {
+		outputErr := errors.Newf("either source_path and destination_path, or items must be given")
 		return irods_common.ToolErrorResult(outputErr), nil
 	}
 
+	progress := irods_common.NewProgressReporter(ctx, request)
+
+	content, err := t.copyFileWithCheck(ctx, fs, &authValue, args.SourcePath, args.DestinationPath, progress, &args)
+	if err != nil {
+		return irods_common.ToolErrorResult(err), nil
+	}
+
+	return irods_common.ToolJSONResult(*content)
+}
+
+func (t *CopyFile) copyFileWithCheck(ctx context.Context, fs *irodsclient_fs.FileSystem, authValue *common.AuthValue, sourcePath string, destinationPath string, progress *irods_common.ProgressReporter, args *CopyFileInputArgs) (*model.CopyFileOutput, error) {
+	irodsSourcePath := irods_common.MakeIRODSPath(t.config, fs.GetAccount(), sourcePath)
+	irodsDestinationPath := irods_common.MakeIRODSPath(t.config, fs.GetAccount(), destinationPath)
+
+	// check permission
+	if !irods_common.IsAccessAllowed(irodsSourcePath, t.GetAccessiblePaths(authValue)) {
+		return nil, errors.Newf("%q request is not permitted for path %q", t.GetName(), irodsSourcePath)
+	}
+	if !irods_common.IsAccessAllowed(irodsDestinationPath, t.GetAccessiblePaths(authValue)) {
+		return nil, errors.Newf("%q request is not permitted for path %q", t.GetName(), irodsDestinationPath)
+	}
+
 	// Copy file
 	sourceEntry, err := fs.Stat(irodsSourcePath)
 	if err != nil {
-		outputErr := errors.Wrapf(err, "failed to stat file or directory info for %q", irodsSourcePath)
-		return irods_common.ToolErrorResult(outputErr), nil
+		return nil, errors.Wrapf(err, "failed to stat file or directory info for %q", irodsSourcePath)
+	}
+
+	if sourceEntry.IsDir() && irods_common.IsIRODSSubPath(irodsSourcePath, irodsDestinationPath) {
+		return nil, errors.Newf("cannot copy directory (collection) %q into itself", irodsSourcePath)
 	}
 
-	content, err := t.copyFile(fs, sourceEntry, irodsDestinationPath)
+	content, err := t.copyFile(ctx, fs, sourceEntry, irodsDestinationPath, progress, args)
 	if err != nil {
-		outputErr := errors.Wrapf(err, "failed to copy file (data-object) or directory (collection) from %q to %q", irodsSourcePath, irodsDestinationPath)
-		return irods_common.ToolErrorResult(outputErr), nil
+		return nil, errors.Wrapf(err, "failed to copy file (data-object) or directory (collection) from %q to %q", irodsSourcePath, irodsDestinationPath)
 	}
 
-	return irods_common.ToolJSONResult(*content)
+	return content, nil
+}
+
+// copyTask is a file (data-object) copy planned by copyFile
+type copyTask struct {
+	sourceEntry *irodsclient_fs.Entry
+	destPath    string
+	overwrite   bool
+	destEntry   *irodsclient_fs.Entry
+	err         error
 }
 
-func (t *CopyFile) copyFile(fs *irodsclient_fs.FileSystem, sourceEntry *irodsclient_fs.Entry, destPath string) (*model.CopyFileOutput, error) {
-	sourceEntries, copiedEntries, err := t.copyFileInternal(fs, sourceEntry, destPath)
+func (t *CopyFile) copyFile(ctx context.Context, fs *irodsclient_fs.FileSystem, sourceEntry *irodsclient_fs.Entry, destPath string, progress *irods_common.ProgressReporter, args *CopyFileInputArgs) (*model.CopyFileOutput, error) {
+	policy := CopyConflictPolicy(args.OnConflict)
+	if len(policy) == 0 {
+		policy = CopyConflictFail
+	}
+
+	// resolve the destination
+	merge := false
+	destEntry, err := fs.Stat(destPath)
 	if err != nil {
-		return nil, err
+		if !irodsclient_types.IsFileNotFoundError(err) {
+			return nil, errors.Wrapf(err, "failed to stat file or directory info for %q", destPath)
+		}
+	} else {
+		switch policy {
+		case CopyConflictSkip, CopyConflictOverwrite:
+			if destEntry.IsDir() != sourceEntry.IsDir() {
+				return nil, errors.Newf("destination %q already exists and is not the same type as the source %q", destPath, sourceEntry.Path)
+			}
+
+			if !sourceEntry.IsDir() && policy == CopyConflictSkip {
+				return &model.CopyFileOutput{
+					SourcePath:          sourceEntry.Path,
+					DestinationPath:     destPath,
+					SourceEntryInfoList: []*irodsclient_fs.Entry{},
+					CopiedEntryInfoList: []*irodsclient_fs.Entry{},
+					SkippedPaths:        []string{destPath},
+				}, nil
+			}
+
+			merge = true
+		case CopyConflictRename:
+			destPath, err = t.makeAvailablePath(fs, destPath)
+			if err != nil {
+				return nil, err
+			}
+		default:
+			return nil, errors.Newf("destination %q already exists", destPath)
+		}
 	}
 
 	fileCopyOutput := &model.CopyFileOutput{
 		SourcePath:          sourceEntry.Path,
 		DestinationPath:     destPath,
-		SourceEntryInfoList: sourceEntries,
-		CopiedEntryInfoList: copiedEntries,
+		SourceEntryInfoList: []*irodsclient_fs.Entry{},
+		CopiedEntryInfoList: []*irodsclient_fs.Entry{},
+	}
+
+	if !sourceEntry.IsDir() {
+		// file
+		task := &copyTask{
+			sourceEntry: sourceEntry,
+			destPath:    destPath,
+			overwrite:   merge,
+		}
+
+		progress.SetTotal(1)
+		t.runCopyTask(fs, task, args)
+		if task.err != nil {
+			return nil, task.err
+		}
+
+		fileCopyOutput.SourceEntryInfoList = append(fileCopyOutput.SourceEntryInfoList, sourceEntry)
+		fileCopyOutput.CopiedEntryInfoList = append(fileCopyOutput.CopiedEntryInfoList, task.destEntry)
+		progress.Done(fmt.Sprintf("copied %q", sourceEntry.Path))
+		return fileCopyOutput, nil
+	}
+
+	// dir
+	tasks, err := t.planDirCopy(ctx, fs, sourceEntry, destPath, merge, policy, fileCopyOutput, args)
+	if err != nil {
+		return nil, err
+	}
+
+	progress.SetTotal(float64(len(tasks)))
+
+	irods_common.RunParallel(len(tasks), irods_common.GetParallelism(args.Parallelism), func(idx int) {
+		task := tasks[idx]
+		if ctx.Err() != nil {
+			task.err = ctx.Err()
+			return
+		}
+
+		t.runCopyTask(fs, task, args)
+		progress.Add(1, fmt.Sprintf("copied %q", task.sourceEntry.Path))
+	})
+
+	for _, task := range tasks {
+		if task.err != nil {
+			fileCopyOutput.FailedEntries = append(fileCopyOutput.FailedEntries, model.CopyFailure{
+				SourcePath:      task.sourceEntry.Path,
+				DestinationPath: task.destPath,
+				Error:           task.err.Error(),
+			})
+			continue
+		}
+
+		fileCopyOutput.SourceEntryInfoList = append(fileCopyOutput.SourceEntryInfoList, task.sourceEntry)
+		fileCopyOutput.CopiedEntryInfoList = append(fileCopyOutput.CopiedEntryInfoList, task.destEntry)
+	}
+
+	progress.Done(fmt.Sprintf("copied %q", sourceEntry.Path))
+
+	if len(tasks) > 0 && len(fileCopyOutput.FailedEntries) == len(tasks) {
+		return nil, errors.Newf("failed to copy all %d files (data-objects), first error: %s", len(tasks), fileCopyOutput.FailedEntries[0].Error)
 	}
 
 	return fileCopyOutput, nil
 }
 
-func (t *CopyFile) copyFileInternal(fs *irodsclient_fs.FileSystem, sourceEntry *irodsclient_fs.Entry, destPath string) ([]*irodsclient_fs.Entry, []*irodsclient_fs.Entry, error) {
-	sourceEntries := []*irodsclient_fs.Entry{sourceEntry}
+// planDirCopy makes destination directories (collections) and returns file copies to run
+func (t *CopyFile) planDirCopy(ctx context.Context, fs *irodsclient_fs.FileSystem, sourceEntry *irodsclient_fs.Entry, destPath string, merge bool, policy CopyConflictPolicy, fileCopyOutput *model.CopyFileOutput, args *CopyFileInputArgs) ([]*copyTask, error) {
+	// existing entries in the destination
+	destEntries := map[string]*irodsclient_fs.Entry{}
+	if merge {
+		err := irods_common.WalkCollection(fs, destPath, 0, func(entry *irodsclient_fs.Entry, depth int) error {
+			if ctx.Err() != nil {
+				return ctx.Err()
+			}
+
+			destEntries[irods_common.GetRelativeIRODSPath(destPath, entry.Path)] = entry
+			return nil
+		})
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	sourceDirs := []*irodsclient_fs.Entry{sourceEntry}
+	tasks := []*copyTask{}
 
-	if !sourceEntry.IsDir() {
-		// file
-		err := fs.CopyFileToFile(sourceEntry.Path, destPath, true)
+	err := irods_common.WalkCollection(fs, sourceEntry.Path, 0, func(entry *irodsclient_fs.Entry, depth int) error {
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+
+		relPath := irods_common.GetRelativeIRODSPath(sourceEntry.Path, entry.Path)
+		destEntryPath := path.Join(destPath, relPath)
+		existingEntry := destEntries[relPath]
+
+		if existingEntry != nil && existingEntry.IsDir() != entry.IsDir() {
+			fileCopyOutput.FailedEntries = append(fileCopyOutput.FailedEntries, model.CopyFailure{
+				SourcePath:      entry.Path,
+				DestinationPath: destEntryPath,
+				Error:           fmt.Sprintf("destination %q already exists and is not the same type as the source", destEntryPath),
+			})
+
+			if entry.IsDir() {
+				return irods_common.ErrSkipDir
+			}
+			return nil
+		}
+
+		if entry.IsDir() {
+			sourceDirs = append(sourceDirs, entry)
+			return nil
+		}
+
+		if existingEntry != nil && policy == CopyConflictSkip {
+			fileCopyOutput.SkippedPaths = append(fileCopyOutput.SkippedPaths, destEntryPath)
+			return nil
+		}
+
+		tasks = append(tasks, &copyTask{
+			sourceEntry: entry,
+			destPath:    destEntryPath,
+			overwrite:   existingEntry != nil,
+		})
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	// make directories in order, parents first
+	for _, dirEntry := range sourceDirs {
+		relPath := irods_common.GetRelativeIRODSPath(sourceEntry.Path, dirEntry.Path)
+		destDirPath := path.Join(destPath, relPath)
+
+		// existing directories are merged
+		err = fs.MakeDir(destDirPath, true)
 		if err != nil {
-			return sourceEntries, nil, errors.Wrapf(err, "failed to copy file (data-object) from %q to %q", sourceEntry.Path, destPath)
+			return nil, errors.Wrapf(err, "failed to copy directory (collection) from %q to %q", dirEntry.Path, destDirPath)
 		}
 
-		destEntry, err := fs.Stat(destPath)
+		err = t.preserveMetadata(fs, dirEntry.Path, destDirPath, args)
 		if err != nil {
-			return sourceEntries, nil, errors.Wrapf(err, "failed to stat file or directory info for %q", destPath)
+			return nil, err
+		}
+
+		destDirEntry, statErr := fs.Stat(destDirPath)
+		if statErr != nil {
+			return nil, errors.Wrapf(statErr, "failed to stat file or directory info for %q", destDirPath)
 		}
 
-		return sourceEntries, []*irodsclient_fs.Entry{destEntry}, nil
+		fileCopyOutput.SourceEntryInfoList = append(fileCopyOutput.SourceEntryInfoList, dirEntry)
+		fileCopyOutput.CopiedEntryInfoList = append(fileCopyOutput.CopiedEntryInfoList, destDirEntry)
 	}
 
-	// dir
-	err := fs.MakeDir(destPath, true)
+	return tasks, nil
+}
+
+func (t *CopyFile) runCopyTask(fs *irodsclient_fs.FileSystem, task *copyTask, args *CopyFileInputArgs) {
+	err := fs.CopyFileToFile(task.sourceEntry.Path, task.destPath, task.overwrite)
 	if err != nil {
-		return sourceEntries, nil, errors.Wrapf(err, "failed to copy directory (collection) from %q to %q", sourceEntry.Path, destPath)
+		task.err = errors.Wrapf(err, "failed to copy file (data-object) from %q to %q", task.sourceEntry.Path, task.destPath)
+		return
 	}
 
-	destEntry, err := fs.Stat(destPath)
+	err = t.preserveMetadata(fs, task.sourceEntry.Path, task.destPath, args)
 	if err != nil {
-		return sourceEntries, nil, errors.Wrapf(err, "failed to stat file or directory info for %q", destPath)
+		task.err = err
+		return
 	}
 
-	copiedEntries := []*irodsclient_fs.Entry{destEntry}
-
-	entries, err := fs.List(sourceEntry.Path)
+	destEntry, err := fs.Stat(task.destPath)
 	if err != nil {
-		return sourceEntries, copiedEntries, errors.Wrapf(err, "failed to list directory (collection) entries for %q", sourceEntry.Path)
+		task.err = errors.Wrapf(err, "failed to stat file or directory info for %q", task.destPath)
+		return
+	}
+
+	task.destEntry = destEntry
+}
+
+func (t *CopyFile) preserveMetadata(fs *irodsclient_fs.FileSystem, sourcePath string, destPath string, args *CopyFileInputArgs) error {
+	if args.PreserveAVUs {
+		// AVUs of an existing destination are kept
+		err := irods_common.CopyMetadata(fs, sourcePath, destPath, false)
+		if err != nil {
+			return err
+		}
 	}
 
-	for _, entry := range entries {
-		// copy recursively
-		destEntryPath := path.Join(destPath, entry.Name)
-		rsourceEntries, rcopiedEntries, err := t.copyFileInternal(fs, entry, destEntryPath)
+	if args.PreserveACLs {
+		err := irods_common.CopyACLs(fs, sourcePath, destPath)
 		if err != nil {
-			return sourceEntries, copiedEntries, err
+			return err
 		}
+	}
+
+	return nil
+}
 
-		sourceEntries = append(sourceEntries, rsourceEntries...)
-		copiedEntries = append(copiedEntries, rcopiedEntries...)
+// makeAvailablePath returns a path that does not exist by adding a number to the name, such as 'name_1.txt'
+func (t *CopyFile) makeAvailablePath(fs *irodsclient_fs.FileSystem, destPath string) (string, error) {
+	dir := irods_common.GetIRODSPathDirname(destPath)
+	name := irods_common.GetIRODSPathBasename(destPath)
+	ext := path.Ext(name)
+	base := strings.TrimSuffix(name, ext)
+	if len(base) == 0 {
+		// hidden files, such as '.bashrc'
+		base = name
+		ext = ""
+	}
+
+	for idx := 1; idx <= copyMaxRenameAttempts; idx++ {
+		newPath := path.Join(dir, fmt.Sprintf("%s_%d%s", base, idx, ext))
+		if !fs.Exists(newPath) {
+			return newPath, nil
+		}
 	}
 
-	return sourceEntries, copiedEntries, nil
+	return "", errors.Newf("failed to find an available name for %q", destPath)
 }
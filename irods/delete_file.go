@@ -2,6 +2,7 @@ package irods
 
 import (
 	"context"
+	"fmt"
 
 	"github.com/cockroachdb/errors"
 	irodsclient_fs "github.com/cyverse/go-irodsclient/fs"
@@ -17,7 +18,9 @@ const (
 )
 
 type DeleteFileInputArgs struct {
-	Path string `json:"path"`
+	Path        string   `json:"path,omitempty"`
+	Paths       []string `json:"paths,omitempty"`
+	Parallelism int      `json:"parallelism,omitempty"`
 }
 
 type DeleteFile struct {
@@ -37,7 +40,8 @@ func (t *DeleteFile) GetName() string {
 }
 
 func (t *DeleteFile) GetDescription() string {
-	return `Delete a file (data-object) or directory (collection).`
+	return `Delete a file (data-object) or directory (collection).
+	To delete many files and directories in a single call, give 'paths' instead of 'path'. Batch items are processed in parallel and each item reports its own result.`
 }
 
 func (t *DeleteFile) GetTool() *mcp.Tool {
@@ -51,8 +55,13 @@ func (t *DeleteFile) GetTool() *mcp.Tool {
 					Type:        "string",
 					Description: "The path to the file (data-object) or directory (collection) to delete.",
 				},
+				"paths": {
+					Type:        "array",
+					Items:       &jsonschema.Schema{Type: "string"},
+					Description: fmt.Sprintf("The list of paths to delete as a batch, used instead of 'path'. Maximum is %d items.", irods_common.MaxBatchItems),
+				},
+				"parallelism": getBatchParallelismSchema(),
 			},
-			Required: []string{"path"},
 		},
 	}
 }
@@ -105,28 +114,58 @@ func (t *DeleteFile) Handler(ctx context.Context, request *mcp.CallToolRequest)
 		return irods_common.ToolErrorResult(outputErr), nil
 	}
 
-	irodsPath := irods_common.MakeIRODSPath(t.config, fs.GetAccount(), args.Path)
+	// batch
+	if len(args.Paths) > 0 {
+		if len(args.Path) > 0 {
+			outputErr := errors.Newf("paths cannot be given together with path")
+			return irods_common.ToolErrorResult(outputErr), nil
+		}
 
-	// check permission
-	if !irods_common.IsAccessAllowed(irodsPath, t.GetAccessiblePaths(&authValue)) {
-		outputErr := errors.Newf("%q request is not permitted for path %q", t.GetName(), irodsPath)
+		err = checkBatchSize(len(args.Paths))
+		if err != nil {
+			return irods_common.ToolErrorResult(err), nil
+		}
+
+		content := runBatch(ctx, len(args.Paths), args.Parallelism, func(idx int) (any, error) {
+			return t.deleteFileWithCheck(fs, &authValue, args.Paths[idx])
+		})
+
+		return irods_common.ToolJSONResult(*content)
+	}
+
+	if len(args.Path) == 0 {
+		outputErr := errors.Newf("either path or paths must be given")
 		return irods_common.ToolErrorResult(outputErr), nil
 	}
 
+	content, err := t.deleteFileWithCheck(fs, &authValue, args.Path)
+	if err != nil {
+		return irods_common.ToolErrorResult(err), nil
+	}
+
+	return irods_common.ToolJSONResult(*content)
+}
+
+func (t *DeleteFile) deleteFileWithCheck(fs *irodsclient_fs.FileSystem, authValue *common.AuthValue, path string) (*model.RemoveFileOutput, error) {
+	irodsPath := irods_common.MakeIRODSPath(t.config, fs.GetAccount(), path)
+
+	// check permission
+	if !irods_common.IsAccessAllowed(irodsPath, t.GetAccessiblePaths(authValue)) {
+		return nil, errors.Newf("%q request is not permitted for path %q", t.GetName(), irodsPath)
+	}
+
 	// Delete file
 	targetEntry, err := fs.Stat(irodsPath)
 	if err != nil {
-		outputErr := errors.Wrapf(err, "failed to stat file or directory info for %q", irodsPath)
-		return irods_common.ToolErrorResult(outputErr), nil
+		return nil, errors.Wrapf(err, "failed to stat file or directory info for %q", irodsPath)
 	}
 
 	content, err := t.deleteFile(fs, targetEntry)
 	if err != nil {
-		outputErr := errors.Wrapf(err, "failed to delete file (data-object) or directory (collection) %q", irodsPath)
-		return irods_common.ToolErrorResult(outputErr), nil
+		return nil, errors.Wrapf(err, "failed to delete file (data-object) or directory (collection) %q", irodsPath)
 	}
 
-	return irods_common.ToolJSONResult(*content)
+	return content, nil
 }
 
 func (t *DeleteFile) deleteFile(fs *irodsclient_fs.FileSystem, targetEntry *irodsclient_fs.Entry) (*model.RemoveFileOutput, error) {
@@ -0,0 +1,137 @@
+package irods
+
+import (
+	"context"
+
+	"github.com/cockroachdb/errors"
+	"github.com/cyverse/irods-mcp-server/common"
+	irods_common "github.com/cyverse/irods-mcp-server/irods/common"
+	"github.com/cyverse/irods-mcp-server/irods/model"
+	"github.com/google/jsonschema-go/jsonschema"
+	"github.com/modelcontextprotocol/go-sdk/mcp"
+)
+
+const (
+	DeleteSavedQueryName = irods_common.IRODSAPIPrefix + "delete_saved_query"
+)
+
+type DeleteSavedQueryInputArgs struct {
+	Name string `json:"name"`
+}
+
+type DeleteSavedQuery struct {
+	mcpServer *IRODSMCPServer
+	config    *common.Config
+}
+
+func NewDeleteSavedQuery(svr *IRODSMCPServer) ToolAPI {
+	return &DeleteSavedQuery{
+		mcpServer: svr,
+		config:    svr.GetConfig(),
+	}
+}
+
+func (t *DeleteSavedQuery) GetName() string {
+	return DeleteSavedQueryName
+}
+
+func (t *DeleteSavedQuery) GetDescription() string {
+	return `Delete a saved query of the user by name. Its 'irods-query://<name>' resource is no longer available.`
+}
+
+func (t *DeleteSavedQuery) GetTool() *mcp.Tool {
+	return &mcp.Tool{
+		Name:        t.GetName(),
+		Description: t.GetDescription(),
+		InputSchema: &jsonschema.Schema{
+			Type: "object",
+			Properties: map[string]*jsonschema.Schema{
+				"name": {
+					Type:        "string",
+					Description: "The name of the saved query to delete.",
+				},
+			},
+			Required: []string{"name"},
+		},
+	}
+}
+
+func (t *DeleteSavedQuery) GetHandler() mcp.ToolHandler {
+	return t.Handler
+}
+
+func (t *DeleteSavedQuery) GetAccessiblePaths(authValue *common.AuthValue) []string {
+	account, err := t.mcpServer.GetIRODSAccountFromAuthValue(authValue)
+	if err != nil {
+		return []string{}
+	}
+
+	homePath := irods_common.GetHomePath(t.config, account)
+
+	paths := []string{}
+	if !account.IsAnonymousUser() {
+		paths = append(paths, homePath)
+		paths = append(paths, homePath+"/*")
+	}
+
+	return paths
+}
+
+func (t *DeleteSavedQuery) Handler(ctx context.Context, request *mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	// arguments
+	args := DeleteSavedQueryInputArgs{}
+	err := irods_common.MarshalInputArguments(t.GetTool(), request, &args)
+	if err != nil {
+		outputErr := errors.Wrapf(err, "failed to marshal input arguments")
+		return irods_common.ToolErrorResult(outputErr), nil
+	}
+
+	// auth
+	authValue, err := common.GetAuthValue(ctx)
+	if err != nil {
+		outputErr := errors.Wrapf(err, "failed to get auth value")
+		return irods_common.ToolErrorResult(outputErr), nil
+	}
+
+	// make a irods filesystem client
+	fs, err := t.mcpServer.GetIRODSFSClientFromAuthValue(&authValue)
+	if err != nil {
+		outputErr := errors.Wrapf(err, "failed to create a irods fs client")
+		return irods_common.ToolErrorResult(outputErr), nil
+	}
+
+	storagePath, err := getSavedQueriesPath(t.config, fs.GetAccount())
+	if err != nil {
+		return irods_common.ToolErrorResult(err), nil
+	}
+
+	savedQueriesLock.Lock()
+	defer savedQueriesLock.Unlock()
+
+	savedQueries, err := loadSavedQueries(fs, storagePath)
+	if err != nil {
+		outputErr := errors.Wrapf(err, "failed to load saved queries")
+		return irods_common.ToolErrorResult(outputErr), nil
+	}
+
+	idx := findSavedQuery(savedQueries, args.Name)
+	if idx < 0 {
+		outputErr := errors.Newf("saved query %q is not found", args.Name)
+		return irods_common.ToolErrorResult(outputErr), nil
+	}
+
+	savedQueries.Queries = append(savedQueries.Queries[:idx], savedQueries.Queries[idx+1:]...)
+
+	err = storeSavedQueries(fs, storagePath, savedQueries)
+	if err != nil {
+		outputErr := errors.Wrapf(err, "failed to store saved queries")
+		return irods_common.ToolErrorResult(outputErr), nil
+	}
+
+	content := model.DeleteSavedQueryOutput{
+		Name:        args.Name,
+		StoragePath: storagePath,
+	}
+
+	return irods_common.ToolJSONResult(content)
+}
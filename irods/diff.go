@@ -0,0 +1,507 @@
+package irods
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/cockroachdb/errors"
+	irodsclient_fs "github.com/cyverse/go-irodsclient/fs"
+	"github.com/cyverse/irods-mcp-server/common"
+	irods_common "github.com/cyverse/irods-mcp-server/irods/common"
+	"github.com/cyverse/irods-mcp-server/irods/model"
+	"github.com/google/jsonschema-go/jsonschema"
+	"github.com/modelcontextprotocol/go-sdk/mcp"
+)
+
+const (
+	DiffName = irods_common.IRODSAPIPrefix + "diff"
+
+	diffMaxOutputSizeDefault int = 64 * 1024 // 64KB
+	diffMaxEntriesDefault    int = 1000
+	diffMaxEntries           int = 10000
+	diffMaxScanEntries       int = 100000
+)
+
+type DiffInputArgs struct {
+	Path            string  `json:"path"`
+	TargetPath      string  `json:"target_path,omitempty"`
+	Text            *string `json:"text,omitempty"`
+	ContextLines    int     `json:"context_lines,omitempty"`
+	CompareMtime    bool    `json:"compare_mtime,omitempty"`
+	CompareChecksum bool    `json:"compare_checksum,omitempty"`
+	MaxDepth        int     `json:"max_depth,omitempty"`
+	MaxOutputSize   int     `json:"max_output_size,omitempty"`
+	MaxEntries      int     `json:"max_entries,omitempty"`
+}
+
+type Diff struct {
+	mcpServer *IRODSMCPServer
+	config    *common.Config
+}
+
+func NewDiff(svr *IRODSMCPServer) ToolAPI {
+	return &Diff{
+		mcpServer: svr,
+		config:    svr.GetConfig(),
+	}
+}
+
+func (t *Diff) GetName() string {
+	return DiffName
+}
+
+func (t *Diff) GetDescription() string {
+	return `Compare two text files (data-objects), a text file against the given text, or two directories (collections).
+	The specified paths must be iRODS paths.
+	For files, returns a unified diff. For directories, returns added, removed, and changed entries compared by size, modification time, and optionally checksum.`
+}
+
+func (t *Diff) GetTool() *mcp.Tool {
+	return &mcp.Tool{
+		Name:        t.GetName(),
+		Description: t.GetDescription(),
+		InputSchema: &jsonschema.Schema{
+			Type: "object",
+			Properties: map[string]*jsonschema.Schema{
+				"path": {
+					Type:        "string",
+					Description: "The path to the original file (data-object) or directory (collection).",
+				},
+				"target_path": {
+					Type:        "string",
+					Description: "The path to the file (data-object) or directory (collection) to compare with. Either 'target_path' or 'text' must be given.",
+				},
+				"text": {
+					Type:        "string",
+					Description: "The text to compare the file (data-object) with. Either 'target_path' or 'text' must be given.",
+				},
+				"context_lines": {
+					Type:        "number",
+					Description: fmt.Sprintf("The number of context lines in the unified diff. Default is %d.", irods_common.DefaultDiffContextLines),
+					Default:     json.RawMessage(fmt.Sprintf("%d", irods_common.DefaultDiffContextLines)),
+				},
+				"compare_mtime": {
+					Type:        "boolean",
+					Description: "Set to false to ignore modification time when comparing directories (collections). Default is true.",
+					Default:     json.RawMessage("true"),
+				},
+				"compare_checksum": {
+					Type:        "boolean",
+					Description: "Set to true to compare checksums of files with the same size when comparing directories (collections). Missing checksums are computed on the server. Default is false.",
+					Default:     json.RawMessage("false"),
+				},
+				"max_depth": {
+					Type:        "number",
+					Description: "The maximum depth to compare directories (collections). Default is 0, which means unlimited.",
+					Default:     json.RawMessage("0"),
+				},
+				"max_output_size": {
+					Type:        "number",
+					Description: fmt.Sprintf("The maximum size of the unified diff in bytes. Default is %d bytes.", diffMaxOutputSizeDefault),
+					Default:     json.RawMessage(fmt.Sprintf("%d", diffMaxOutputSizeDefault)),
+				},
+				"max_entries": {
+					Type:        "number",
+					Description: fmt.Sprintf("The maximum number of differing entries to return when comparing directories (collections). Maximum is %d. Default is %d.", diffMaxEntries, diffMaxEntriesDefault),
+					Default:     json.RawMessage(fmt.Sprintf("%d", diffMaxEntriesDefault)),
+				},
+			},
+			Required: []string{"path"},
+		},
+	}
+}
+
+func (t *Diff) GetHandler() mcp.ToolHandler {
+	return t.Handler
+}
+
+func (t *Diff) GetAccessiblePaths(authValue *common.AuthValue) []string {
+	account, err := t.mcpServer.GetIRODSAccountFromAuthValue(authValue)
+	if err != nil {
+		return []string{}
+	}
+
+	homePath := irods_common.GetHomePath(t.config, account)
+	sharedPath := irods_common.GetSharedPath(t.config, account)
+
+	paths := []string{
+		sharedPath + "/*",
+	}
+
+	if !account.IsAnonymousUser() {
+		paths = append(paths, homePath)
+		paths = append(paths, homePath+"/*")
+	}
+
+	return paths
+}
+
+func (t *Diff) Handler(ctx context.Context, request *mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	// arguments
+	args := DiffInputArgs{}
+	err := irods_common.MarshalInputArguments(t.GetTool(), request, &args)
+	if err != nil {
+		outputErr := errors.Wrapf(err, "failed to marshal input arguments")
+		return irods_common.ToolErrorResult(outputErr), nil
+	}
+
+	if len(args.TargetPath) == 0 && args.Text == nil {
+		outputErr := errors.Newf("either target_path or text must be given")
+		return irods_common.ToolErrorResult(outputErr), nil
+	} else if len(args.TargetPath) > 0 && args.Text != nil {
+		outputErr := errors.Newf("target_path and text cannot be given together")
+		return irods_common.ToolErrorResult(outputErr), nil
+	}
+
+	// auth
+	authValue, err := common.GetAuthValue(ctx)
+	if err != nil {
+		outputErr := errors.Wrapf(err, "failed to get auth value")
+		return irods_common.ToolErrorResult(outputErr), nil
+	}
+
+	// make a irods filesystem client
+	fs, err := t.mcpServer.GetIRODSFSClientFromAuthValue(&authValue)
+	if err != nil {
+		outputErr := errors.Wrapf(err, "failed to create a irods fs client")
+		return irods_common.ToolErrorResult(outputErr), nil
+	}
+
+	irodsPath := irods_common.MakeIRODSPath(t.config, fs.GetAccount(), args.Path)
+
+	// check permission
+	if !irods_common.IsAccessAllowed(irodsPath, t.GetAccessiblePaths(&authValue)) {
+		outputErr := errors.Newf("%q request is not permitted for path %q", t.GetName(), irodsPath)
+		return irods_common.ToolErrorResult(outputErr), nil
+	}
+
+	entry, err := fs.Stat(irodsPath)
+	if err != nil {
+		outputErr := errors.Wrapf(err, "failed to stat file info for %q", irodsPath)
+		return irods_common.ToolErrorResult(outputErr), nil
+	}
+
+	// compare with text
+	if args.Text != nil {
+		if entry.IsDir() {
+			outputErr := errors.Newf("path %q is a directory (collection), text can only be compared with a file (data-object)", irodsPath)
+			return irods_common.ToolErrorResult(outputErr), nil
+		}
+
+		content, err := t.diffText(fs, entry, *args.Text, &args)
+		if err != nil {
+			outputErr := errors.Wrapf(err, "failed to compare file (data-object) %q with text", irodsPath)
+			return irods_common.ToolErrorResult(outputErr), nil
+		}
+
+		return irods_common.ToolJSONResult(*content)
+	}
+
+	targetIRODSPath := irods_common.MakeIRODSPath(t.config, fs.GetAccount(), args.TargetPath)
+
+	// check permission
+	if !irods_common.IsAccessAllowed(targetIRODSPath, t.GetAccessiblePaths(&authValue)) {
+		outputErr := errors.Newf("%q request is not permitted for path %q", t.GetName(), targetIRODSPath)
+		return irods_common.ToolErrorResult(outputErr), nil
+	}
+
+	targetEntry, err := fs.Stat(targetIRODSPath)
+	if err != nil {
+		outputErr := errors.Wrapf(err, "failed to stat file info for %q", targetIRODSPath)
+		return irods_common.ToolErrorResult(outputErr), nil
+	}
+
+	if entry.IsDir() != targetEntry.IsDir() {
+		outputErr := errors.Newf("cannot compare a file (data-object) with a directory (collection), %q and %q", irodsPath, targetIRODSPath)
+		return irods_common.ToolErrorResult(outputErr), nil
+	}
+
+	if entry.IsDir() {
+		content, err := t.diffCollections(ctx, fs, entry, targetEntry, &args)
+		if err != nil {
+			outputErr := errors.Wrapf(err, "failed to compare directories (collections) %q and %q", irodsPath, targetIRODSPath)
+			return irods_common.ToolErrorResult(outputErr), nil
+		}
+
+		return irods_common.ToolJSONResult(*content)
+	}
+
+	content, err := t.diffFiles(fs, entry, targetEntry, &args)
+	if err != nil {
+		outputErr := errors.Wrapf(err, "failed to compare files (data-objects) %q and %q", irodsPath, targetIRODSPath)
+		return irods_common.ToolErrorResult(outputErr), nil
+	}
+
+	return irods_common.ToolJSONResult(*content)
+}
+
+func (t *Diff) readTextFile(fs *irodsclient_fs.FileSystem, entry *irodsclient_fs.Entry) (string, error) {
+	if entry.Size > irods_common.MaxInlineSize {
+		return "", errors.Newf("file %q is too large to compare (%d bytes), maximum size is %d bytes", entry.Path, entry.Size, irods_common.MaxInlineSize)
+	}
+
+	byteContent, err := irods_common.ReadDataObject(fs, entry.Path, 0, entry.Size)
+	if err != nil {
+		return "", errors.Wrapf(err, "failed to read file (data-object) %q", entry.Path)
+	}
+
+	if !utf8.Valid(byteContent) {
+		return "", errors.Newf("file (data-object) %q is not a UTF-8 text file", entry.Path)
+	}
+
+	return string(byteContent), nil
+}
+
+func (t *Diff) diffText(fs *irodsclient_fs.FileSystem, entry *irodsclient_fs.Entry, text string, args *DiffInputArgs) (*model.DiffFilesOutput, error) {
+	oldText, err := t.readTextFile(fs, entry)
+	if err != nil {
+		return nil, err
+	}
+
+	return t.makeDiffFilesOutput(entry.Path, "", oldText, text, args), nil
+}
+
+func (t *Diff) diffFiles(fs *irodsclient_fs.FileSystem, entry *irodsclient_fs.Entry, targetEntry *irodsclient_fs.Entry, args *DiffInputArgs) (*model.DiffFilesOutput, error) {
+	// identical checksums mean identical content
+	checksum := irods_common.GetEntryChecksum(entry)
+	targetChecksum := irods_common.GetEntryChecksum(targetEntry)
+	if entry.Size == targetEntry.Size && irods_common.IsSameChecksum(checksum, targetChecksum) {
+		return &model.DiffFilesOutput{
+			Path:       entry.Path,
+			TargetPath: targetEntry.Path,
+			Identical:  true,
+		}, nil
+	}
+
+	oldText, err := t.readTextFile(fs, entry)
+	if err != nil {
+		return nil, err
+	}
+
+	newText, err := t.readTextFile(fs, targetEntry)
+	if err != nil {
+		return nil, err
+	}
+
+	return t.makeDiffFilesOutput(entry.Path, targetEntry.Path, oldText, newText, args), nil
+}
+
+func (t *Diff) makeDiffFilesOutput(path string, targetPath string, oldText string, newText string, args *DiffInputArgs) *model.DiffFilesOutput {
+	newName := targetPath
+	if len(newName) == 0 {
+		newName = path
+	}
+
+	contextLines := args.ContextLines
+	if contextLines < 0 {
+		contextLines = 0
+	}
+
+	diff := irods_common.UnifiedDiff(path, newName, oldText, newText, contextLines)
+
+	output := &model.DiffFilesOutput{
+		Path:       path,
+		TargetPath: targetPath,
+		Identical:  len(diff) == 0,
+		Diff:       diff,
+	}
+
+	maxOutputSize := args.MaxOutputSize
+	if maxOutputSize <= 0 {
+		maxOutputSize = diffMaxOutputSizeDefault
+	}
+
+	if len(diff) > maxOutputSize {
+		// cut at a line boundary
+		lines := irods_common.SplitLines(diff)
+		size := 0
+		cut := 0
+		for _, line := range lines {
+			if size+len(line) > maxOutputSize {
+				break
+			}
+			size += len(line)
+			cut++
+		}
+
+		output.Diff = strings.Join(lines[:cut], "")
+		output.Truncated = true
+	}
+
+	return output
+}
+
+func (t *Diff) scanCollection(ctx context.Context, fs *irodsclient_fs.FileSystem, rootPath string, maxDepth int) (map[string]*irodsclient_fs.Entry, error) {
+	entries := map[string]*irodsclient_fs.Entry{}
+
+	err := irods_common.WalkCollection(fs, rootPath, maxDepth, func(entry *irodsclient_fs.Entry, depth int) error {
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+
+		if len(entries) >= diffMaxScanEntries {
+			return errors.Newf("directory (collection) %q has too many entries, maximum is %d", rootPath, diffMaxScanEntries)
+		}
+
+		entries[irods_common.GetRelativeIRODSPath(rootPath, entry.Path)] = entry
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return entries, nil
+}
+
+func (t *Diff) diffCollections(ctx context.Context, fs *irodsclient_fs.FileSystem, entry *irodsclient_fs.Entry, targetEntry *irodsclient_fs.Entry, args *DiffInputArgs) (*model.DiffCollectionsOutput, error) {
+	sourceEntries, err := t.scanCollection(ctx, fs, entry.Path, args.MaxDepth)
+	if err != nil {
+		return nil, err
+	}
+
+	targetEntries, err := t.scanCollection(ctx, fs, targetEntry.Path, args.MaxDepth)
+	if err != nil {
+		return nil, err
+	}
+
+	maxEntries := args.MaxEntries
+	if maxEntries <= 0 {
+		maxEntries = diffMaxEntriesDefault
+	} else if maxEntries > diffMaxEntries {
+		maxEntries = diffMaxEntries
+	}
+
+	output := &model.DiffCollectionsOutput{
+		Path:       entry.Path,
+		TargetPath: targetEntry.Path,
+		Added:      []model.DiffEntry{},
+		Removed:    []model.DiffEntry{},
+		Changed:    []model.DiffEntry{},
+	}
+
+	relPaths := make([]string, 0, len(sourceEntries)+len(targetEntries))
+	for relPath := range sourceEntries {
+		relPaths = append(relPaths, relPath)
+	}
+	for relPath := range targetEntries {
+		if _, ok := sourceEntries[relPath]; !ok {
+			relPaths = append(relPaths, relPath)
+		}
+	}
+	sort.Strings(relPaths)
+
+	count := 0
+	for _, relPath := range relPaths {
+		if ctx.Err() != nil {
+			return nil, ctx.Err()
+		}
+
+		sourceEntry := sourceEntries[relPath]
+		destEntry := targetEntries[relPath]
+
+		diffEntry := model.DiffEntry{
+			RelativePath: relPath,
+		}
+
+		if sourceEntry != nil {
+			diffEntry.Type = string(sourceEntry.Type)
+			diffEntry.Size = sourceEntry.Size
+			diffEntry.ModifyTime = &sourceEntry.ModifyTime
+		}
+
+		if destEntry != nil {
+			diffEntry.Type = string(destEntry.Type)
+			diffEntry.TargetSize = destEntry.Size
+			diffEntry.TargetModifyTime = &destEntry.ModifyTime
+		}
+
+		var list *[]model.DiffEntry
+		switch {
+		case sourceEntry == nil:
+			list = &output.Added
+		case destEntry == nil:
+			list = &output.Removed
+		default:
+			reasons, err := t.compareEntries(fs, sourceEntry, destEntry, args)
+			if err != nil {
+				return nil, err
+			}
+
+			if len(reasons) == 0 {
+				output.Unchanged++
+				continue
+			}
+
+			diffEntry.Reasons = reasons
+			list = &output.Changed
+		}
+
+		if count >= maxEntries {
+			output.Truncated = true
+			continue
+		}
+
+		*list = append(*list, diffEntry)
+		count++
+	}
+
+	return output, nil
+}
+
+func (t *Diff) compareEntries(fs *irodsclient_fs.FileSystem, sourceEntry *irodsclient_fs.Entry, targetEntry *irodsclient_fs.Entry, args *DiffInputArgs) ([]string, error) {
+	if sourceEntry.Type != targetEntry.Type {
+		return []string{"type"}, nil
+	}
+
+	if sourceEntry.IsDir() {
+		return nil, nil
+	}
+
+	reasons := []string{}
+	if sourceEntry.Size != targetEntry.Size {
+		reasons = append(reasons, "size")
+	}
+
+	if args.CompareMtime && sourceEntry.ModifyTime.Unix() != targetEntry.ModifyTime.Unix() {
+		reasons = append(reasons, "mtime")
+	}
+
+	if args.CompareChecksum && sourceEntry.Size == targetEntry.Size {
+		same, err := t.hasSameChecksum(fs, sourceEntry, targetEntry)
+		if err != nil {
+			return nil, err
+		}
+
+		if !same {
+			reasons = append(reasons, "checksum")
+		}
+	}
+
+	return reasons, nil
+}
+
+func (t *Diff) hasSameChecksum(fs *irodsclient_fs.FileSystem, sourceEntry *irodsclient_fs.Entry, targetEntry *irodsclient_fs.Entry) (bool, error) {
+	var err error
+
+	checksum := irods_common.GetEntryChecksum(sourceEntry)
+	if len(checksum) == 0 {
+		checksum, err = irods_common.GetDataObjectChecksum(fs, sourceEntry.Path)
+		if err != nil {
+			return false, err
+		}
+	}
+
+	targetChecksum := irods_common.GetEntryChecksum(targetEntry)
+	if len(targetChecksum) == 0 {
+		targetChecksum, err = irods_common.GetDataObjectChecksum(fs, targetEntry.Path)
+		if err != nil {
+			return false, err
+		}
+	}
+
+	return irods_common.IsSameChecksum(checksum, targetChecksum), nil
+}
@@ -0,0 +1,318 @@
+package irods
+
+import (
+	"context"
+	"encoding/json"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/cockroachdb/errors"
+	irodsclient_fs "github.com/cyverse/go-irodsclient/fs"
+	"github.com/cyverse/irods-mcp-server/common"
+	irods_common "github.com/cyverse/irods-mcp-server/irods/common"
+	"github.com/cyverse/irods-mcp-server/irods/model"
+	"github.com/google/jsonschema-go/jsonschema"
+	"github.com/modelcontextprotocol/go-sdk/mcp"
+)
+
+const (
+	EditFileName = irods_common.IRODSAPIPrefix + "edit_file"
+)
+
+type EditFileReplacement struct {
+	OldText    string `json:"old_text"`
+	NewText    string `json:"new_text"`
+	ReplaceAll bool   `json:"replace_all,omitempty"`
+}
+
+type EditFileInputArgs struct {
+	Path    string                          `json:"path"`
+	Edits   []EditFileReplacement           `json:"edits,omitempty"`
+	Patch   string                          `json:"patch,omitempty"`
+	IfMatch *irods_common.WritePrecondition `json:"if_match,omitempty"`
+	DryRun  bool                            `json:"dry_run,omitempty"`
+}
+
+type EditFile struct {
+	mcpServer *IRODSMCPServer
+	config    *common.Config
+}
+
+func NewEditFile(svr *IRODSMCPServer) ToolAPI {
+	return &EditFile{
+		mcpServer: svr,
+		config:    svr.GetConfig(),
+	}
+}
+
+func (t *EditFile) GetName() string {
+	return EditFileName
+}
+
+func (t *EditFile) GetDescription() string {
+	return `Edit a text file (data-object) with the specified path using exact search/replace pairs or a unified diff.
+	The specified path must be an iRODS path.
+	The edited content is written to a temporary file first, then renamed into place.
+	Returns the resulting diff in unified diff format.`
+}
+
+func (t *EditFile) GetTool() *mcp.Tool {
+	return &mcp.Tool{
+		Name:        t.GetName(),
+		Description: t.GetDescription(),
+		InputSchema: &jsonschema.Schema{
+			Type: "object",
+			Properties: map[string]*jsonschema.Schema{
+				"path": {
+					Type:        "string",
+					Description: "The path to the text file (data-object) to edit.",
+				},
+				"edits": {
+					Type:        "array",
+					Description: "The list of search/replace pairs, applied in order. Either 'edits' or 'patch' must be given.",
+					Items: &jsonschema.Schema{
+						Type: "object",
+						Properties: map[string]*jsonschema.Schema{
+							"old_text": {
+								Type:        "string",
+								Description: "The exact text to search for. The edit fails if it is not found, or found more than once without 'replace_all'.",
+							},
+							"new_text": {
+								Type:        "string",
+								Description: "The text to replace with.",
+							},
+							"replace_all": {
+								Type:        "boolean",
+								Description: "Set to true to replace all occurrences of 'old_text'. Default is false.",
+								Default:     json.RawMessage("false"),
+							},
+						},
+						Required: []string{"old_text", "new_text"},
+					},
+				},
+				"patch": {
+					Type:        "string",
+					Description: "The unified diff to apply, line counts in hunk headers must match the hunk lines. Either 'edits' or 'patch' must be given.",
+				},
+				"if_match": {
+					Type:        "object",
+					Description: "Preconditions on the file. The edit fails if any of the given conditions does not match the file before or after the edited content is prepared.",
+					Properties: map[string]*jsonschema.Schema{
+						"checksum": {
+							Type:        "string",
+							Description: "The expected iRODS checksum of the file, e.g., 'sha2:...'.",
+						},
+						"modify_time": {
+							Type:        "string",
+							Description: "The expected modification time of the file in RFC3339 format.",
+						},
+					},
+				},
+				"dry_run": {
+					Type:        "boolean",
+					Description: "Set to true to return the resulting diff without changing the file. Default is false.",
+					Default:     json.RawMessage("false"),
+				},
+			},
+			Required: []string{"path"},
+		},
+	}
+}
+
+func (t *EditFile) GetHandler() mcp.ToolHandler {
+	return t.Handler
+}
+
+func (t *EditFile) GetAccessiblePaths(authValue *common.AuthValue) []string {
+	account, err := t.mcpServer.GetIRODSAccountFromAuthValue(authValue)
+	if err != nil {
+		return []string{}
+	}
+
+	homePath := irods_common.GetHomePath(t.config, account)
+	sharedPath := irods_common.GetSharedPath(t.config, account)
+
+	paths := []string{
+		sharedPath + "/*",
+	}
+
+	if !account.IsAnonymousUser() {
+		paths = append(paths, homePath)
+		paths = append(paths, homePath+"/*")
+	}
+
+	return paths
+}
+
+func (t *EditFile) Handler(ctx context.Context, request *mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	// arguments
+	args := EditFileInputArgs{}
+	err := irods_common.MarshalInputArguments(t.GetTool(), request, &args)
+	if err != nil {
+		outputErr := errors.Wrapf(err, "failed to marshal input arguments")
+		return irods_common.ToolErrorResult(outputErr), nil
+	}
+
+	if len(args.Edits) == 0 && len(args.Patch) == 0 {
+		outputErr := errors.Newf("either edits or patch must be given")
+		return irods_common.ToolErrorResult(outputErr), nil
+	} else if len(args.Edits) > 0 && len(args.Patch) > 0 {
+		outputErr := errors.Newf("edits and patch cannot be given together")
+		return irods_common.ToolErrorResult(outputErr), nil
+	}
+
+	// auth
+	authValue, err := common.GetAuthValue(ctx)
+	if err != nil {
+		outputErr := errors.Wrapf(err, "failed to get auth value")
+		return irods_common.ToolErrorResult(outputErr), nil
+	}
+
+	// make a irods filesystem client
+	fs, err := t.mcpServer.GetIRODSFSClientFromAuthValue(&authValue)
+	if err != nil {
+		outputErr := errors.Wrapf(err, "failed to create a irods fs client")
+		return irods_common.ToolErrorResult(outputErr), nil
+	}
+
+	irodsPath := irods_common.MakeIRODSPath(t.config, fs.GetAccount(), args.Path)
+
+	// check permission
+	if !irods_common.IsAccessAllowed(irodsPath, t.GetAccessiblePaths(&authValue)) {
+		outputErr := errors.Newf("%q request is not permitted for path %q", t.GetName(), irodsPath)
+		return irods_common.ToolErrorResult(outputErr), nil
+	}
+
+	// Get file info
+	entry, err := fs.Stat(irodsPath)
+	if err != nil {
+		outputErr := errors.Wrapf(err, "failed to stat file info for %q", irodsPath)
+		return irods_common.ToolErrorResult(outputErr), nil
+	}
+
+	if entry.IsDir() {
+		outputErr := errors.Newf("path %q is a directory (collection)", irodsPath)
+		return irods_common.ToolErrorResult(outputErr), nil
+	}
+
+	// the cached entry may miss writes by other clients, its size must match the content read
+	entry, err = irods_common.StatFileNoCache(fs, irodsPath)
+	if err != nil {
+		outputErr := errors.Wrapf(err, "failed to stat file info for %q", irodsPath)
+		return irods_common.ToolErrorResult(outputErr), nil
+	}
+
+	if entry.Size > irods_common.MaxInlineSize {
+		outputErr := errors.Newf("file %q is too large to edit (%d bytes), maximum size is %d bytes", irodsPath, entry.Size, irods_common.MaxInlineSize)
+		return irods_common.ToolErrorResult(outputErr), nil
+	}
+
+	// check preconditions
+	err = irods_common.CheckWritePrecondition(fs, irodsPath, args.IfMatch)
+	if err != nil {
+		return irods_common.ToolErrorResult(err), nil
+	}
+
+	content, err := t.editFile(fs, entry, &args)
+	if err != nil {
+		outputErr := errors.Wrapf(err, "failed to edit file (data-object) for %q", irodsPath)
+		return irods_common.ToolErrorResult(outputErr), nil
+	}
+
+	return irods_common.ToolJSONResult(*content)
+}
+
+func (t *EditFile) editFile(fs *irodsclient_fs.FileSystem, entry *irodsclient_fs.Entry, args *EditFileInputArgs) (*model.EditFileOutput, error) {
+	byteContent, err := irods_common.ReadDataObject(fs, entry.Path, 0, entry.Size)
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to read file (data-object) %q", entry.Path)
+	}
+
+	if !utf8.Valid(byteContent) {
+		return nil, errors.Newf("file (data-object) %q is not a UTF-8 text file", entry.Path)
+	}
+
+	oldText := string(byteContent)
+	newText := ""
+	if len(args.Patch) > 0 {
+		newText, err = irods_common.ApplyUnifiedDiff(oldText, args.Patch)
+		if err != nil {
+			return nil, errors.Wrapf(err, "failed to apply patch")
+		}
+	} else {
+		newText, err = t.applyReplacements(oldText, args.Edits)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	diff := irods_common.UnifiedDiff(entry.Path, entry.Path, oldText, newText, irods_common.DefaultDiffContextLines)
+
+	editFileOutput := &model.EditFileOutput{
+		Path:       entry.Path,
+		Diff:       diff,
+		DryRun:     args.DryRun,
+		Size:       entry.Size,
+		Checksum:   irods_common.GetEntryChecksum(entry),
+		ModifyTime: entry.ModifyTime,
+	}
+
+	if args.DryRun || len(diff) == 0 {
+		// nothing to write
+		return editFileOutput, nil
+	}
+
+	// write to a temp file
+	tempPath := irods_common.MakeTempDataObjectPath(entry.Path, "edit")
+	err = irods_common.WriteDataObject(fs, tempPath, irods_common.WriteModeCreateNew, 0, []byte(newText))
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to write temporary file (data-object) %q", tempPath)
+	}
+
+	// check if the file has been changed while editing
+	if !args.IfMatch.IsEmpty() {
+		err = irods_common.CheckWritePrecondition(fs, entry.Path, args.IfMatch)
+		if err != nil {
+			fs.RemoveFile(tempPath, true) //nolint
+			return nil, err
+		}
+	}
+
+	err = irods_common.ReplaceDataObject(fs, tempPath, entry.Path)
+	if err != nil {
+		fs.RemoveFile(tempPath, true) //nolint
+		return nil, errors.Wrapf(err, "failed to replace file (data-object) %q", entry.Path)
+	}
+
+	writeOutput, err := makeWriteFileOutput(fs, entry.Path, irods_common.WriteModeOverwrite, 0, len(newText))
+	if err != nil {
+		return nil, err
+	}
+
+	editFileOutput.Size = writeOutput.Size
+	editFileOutput.Checksum = writeOutput.Checksum
+	editFileOutput.ModifyTime = writeOutput.ModifyTime
+
+	return editFileOutput, nil
+}
+
+func (t *EditFile) applyReplacements(text string, edits []EditFileReplacement) (string, error) {
+	for idx, edit := range edits {
+		if len(edit.OldText) == 0 {
+			return "", errors.Newf("edit #%d has empty old_text", idx+1)
+		}
+
+		count := strings.Count(text, edit.OldText)
+		if count == 0 {
+			return "", errors.Newf("edit #%d failed, old_text is not found", idx+1)
+		}
+
+		if count > 1 && !edit.ReplaceAll {
+			return "", errors.Newf("edit #%d failed, old_text is found %d times, add more context to make it unique or set replace_all", idx+1, count)
+		}
+
+		text = strings.ReplaceAll(text, edit.OldText, edit.NewText)
+	}
+
+	return text, nil
+}
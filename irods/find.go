@@ -0,0 +1,725 @@
+package irods
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"path"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/cockroachdb/errors"
+	irodsclient_fs "github.com/cyverse/go-irodsclient/fs"
+	irodsclient_common "github.com/cyverse/go-irodsclient/irods/common"
+	irodsclient_types "github.com/cyverse/go-irodsclient/irods/types"
+	"github.com/cyverse/irods-mcp-server/common"
+	irods_common "github.com/cyverse/irods-mcp-server/irods/common"
+	"github.com/cyverse/irods-mcp-server/irods/model"
+	"github.com/google/jsonschema-go/jsonschema"
+	"github.com/modelcontextprotocol/go-sdk/mcp"
+)
+
+const (
+	FindName = irods_common.IRODSAPIPrefix + "find"
+
+	findLimitDefault int = 100
+	findMaxLimit     int = 500
+	findMaxScanRows  int = 50000 // rows to scan when filters cannot be run by the catalog
+
+	findTypeAll       = "all"
+	findTypeFile      = "file"
+	findTypeDirectory = "directory"
+
+	findOrderAsc  = "asc"
+	findOrderDesc = "desc"
+)
+
+type FindInputArgs struct {
+	Path           string `json:"path"`
+	Name           string `json:"name,omitempty"`
+	Regex          string `json:"regex,omitempty"`
+	Type           string `json:"type,omitempty"`
+	MinSize        string `json:"min_size,omitempty"`
+	MaxSize        string `json:"max_size,omitempty"`
+	ModifiedAfter  string `json:"modified_after,omitempty"`
+	ModifiedBefore string `json:"modified_before,omitempty"`
+	CreatedAfter   string `json:"created_after,omitempty"`
+	CreatedBefore  string `json:"created_before,omitempty"`
+	Owner          string `json:"owner,omitempty"`
+	Resource       string `json:"resource,omitempty"`
+	ReplicaStatus  string `json:"replica_status,omitempty"`
+	HasChecksum    *bool  `json:"has_checksum,omitempty"`
+	MinDepth       *int   `json:"min_depth,omitempty"`
+	MaxDepth       *int   `json:"max_depth,omitempty"`
+	Sort           string `json:"sort,omitempty"`
+	Order          string `json:"order,omitempty"`
+	Offset         int    `json:"offset,omitempty"`
+	Limit          int    `json:"limit,omitempty"`
+	Cursor         string `json:"cursor,omitempty"`
+}
+
+// findFilter is the parsed filters of a find request
+type findFilter struct {
+	Root          string
+	Types         []string
+	NameLike      string
+	NameGlob      string
+	NameExact     bool
+	MinSize       *int64
+	MaxSize       *int64
+	ModifiedAfter *time.Time
+	ModifiedUntil *time.Time
+	CreatedAfter  *time.Time
+	CreatedUntil  *time.Time
+	Owner         string
+	Resource      string
+	ReplicaStatus string
+	HasChecksum   *bool
+	MinDepth      int
+	MaxDepth      int // -1 for no limit
+	Sort          string
+	Descending    bool
+
+	nameRegex *regexp.Regexp
+}
+
+// hasDataObjectFilter checks if a filter that only applies to files (data-objects) is given
+func (f *findFilter) hasDataObjectFilter() bool {
+	return f.MinSize != nil || f.MaxSize != nil || len(f.Resource) > 0 || len(f.ReplicaStatus) > 0 || f.HasChecksum != nil
+}
+
+// needsScan checks if some filters must be applied after the catalog query
+func (f *findFilter) needsScan() bool {
+	return f.nameRegex != nil || (len(f.NameGlob) > 0 && !f.NameExact) || (f.HasChecksum != nil && !*f.HasChecksum)
+}
+
+type Find struct {
+	mcpServer *IRODSMCPServer
+	config    *common.Config
+}
+
+func NewFind(svr *IRODSMCPServer) ToolAPI {
+	return &Find{
+		mcpServer: svr,
+		config:    svr.GetConfig(),
+	}
+}
+
+func (t *Find) GetName() string {
+	return FindName
+}
+
+func (t *Find) GetDescription() string {
+	return `Find files (data-objects) and directories (collections) under a directory, like unix 'find', using catalog queries instead of walking the tree.
+	Filter by name (unix wildcards or regular expression), size range, modify/create time range, owner, storage resource, replica status, checksum presence and depth under the path.
+	Size, resource, replica status and checksum filters only match files. Times are dates, date-times, or durations before now such as '30d' or '12h'.
+	Results are sorted by path, name, size, mtime or ctime and paginated, directories come before files. Pass next_cursor to get the next page.
+	The matching entries are returned in JSON format with the total number of matches.`
+}
+
+func (t *Find) GetTool() *mcp.Tool {
+	return &mcp.Tool{
+		Name:        t.GetName(),
+		Description: t.GetDescription(),
+		InputSchema: &jsonschema.Schema{
+			Type: "object",
+			Properties: map[string]*jsonschema.Schema{
+				"path": {
+					Type:        "string",
+					Description: "The directory (collection) path to search under.",
+				},
+				"name": {
+					Type:        "string",
+					Description: "Unix wildcard pattern, such as '*.csv', matched against the name of entries.",
+				},
+				"regex": {
+					Type:        "string",
+					Description: "Regular expression matched against the name of entries. Slower than name, because it is applied after the catalog query.",
+				},
+				"type": {
+					Type:        "string",
+					Description: "The type of entries to find.",
+					Enum:        []interface{}{findTypeAll, findTypeFile, findTypeDirectory},
+					Default:     json.RawMessage(`"all"`),
+				},
+				"min_size": {
+					Type:        "string",
+					Description: "Minimum file size in bytes, or with a unit such as '10M' or '2G'.",
+				},
+				"max_size": {
+					Type:        "string",
+					Description: "Maximum file size in bytes, or with a unit such as '10M' or '2G'.",
+				},
+				"modified_after": {
+					Type:        "string",
+					Description: "Only entries modified at or after this time, e.g. '2024-01-31', '2024-01-31T12:00:00Z' or '30d' (30 days ago).",
+				},
+				"modified_before": {
+					Type:        "string",
+					Description: "Only entries modified at or before this time.",
+				},
+				"created_after": {
+					Type:        "string",
+					Description: "Only entries created at or after this time.",
+				},
+				"created_before": {
+					Type:        "string",
+					Description: "Only entries created at or before this time.",
+				},
+				"owner": {
+					Type:        "string",
+					Description: "The owner user name.",
+				},
+				"resource": {
+					Type:        "string",
+					Description: "The storage resource name where a replica of the file is stored.",
+				},
+				"replica_status": {
+					Type:        "string",
+					Description: "The status of a replica of the file.",
+					Enum:        []interface{}{"good", "stale"},
+				},
+				"has_checksum": {
+					Type:        "boolean",
+					Description: "Find only files with (true) or without (false) a checksum in the catalog.",
+				},
+				"min_depth": {
+					Type:        "number",
+					Description: "Minimum depth under the path, the path itself is 0 and its direct children are 1.",
+				},
+				"max_depth": {
+					Type:        "number",
+					Description: "Maximum depth under the path.",
+				},
+				"sort": {
+					Type:        "string",
+					Description: "The field to sort by.",
+					Enum:        []interface{}{catalogSortPath, catalogSortName, catalogSortSize, catalogSortMtime, catalogSortCtime},
+					Default:     json.RawMessage(`"path"`),
+				},
+				"order": {
+					Type:        "string",
+					Description: "The sort order.",
+					Enum:        []interface{}{findOrderAsc, findOrderDesc},
+					Default:     json.RawMessage(`"asc"`),
+				},
+				"offset": {
+					Type:        "number",
+					Description: "Number of matching entries to skip (for pagination). Default: 0.",
+					Default:     json.RawMessage("0"),
+				},
+				"limit": {
+					Type:        "number",
+					Description: fmt.Sprintf("Maximum number of entries to return (for pagination). Default: %d, max: %d.", findLimitDefault, findMaxLimit),
+					Default:     json.RawMessage(fmt.Sprintf("%d", findLimitDefault)),
+				},
+				"cursor": {
+					Type:        "string",
+					Description: "The next_cursor returned by a previous call with the same filters, to get the next page. Overrides offset.",
+				},
+			},
+			Required: []string{"path"},
+		},
+	}
+}
+
+func (t *Find) GetHandler() mcp.ToolHandler {
+	return t.Handler
+}
+
+func (t *Find) GetAccessiblePaths(authValue *common.AuthValue) []string {
+	account, err := t.mcpServer.GetIRODSAccountFromAuthValue(authValue)
+	if err != nil {
+		return []string{}
+	}
+
+	homePath := irods_common.GetHomePath(t.config, account)
+	sharedPath := irods_common.GetSharedPath(t.config, account)
+
+	paths := []string{
+		sharedPath,
+		sharedPath + "/*",
+	}
+
+	if !account.IsAnonymousUser() {
+		paths = append(paths, homePath)
+		paths = append(paths, homePath+"/*")
+	}
+
+	return paths
+}
+
+func (t *Find) Handler(ctx context.Context, request *mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	// arguments
+	args := FindInputArgs{}
+	err := irods_common.MarshalInputArguments(t.GetTool(), request, &args)
+	if err != nil {
+		outputErr := errors.Wrapf(err, "failed to marshal input arguments")
+		return irods_common.ToolErrorResult(outputErr), nil
+	}
+
+	// auth
+	authValue, err := common.GetAuthValue(ctx)
+	if err != nil {
+		outputErr := errors.Wrapf(err, "failed to get auth value")
+		return irods_common.ToolErrorResult(outputErr), nil
+	}
+
+	// make a irods filesystem client
+	fs, err := t.mcpServer.GetIRODSFSClientFromAuthValue(&authValue)
+	if err != nil {
+		outputErr := errors.Wrapf(err, "failed to create a irods fs client")
+		return irods_common.ToolErrorResult(outputErr), nil
+	}
+
+	irodsPath := irods_common.MakeIRODSPath(t.config, fs.GetAccount(), args.Path)
+
+	// check permission
+	accessiblePaths := t.GetAccessiblePaths(&authValue)
+	if !irods_common.IsAccessAllowed(irodsPath, accessiblePaths) {
+		outputErr := errors.Newf("%q request is not permitted for path %q", t.GetName(), irodsPath)
+		return irods_common.ToolErrorResult(outputErr), nil
+	}
+
+	rootEntry, err := fs.Stat(irodsPath)
+	if err != nil {
+		outputErr := errors.Wrapf(err, "failed to stat %q", irodsPath)
+		return irods_common.ToolErrorResult(outputErr), nil
+	}
+
+	if !rootEntry.IsDir() {
+		outputErr := errors.Newf("path %q is not a directory (collection)", irodsPath)
+		return irods_common.ToolErrorResult(outputErr), nil
+	}
+
+	filter, err := t.makeFilter(irodsPath, &args)
+	if err != nil {
+		outputErr := errors.Wrapf(err, "invalid filter")
+		return irods_common.ToolErrorResult(outputErr), nil
+	}
+
+	// apply pagination defaults/bounds
+	offset := args.Offset
+	if offset < 0 {
+		offset = 0
+	}
+	limit := args.Limit
+	if limit <= 0 {
+		limit = findLimitDefault
+	} else if limit > findMaxLimit {
+		limit = findMaxLimit
+	}
+
+	// relative times move with each call, so the fingerprint is made from arguments
+	fingerprintArgs := args
+	fingerprintArgs.Offset = 0
+	fingerprintArgs.Limit = 0
+	fingerprintArgs.Cursor = ""
+	fingerprint := irods_common.MakePageFingerprint(irodsPath, fingerprintArgs)
+	if len(args.Cursor) > 0 {
+		offset, err = irods_common.DecodePageCursor(args.Cursor, fingerprint)
+		if err != nil {
+			outputErr := errors.Wrapf(err, "failed to decode cursor")
+			return irods_common.ToolErrorResult(outputErr), nil
+		}
+	}
+
+	content, err := t.find(fs, accessiblePaths, filter, offset, limit)
+	if err != nil {
+		outputErr := errors.Wrapf(err, "failed to find files (data-objects) or directories (collections) under %q", irodsPath)
+		return irods_common.ToolErrorResult(outputErr), nil
+	}
+
+	if offset+limit < content.Total {
+		content.NextCursor = irods_common.EncodePageCursor(offset+limit, fingerprint)
+	}
+
+	return irods_common.ToolJSONResult(*content)
+}
+
+func (t *Find) makeFilter(rootPath string, args *FindInputArgs) (*findFilter, error) {
+	now := time.Now()
+
+	filter := &findFilter{
+		Root:          rootPath,
+		Owner:         args.Owner,
+		Resource:      args.Resource,
+		HasChecksum:   args.HasChecksum,
+		MaxDepth:      -1,
+		Sort:          strings.ToLower(args.Sort),
+		Descending:    strings.ToLower(args.Order) == findOrderDesc,
+		ReplicaStatus: strings.ToLower(args.ReplicaStatus),
+	}
+
+	if len(args.Name) > 0 {
+		filter.NameGlob = args.Name
+		filter.NameLike, filter.NameExact = irods_common.GlobToGenQueryLike(args.Name)
+		if _, err := path.Match(args.Name, ""); err != nil {
+			return nil, errors.Wrapf(err, "invalid name pattern %q", args.Name)
+		}
+	}
+
+	if len(args.Regex) > 0 {
+		nameRegex, err := regexp.Compile(args.Regex)
+		if err != nil {
+			return nil, errors.Wrapf(err, "invalid regular expression %q", args.Regex)
+		}
+		filter.nameRegex = nameRegex
+	}
+
+	parseSize := func(size string) (*int64, error) {
+		if len(size) == 0 {
+			return nil, nil
+		}
+		parsed, err := irods_common.ParseSize(size)
+		if err != nil {
+			return nil, errors.Wrapf(err, "invalid size %q", size)
+		}
+		return &parsed, nil
+	}
+
+	parseTime := func(t string) (*time.Time, error) {
+		if len(t) == 0 {
+			return nil, nil
+		}
+		parsed, err := irods_common.ParseTimeBound(t, now)
+		if err != nil {
+			return nil, err
+		}
+		return &parsed, nil
+	}
+
+	var err error
+	if filter.MinSize, err = parseSize(args.MinSize); err != nil {
+		return nil, err
+	}
+	if filter.MaxSize, err = parseSize(args.MaxSize); err != nil {
+		return nil, err
+	}
+	if filter.ModifiedAfter, err = parseTime(args.ModifiedAfter); err != nil {
+		return nil, err
+	}
+	if filter.ModifiedUntil, err = parseTime(args.ModifiedBefore); err != nil {
+		return nil, err
+	}
+	if filter.CreatedAfter, err = parseTime(args.CreatedAfter); err != nil {
+		return nil, err
+	}
+	if filter.CreatedUntil, err = parseTime(args.CreatedBefore); err != nil {
+		return nil, err
+	}
+
+	switch filter.ReplicaStatus {
+	case "", "good", "stale":
+	default:
+		return nil, errors.Newf("unknown replica status %q", args.ReplicaStatus)
+	}
+
+	if args.MinDepth != nil {
+		if *args.MinDepth < 0 {
+			return nil, errors.Newf("invalid min_depth %d", *args.MinDepth)
+		}
+		filter.MinDepth = *args.MinDepth
+	}
+
+	if args.MaxDepth != nil {
+		if *args.MaxDepth < filter.MinDepth {
+			return nil, errors.Newf("max_depth %d is less than min_depth %d", *args.MaxDepth, filter.MinDepth)
+		}
+		filter.MaxDepth = *args.MaxDepth
+	}
+
+	switch filter.Sort {
+	case "":
+		filter.Sort = catalogSortPath
+	case catalogSortPath, catalogSortName, catalogSortSize, catalogSortMtime, catalogSortCtime:
+	default:
+		return nil, errors.Newf("unknown sort field %q", args.Sort)
+	}
+
+	switch strings.ToLower(args.Type) {
+	case "", findTypeAll:
+		filter.Types = []string{findTypeDirectory, findTypeFile}
+		if filter.hasDataObjectFilter() {
+			filter.Types = []string{findTypeFile}
+		}
+	case findTypeFile:
+		filter.Types = []string{findTypeFile}
+	case findTypeDirectory:
+		if filter.hasDataObjectFilter() {
+			return nil, errors.Newf("size, resource, replica status and checksum filters cannot be used for directories")
+		}
+		filter.Types = []string{findTypeDirectory}
+	default:
+		return nil, errors.Newf("unknown type %q", args.Type)
+	}
+
+	return filter, nil
+}
+
+func (t *Find) find(fs *irodsclient_fs.FileSystem, accessiblePaths []string, filter *findFilter, offset int, limit int) (*model.FindOutput, error) {
+	outputEntries := []model.EntryWithAccess{}
+
+	queries, err := t.makeQueries(filter)
+	if err != nil {
+		return nil, err
+	}
+
+	var matches []string
+	var total int
+	if filter.needsScan() {
+		matches, total, err = t.scan(fs, filter, queries, offset, limit)
+	} else {
+		matches, total, err = queryCatalogPage(fs, queries, findMaxScanRows, offset, limit)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	for _, match := range matches {
+		// check permission
+		// filter out entries not in accessible paths
+		if !irods_common.IsAccessAllowed(match, accessiblePaths) {
+			continue
+		}
+
+		entry, err := fs.Stat(match)
+		if err != nil {
+			if irodsclient_types.IsFileNotFoundError(err) {
+				// removed after the query
+				continue
+			}
+			return nil, errors.Wrapf(err, "failed to stat %q", match)
+		}
+
+		entryStruct := model.EntryWithAccess{
+			Entry:       entry,
+			ResourceURI: irods_common.MakeResourceURI(entry.Path),
+			WebDAVURI:   irods_common.MakeWebdavURL(t.config, entry.Path, fs.GetAccount()),
+		}
+
+		outputEntries = append(outputEntries, entryStruct)
+	}
+
+	findOutput := &model.FindOutput{
+		Path:            filter.Root,
+		MatchingEntries: outputEntries,
+		Total:           total,
+		Offset:          offset,
+		Limit:           limit,
+	}
+
+	return findOutput, nil
+}
+
+// makeQueries makes catalog queries for entry types in the order of results
+func (t *Find) makeQueries(filter *findFilter) ([]catalogPageQuery, error) {
+	queries := []catalogPageQuery{}
+	for _, entryType := range filter.Types {
+		query, err := t.makeQuery(filter, entryType)
+		if err != nil {
+			return nil, err
+		}
+
+		if query != nil {
+			queries = append(queries, *query)
+		}
+	}
+
+	return queries, nil
+}
+
+// scan reads matching rows from the catalog and applies filters the catalog cannot run
+func (t *Find) scan(fs *irodsclient_fs.FileSystem, filter *findFilter, queries []catalogPageQuery, offset int, limit int) ([]string, int, error) {
+	// a data object without checksum has no replica with checksum
+	withChecksum := map[string]bool{}
+	if filter.HasChecksum != nil && !*filter.HasChecksum {
+		checksumFilter := *filter
+		hasChecksum := true
+		checksumFilter.HasChecksum = &hasChecksum
+
+		checksumQuery, err := t.makeQuery(&checksumFilter, findTypeFile)
+		if err != nil {
+			return nil, 0, err
+		}
+
+		if checksumQuery != nil {
+			paths, err := scanCatalogPaths(fs, *checksumQuery, findMaxScanRows)
+			if err != nil {
+				return nil, 0, err
+			}
+
+			for _, entryPath := range paths {
+				withChecksum[entryPath] = true
+			}
+		}
+	}
+
+	match := func(entryPath string) bool {
+		if withChecksum[entryPath] {
+			return false
+		}
+
+		name := path.Base(entryPath)
+		if len(filter.NameGlob) > 0 && !filter.NameExact {
+			if matched, _ := path.Match(filter.NameGlob, name); !matched {
+				return false
+			}
+		}
+
+		if filter.nameRegex != nil && !filter.nameRegex.MatchString(name) {
+			return false
+		}
+
+		return true
+	}
+
+	return scanCatalogPage(fs, queries, match, findMaxScanRows, offset, limit)
+}
+
+// makeQuery makes a catalog query for an entry type, returns nil if the depth range excludes the type
+func (t *Find) makeQuery(filter *findFilter, entryType string) (*catalogPageQuery, error) {
+	// depth of the collection name column
+	minDepth := filter.MinDepth
+	maxDepth := filter.MaxDepth
+	if entryType == findTypeFile {
+		minDepth--
+		if maxDepth >= 0 {
+			maxDepth--
+			if maxDepth < 0 {
+				return nil, nil
+			}
+		}
+	}
+
+	var ownerColumn, modifyTimeColumn, createTimeColumn irodsclient_common.ICATColumnNumber
+	if entryType == findTypeFile {
+		ownerColumn = irodsclient_common.ICAT_COLUMN_D_OWNER_NAME
+		modifyTimeColumn = irodsclient_common.ICAT_COLUMN_D_MODIFY_TIME
+		createTimeColumn = irodsclient_common.ICAT_COLUMN_D_CREATE_TIME
+	} else {
+		ownerColumn = irodsclient_common.ICAT_COLUMN_COLL_OWNER_NAME
+		modifyTimeColumn = irodsclient_common.ICAT_COLUMN_COLL_MODIFY_TIME
+		createTimeColumn = irodsclient_common.ICAT_COLUMN_COLL_CREATE_TIME
+	}
+
+	selects, pathColumns := makeCatalogSelects(entryType == findTypeFile, filter.Sort, filter.Descending)
+
+	conditions := []irods_common.GenQueryCondition{}
+	addCondition := func(column irodsclient_common.ICATColumnNumber, operator string, value string) error {
+		quotedValue, err := irods_common.QuoteGenQueryValue(value)
+		if err != nil {
+			return err
+		}
+		conditions = append(conditions, irods_common.GenQueryCondition{Column: column, Condition: operator + " " + quotedValue})
+		return nil
+	}
+
+	// depth, wildcards in the root path must match only themselves
+	rootPrefix := irods_common.EscapeGenQueryLike(strings.TrimSuffix(filter.Root, "/"))
+	if minDepth <= 0 {
+		rootCondition, err := irods_common.MakeGenQueryPathCondition(filter.Root)
+		if err != nil {
+			return nil, err
+		}
+		conditions = append(conditions, irods_common.GenQueryCondition{Column: irodsclient_common.ICAT_COLUMN_COLL_NAME, Condition: rootCondition})
+	} else {
+		err := addCondition(irodsclient_common.ICAT_COLUMN_COLL_NAME, "like", rootPrefix+strings.Repeat("/%", minDepth))
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	if maxDepth >= 0 {
+		err := addCondition(irodsclient_common.ICAT_COLUMN_COLL_NAME, "not like", rootPrefix+strings.Repeat("/%", maxDepth+1))
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	// name
+	if len(filter.NameLike) > 0 {
+		var err error
+		if entryType == findTypeFile {
+			err = addCondition(irodsclient_common.ICAT_COLUMN_DATA_NAME, "like", filter.NameLike)
+		} else {
+			err = addCondition(irodsclient_common.ICAT_COLUMN_COLL_NAME, "like", "%/"+filter.NameLike)
+		}
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	// size
+	if filter.MinSize != nil {
+		err := addCondition(irodsclient_common.ICAT_COLUMN_DATA_SIZE, ">=", fmt.Sprintf("%d", *filter.MinSize))
+		if err != nil {
+			return nil, err
+		}
+	}
+	if filter.MaxSize != nil {
+		err := addCondition(irodsclient_common.ICAT_COLUMN_DATA_SIZE, "<=", fmt.Sprintf("%d", *filter.MaxSize))
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	// time
+	timeBounds := []struct {
+		column   irodsclient_common.ICATColumnNumber
+		operator string
+		bound    *time.Time
+	}{
+		{modifyTimeColumn, ">=", filter.ModifiedAfter},
+		{modifyTimeColumn, "<=", filter.ModifiedUntil},
+		{createTimeColumn, ">=", filter.CreatedAfter},
+		{createTimeColumn, "<=", filter.CreatedUntil},
+	}
+	for _, timeBound := range timeBounds {
+		if timeBound.bound == nil {
+			continue
+		}
+
+		err := addCondition(timeBound.column, timeBound.operator, irods_common.MakeGenQueryTime(*timeBound.bound))
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	// owner
+	if len(filter.Owner) > 0 {
+		err := addCondition(ownerColumn, "=", filter.Owner)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	// replica
+	if len(filter.Resource) > 0 {
+		err := addCondition(irodsclient_common.ICAT_COLUMN_D_RESC_NAME, "=", filter.Resource)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	switch filter.ReplicaStatus {
+	case "good":
+		conditions = append(conditions, irods_common.GenQueryCondition{Column: irodsclient_common.ICAT_COLUMN_D_REPL_STATUS, Condition: "= '1'"})
+	case "stale":
+		conditions = append(conditions, irods_common.GenQueryCondition{Column: irodsclient_common.ICAT_COLUMN_D_REPL_STATUS, Condition: "= '0'"})
+	}
+
+	if filter.HasChecksum != nil && *filter.HasChecksum {
+		conditions = append(conditions, irods_common.GenQueryCondition{Column: irodsclient_common.ICAT_COLUMN_D_DATA_CHECKSUM, Condition: "<> ''"})
+	}
+
+	return &catalogPageQuery{
+		entryType: entryType,
+		query: &irods_common.GenQuery{
+			Selects:    selects,
+			Conditions: conditions,
+		},
+		pathColumns: pathColumns,
+	}, nil
+}
@@ -0,0 +1,510 @@
+package irods
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/cockroachdb/errors"
+	irodsclient_fs "github.com/cyverse/go-irodsclient/fs"
+	irodsclient_common "github.com/cyverse/go-irodsclient/irods/common"
+	"github.com/cyverse/irods-mcp-server/common"
+	irods_common "github.com/cyverse/irods-mcp-server/irods/common"
+	"github.com/cyverse/irods-mcp-server/irods/model"
+	"github.com/google/jsonschema-go/jsonschema"
+	"github.com/modelcontextprotocol/go-sdk/mcp"
+)
+
+const (
+	GenQueryName = irods_common.IRODSAPIPrefix + "genquery"
+
+	genQueryLimitDefault int = 100
+	genQueryMaxLimit     int = 500
+	genQueryMaxSelects   int = 16
+	genQueryMaxFilters   int = 32
+
+	genQueryAggregateNone  = "none"
+	genQueryAggregateCount = "count"
+	genQueryAggregateSum   = "sum"
+	genQueryAggregateMin   = "min"
+	genQueryAggregateMax   = "max"
+	genQueryAggregateAvg   = "avg"
+)
+
+var genQueryAggregateOptions = map[string]int{
+	genQueryAggregateNone:  irods_common.GenQuerySelectNormal,
+	genQueryAggregateCount: irods_common.GenQuerySelectCount,
+	genQueryAggregateSum:   irods_common.GenQuerySelectSum,
+	genQueryAggregateMin:   irods_common.GenQuerySelectMin,
+	genQueryAggregateMax:   irods_common.GenQuerySelectMax,
+	genQueryAggregateAvg:   irods_common.GenQuerySelectAvg,
+}
+
+type GenQuerySelectArg struct {
+	Column    string `json:"column"`
+	Aggregate string `json:"aggregate,omitempty"`
+}
+
+type GenQueryConditionArg struct {
+	Column   string   `json:"column"`
+	Operator string   `json:"operator,omitempty"`
+	Value    string   `json:"value,omitempty"`
+	Values   []string `json:"values,omitempty"`
+}
+
+type GenQueryInputArgs struct {
+	Select     []GenQuerySelectArg    `json:"select"`
+	Conditions []GenQueryConditionArg `json:"conditions,omitempty"`
+	Path       string                 `json:"path,omitempty"`
+	OrderBy    string                 `json:"order_by,omitempty"`
+	Order      string                 `json:"order,omitempty"`
+	Offset     int                    `json:"offset,omitempty"`
+	Limit      int                    `json:"limit,omitempty"`
+	Cursor     string                 `json:"cursor,omitempty"`
+}
+
+type GenQuery struct {
+	mcpServer *IRODSMCPServer
+	config    *common.Config
+}
+
+func NewGenQuery(svr *IRODSMCPServer) ToolAPI {
+	return &GenQuery{
+		mcpServer: svr,
+		config:    svr.GetConfig(),
+	}
+}
+
+func (t *GenQuery) GetName() string {
+	return GenQueryName
+}
+
+func (t *GenQuery) GetDescription() string {
+	return fmt.Sprintf(`Run a catalog query (GenQuery) selecting columns, optionally aggregated (count, sum, min, max, avg), with conditions.
+	Rows are grouped by the columns that are not aggregated, e.g. select DATA_RESC_NAME and sum of DATA_SIZE for total bytes per resource.
+	Queries are always limited to files (data-objects) and directories (collections) under the accessible paths, or under path if given.
+	Allowed columns: %s.
+	Times are seconds since epoch, zero-padded to 11 digits. Results are paginated, pass next_cursor to get the next page.`, strings.Join(t.getAllowedColumns(), ", "))
+}
+
+func (t *GenQuery) GetTool() *mcp.Tool {
+	return &mcp.Tool{
+		Name:        t.GetName(),
+		Description: t.GetDescription(),
+		InputSchema: &jsonschema.Schema{
+			Type: "object",
+			Properties: map[string]*jsonschema.Schema{
+				"select": {
+					Type:        "array",
+					Description: "Columns to select, in the order of values in result rows.",
+					Items: &jsonschema.Schema{
+						Type: "object",
+						Properties: map[string]*jsonschema.Schema{
+							"column": {
+								Type:        "string",
+								Description: "The column name, e.g. DATA_SIZE.",
+							},
+							"aggregate": {
+								Type:        "string",
+								Description: "The aggregate function to apply to the column.",
+								Enum:        []interface{}{genQueryAggregateNone, genQueryAggregateCount, genQueryAggregateSum, genQueryAggregateMin, genQueryAggregateMax, genQueryAggregateAvg},
+								Default:     json.RawMessage(`"none"`),
+							},
+						},
+						Required: []string{"column"},
+					},
+				},
+				"conditions": {
+					Type:        "array",
+					Description: "Conditions on columns, all of them must match.",
+					Items: &jsonschema.Schema{
+						Type: "object",
+						Properties: map[string]*jsonschema.Schema{
+							"column": {
+								Type:        "string",
+								Description: "The column name, e.g. META_DATA_ATTR_NAME.",
+							},
+							"operator": {
+								Type:        "string",
+								Description: "The operator to compare the column with the value.",
+								Enum:        avuOperators,
+								Default:     json.RawMessage(`"="`),
+							},
+							"value": {
+								Type:        "string",
+								Description: "The value to compare. Use % and _ as wildcards for 'like'.",
+							},
+							"values": {
+								Type:        "array",
+								Description: "Lower and upper bounds for 'between', or candidates for 'in'.",
+								Items: &jsonschema.Schema{
+									Type: "string",
+								},
+							},
+						},
+						Required: []string{"column"},
+					},
+				},
+				"path": {
+					Type:        "string",
+					Description: "The collection path to query under. Omit to query everywhere accessible.",
+				},
+				"order_by": {
+					Type:        "string",
+					Description: "A selected column without aggregate to sort rows by first.",
+				},
+				"order": {
+					Type:        "string",
+					Description: "The sort order of order_by.",
+					Enum:        []interface{}{"asc", "desc"},
+					Default:     json.RawMessage(`"asc"`),
+				},
+				"offset": {
+					Type:        "number",
+					Description: "Number of rows to skip (for pagination). Default: 0.",
+					Default:     json.RawMessage("0"),
+				},
+				"limit": {
+					Type:        "number",
+					Description: fmt.Sprintf("Maximum number of rows to return (for pagination). Default: %d, max: %d.", genQueryLimitDefault, genQueryMaxLimit),
+					Default:     json.RawMessage(fmt.Sprintf("%d", genQueryLimitDefault)),
+				},
+				"cursor": {
+					Type:        "string",
+					Description: "The next_cursor returned by a previous call with the same query, to get the next page. Overrides offset.",
+				},
+			},
+			Required: []string{"select"},
+		},
+	}
+}
+
+func (t *GenQuery) GetHandler() mcp.ToolHandler {
+	return t.Handler
+}
+
+func (t *GenQuery) GetAccessiblePaths(authValue *common.AuthValue) []string {
+	account, err := t.mcpServer.GetIRODSAccountFromAuthValue(authValue)
+	if err != nil {
+		return []string{}
+	}
+
+	homePath := irods_common.GetHomePath(t.config, account)
+	sharedPath := irods_common.GetSharedPath(t.config, account)
+
+	paths := []string{
+		sharedPath,
+		sharedPath + "/*",
+	}
+
+	if !account.IsAnonymousUser() {
+		paths = append(paths, homePath)
+		paths = append(paths, homePath+"/*")
+	}
+
+	return paths
+}
+
+// getAllowedColumns returns columns the tool may select or filter on
+func (t *GenQuery) getAllowedColumns() []string {
+	columns := []string{}
+	for _, column := range t.config.GenQueryColumns {
+		column = strings.ToUpper(strings.TrimSpace(column))
+		if len(column) > 0 {
+			columns = append(columns, column)
+		}
+	}
+
+	if len(columns) == 0 {
+		return irods_common.DefaultGenQueryColumns
+	}
+	return columns
+}
+
+// getColumn returns the catalog column of an allowed column name
+func (t *GenQuery) getColumn(name string) (irodsclient_common.ICATColumnNumber, error) {
+	name = strings.ToUpper(strings.TrimSpace(name))
+
+	allowed := false
+	for _, allowedColumn := range t.getAllowedColumns() {
+		if allowedColumn == name {
+			allowed = true
+			break
+		}
+	}
+
+	if !allowed {
+		return 0, errors.Newf("column %q is not allowed", name)
+	}
+
+	column, ok := irods_common.GetGenQueryColumn(name)
+	if !ok {
+		return 0, errors.Newf("unknown column %q", name)
+	}
+	return column, nil
+}
+
+func (t *GenQuery) Handler(ctx context.Context, request *mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	// arguments
+	args := GenQueryInputArgs{}
+	err := irods_common.MarshalInputArguments(t.GetTool(), request, &args)
+	if err != nil {
+		outputErr := errors.Wrapf(err, "failed to marshal input arguments")
+		return irods_common.ToolErrorResult(outputErr), nil
+	}
+
+	// auth
+	authValue, err := common.GetAuthValue(ctx)
+	if err != nil {
+		outputErr := errors.Wrapf(err, "failed to get auth value")
+		return irods_common.ToolErrorResult(outputErr), nil
+	}
+
+	// make a irods filesystem client
+	fs, err := t.mcpServer.GetIRODSFSClientFromAuthValue(&authValue)
+	if err != nil {
+		outputErr := errors.Wrapf(err, "failed to create a irods fs client")
+		return irods_common.ToolErrorResult(outputErr), nil
+	}
+
+	accessiblePaths := t.GetAccessiblePaths(&authValue)
+
+	// query only under accessible paths
+	queryPath := ""
+	queryRoots := irods_common.GetAccessibleRoots(accessiblePaths)
+	if len(args.Path) > 0 {
+		queryPath = irods_common.MakeIRODSPath(t.config, fs.GetAccount(), args.Path)
+
+		// check permission
+		if !irods_common.IsAccessAllowed(queryPath, accessiblePaths) {
+			outputErr := errors.Newf("%q request is not permitted for path %q", t.GetName(), queryPath)
+			return irods_common.ToolErrorResult(outputErr), nil
+		}
+
+		queryRoots = []string{queryPath}
+	}
+
+	if len(queryRoots) == 0 {
+		outputErr := errors.Newf("no path is accessible for %q request", t.GetName())
+		return irods_common.ToolErrorResult(outputErr), nil
+	}
+
+	query, columnNames, order, err := t.makeQuery(&args, queryRoots)
+	if err != nil {
+		outputErr := errors.Wrapf(err, "invalid query")
+		return irods_common.ToolErrorResult(outputErr), nil
+	}
+
+	// apply pagination defaults/bounds
+	offset := args.Offset
+	if offset < 0 {
+		offset = 0
+	}
+	limit := args.Limit
+	if limit <= 0 {
+		limit = genQueryLimitDefault
+	} else if limit > genQueryMaxLimit {
+		limit = genQueryMaxLimit
+	}
+
+	fingerprint := irods_common.MakePageFingerprint(query.Selects, query.Conditions)
+	if len(args.Cursor) > 0 {
+		offset, err = irods_common.DecodePageCursor(args.Cursor, fingerprint)
+		if err != nil {
+			outputErr := errors.Wrapf(err, "failed to decode cursor")
+			return irods_common.ToolErrorResult(outputErr), nil
+		}
+	}
+
+	content, err := t.runQuery(fs, query, order, offset, limit)
+	if err != nil {
+		outputErr := errors.Wrapf(err, "failed to run catalog query")
+		return irods_common.ToolErrorResult(outputErr), nil
+	}
+
+	content.Columns = columnNames
+	content.Path = queryPath
+	content.Roots = queryRoots
+
+	if offset+limit < content.Total {
+		content.NextCursor = irods_common.EncodePageCursor(offset+limit, fingerprint)
+	}
+
+	return irods_common.ToolJSONResult(*content)
+}
+
+// makeQuery validates arguments and makes a catalog query limited to the roots
+// returns the query, names of result columns, and positions of selects in the query for each result column
+func (t *GenQuery) makeQuery(args *GenQueryInputArgs, roots []string) (*irods_common.GenQuery, []string, []int, error) {
+	if len(args.Select) == 0 {
+		return nil, nil, nil, errors.Newf("no column to select")
+	}
+	if len(args.Select) > genQueryMaxSelects {
+		return nil, nil, nil, errors.Newf("too many columns to select, max %d", genQueryMaxSelects)
+	}
+	if len(args.Conditions) > genQueryMaxFilters {
+		return nil, nil, nil, errors.Newf("too many conditions, max %d", genQueryMaxFilters)
+	}
+
+	orderBy := strings.ToUpper(strings.TrimSpace(args.OrderBy))
+	descending := false
+	switch strings.ToLower(args.Order) {
+	case "", "asc":
+	case "desc":
+		descending = true
+	default:
+		return nil, nil, nil, errors.Newf("unknown order %q", args.Order)
+	}
+
+	selects := []irods_common.GenQueryColumn{}
+	columnNames := []string{}
+	seen := map[irodsclient_common.ICATColumnNumber]bool{}
+	orderByIdx := -1
+	for idx, sel := range args.Select {
+		column, err := t.getColumn(sel.Column)
+		if err != nil {
+			return nil, nil, nil, err
+		}
+
+		// results are matched to selects by column
+		if seen[column] {
+			return nil, nil, nil, errors.Newf("column %q is selected more than once", sel.Column)
+		}
+		seen[column] = true
+
+		aggregate := strings.ToLower(strings.TrimSpace(sel.Aggregate))
+		if len(aggregate) == 0 {
+			aggregate = genQueryAggregateNone
+		}
+
+		option, ok := genQueryAggregateOptions[aggregate]
+		if !ok {
+			return nil, nil, nil, errors.Newf("unknown aggregate %q", sel.Aggregate)
+		}
+
+		name := strings.ToUpper(strings.TrimSpace(sel.Column))
+		if aggregate != genQueryAggregateNone {
+			name = fmt.Sprintf("%s(%s)", strings.ToUpper(aggregate), name)
+		} else {
+			// rows are ordered by grouped columns for stable pagination
+			option |= irods_common.GenQueryOrderBy
+		}
+
+		if len(orderBy) > 0 && orderBy == strings.ToUpper(strings.TrimSpace(sel.Column)) {
+			if aggregate != genQueryAggregateNone {
+				return nil, nil, nil, errors.Newf("cannot order by aggregated column %q", sel.Column)
+			}
+
+			orderByIdx = idx
+			if descending {
+				option = irods_common.GenQuerySelectNormal | irods_common.GenQueryOrderByDesc
+			}
+		}
+
+		selects = append(selects, irods_common.GenQueryColumn{Column: column, Option: option})
+		columnNames = append(columnNames, name)
+	}
+
+	if len(orderBy) > 0 && orderByIdx < 0 {
+		return nil, nil, nil, errors.Newf("order_by column %q is not selected", args.OrderBy)
+	}
+
+	// the catalog orders by selected columns in order, so the order_by column goes first
+	order := make([]int, len(selects))
+	querySelects := make([]irods_common.GenQueryColumn, 0, len(selects))
+	if orderByIdx >= 0 {
+		querySelects = append(querySelects, selects[orderByIdx])
+	}
+	for idx, sel := range selects {
+		if idx != orderByIdx {
+			querySelects = append(querySelects, sel)
+		}
+	}
+	for idx := range selects {
+		switch {
+		case idx == orderByIdx:
+			order[idx] = 0
+		case orderByIdx >= 0 && idx < orderByIdx:
+			order[idx] = idx + 1
+		default:
+			order[idx] = idx
+		}
+	}
+
+	conditions := []irods_common.GenQueryCondition{}
+	for _, cond := range args.Conditions {
+		column, err := t.getColumn(cond.Column)
+		if err != nil {
+			return nil, nil, nil, err
+		}
+
+		operator := strings.ToLower(strings.Join(strings.Fields(cond.Operator), " "))
+		switch operator {
+		case "", "==":
+			operator = avuOperatorEqual
+		case "<>":
+			operator = avuOperatorNotEqual
+		}
+
+		values := cond.Values
+		if operator == avuOperatorIn && len(values) == 0 && len(cond.Value) > 0 {
+			values = []string{cond.Value}
+		}
+
+		condition, err := irods_common.MakeGenQueryValueCondition(operator, cond.Value, values)
+		if err != nil {
+			return nil, nil, nil, errors.Wrapf(err, "invalid condition on column %q", cond.Column)
+		}
+
+		conditions = append(conditions, irods_common.GenQueryCondition{Column: column, Condition: condition})
+	}
+
+	// forced, so rows only come from accessible collections
+	rootCondition, err := irods_common.MakeGenQueryPathCondition(roots...)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+	conditions = append(conditions, irods_common.GenQueryCondition{Column: irodsclient_common.ICAT_COLUMN_COLL_NAME, Condition: rootCondition})
+
+	query := &irods_common.GenQuery{
+		Selects:    querySelects,
+		Conditions: conditions,
+	}
+
+	return query, columnNames, order, nil
+}
+
+func (t *GenQuery) runQuery(fs *irodsclient_fs.FileSystem, query *irods_common.GenQuery, order []int, offset int, limit int) (*model.GenQueryOutput, error) {
+	pageQuery := *query
+	pageQuery.Options |= irods_common.GenQueryOptionReturnTotalRowCount
+	pageQuery.Offset = offset
+	pageQuery.Limit = limit
+
+	result, err := irods_common.RunGenQuery(fs, &pageQuery)
+	if err != nil {
+		return nil, err
+	}
+
+	// back to the order of selects in arguments
+	rows := make([][]string, 0, len(result.Rows))
+	for _, row := range result.Rows {
+		outputRow := make([]string, len(order))
+		for idx, queryIdx := range order {
+			outputRow[idx] = row[queryIdx]
+		}
+		rows = append(rows, outputRow)
+	}
+
+	total := result.TotalRowCount
+	if total < 0 {
+		total = offset + len(rows)
+	}
+
+	return &model.GenQueryOutput{
+		Rows:   rows,
+		Total:  total,
+		Offset: offset,
+		Limit:  limit,
+	}, nil
+}
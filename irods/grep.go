@@ -0,0 +1,531 @@
+package irods
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"regexp"
+	"sort"
+	"strings"
+	"sync/atomic"
+	"unicode/utf8"
+
+	"github.com/cockroachdb/errors"
+	irodsclient_fs "github.com/cyverse/go-irodsclient/fs"
+	"github.com/cyverse/irods-mcp-server/common"
+	irods_common "github.com/cyverse/irods-mcp-server/irods/common"
+	"github.com/cyverse/irods-mcp-server/irods/model"
+	"github.com/google/jsonschema-go/jsonschema"
+	"github.com/modelcontextprotocol/go-sdk/mcp"
+)
+
+const (
+	GrepName = irods_common.IRODSAPIPrefix + "grep"
+
+	grepMaxFileSizeDefault   int64 = 10 * 1024 * 1024  // 10MB
+	grepMaxTotalBytesDefault int64 = 100 * 1024 * 1024 // 100MB
+	grepMaxMatchesDefault    int   = 200
+	grepMaxMatches           int   = 5000
+	grepMaxContextLines      int   = 10
+	grepMaxFiles             int   = 10000
+	grepMaxLineLength        int   = 1000
+)
+
+type GrepInputArgs struct {
+	Path            string   `json:"path"`
+	Pattern         string   `json:"pattern"`
+	IsRegex         bool     `json:"is_regex,omitempty"`
+	CaseInsensitive bool     `json:"case_insensitive,omitempty"`
+	Include         []string `json:"include,omitempty"`
+	Exclude         []string `json:"exclude,omitempty"`
+	MaxFileSize     int64    `json:"max_file_size,omitempty"`
+	MaxTotalBytes   int64    `json:"max_total_bytes,omitempty"`
+	ContextLines    int      `json:"context_lines,omitempty"`
+	MaxMatches      int      `json:"max_matches,omitempty"`
+	Parallelism     int      `json:"parallelism,omitempty"`
+}
+
+type Grep struct {
+	mcpServer *IRODSMCPServer
+	config    *common.Config
+}
+
+func NewGrep(svr *IRODSMCPServer) ToolAPI {
+	return &Grep{
+		mcpServer: svr,
+		config:    svr.GetConfig(),
+	}
+}
+
+func (t *Grep) GetName() string {
+	return GrepName
+}
+
+func (t *Grep) GetDescription() string {
+	return `Recursively search the contents of text files (data-objects) under a directory (collection) for a literal string or a regular expression.
+	The specified search root path must be an iRODS path. Binary files are skipped.
+	Returns matching lines with surrounding context lines and resource URIs in JSON format.`
+}
+
+func (t *Grep) GetTool() *mcp.Tool {
+	return &mcp.Tool{
+		Name:        t.GetName(),
+		Description: t.GetDescription(),
+		InputSchema: &jsonschema.Schema{
+			Type: "object",
+			Properties: map[string]*jsonschema.Schema{
+				"path": {
+					Type:        "string",
+					Description: "The path to the directory (collection) to search under.",
+				},
+				"pattern": {
+					Type:        "string",
+					Description: "The literal string or regular expression (RE2 syntax) to search for.",
+				},
+				"is_regex": {
+					Type:        "boolean",
+					Description: "Set to true to treat 'pattern' as a regular expression. Default is false, which searches for a literal string.",
+					Default:     json.RawMessage("false"),
+				},
+				"case_insensitive": {
+					Type:        "boolean",
+					Description: "Set to true to ignore case when matching. Default is false.",
+					Default:     json.RawMessage("false"),
+				},
+				"include": {
+					Type:        "array",
+					Items:       &jsonschema.Schema{Type: "string"},
+					Description: "Unix wildcard patterns of files to search, such as '*.txt'. Patterns without '/' match file names, others match paths relative to the search root. Default is all files.",
+				},
+				"exclude": {
+					Type:        "array",
+					Items:       &jsonschema.Schema{Type: "string"},
+					Description: "Unix wildcard patterns of files and directories to skip, such as '*.log' or '.git'.",
+				},
+				"max_file_size": {
+					Type:        "number",
+					Description: fmt.Sprintf("Files larger than this size in bytes are skipped. Default is %d bytes.", grepMaxFileSizeDefault),
+					Default:     json.RawMessage(fmt.Sprintf("%d", grepMaxFileSizeDefault)),
+				},
+				"max_total_bytes": {
+					Type:        "number",
+					Description: fmt.Sprintf("The total number of bytes to read across all files. The search stops when the budget is exhausted. Default is %d bytes.", grepMaxTotalBytesDefault),
+					Default:     json.RawMessage(fmt.Sprintf("%d", grepMaxTotalBytesDefault)),
+				},
+				"context_lines": {
+					Type:        "number",
+					Description: fmt.Sprintf("The number of lines to return before and after each matching line. Maximum is %d. Default is 0.", grepMaxContextLines),
+					Default:     json.RawMessage("0"),
+				},
+				"max_matches": {
+					Type:        "number",
+					Description: fmt.Sprintf("The maximum number of matching lines to return. Maximum is %d. Default is %d.", grepMaxMatches, grepMaxMatchesDefault),
+					Default:     json.RawMessage(fmt.Sprintf("%d", grepMaxMatchesDefault)),
+				},
+				"parallelism": {
+					Type:        "number",
+					Description: fmt.Sprintf("The number of files to read in parallel. Maximum is %d. Default is %d.", irods_common.MaxParallelism, irods_common.GetDefaultTransferThreadNum()),
+					Default:     json.RawMessage(fmt.Sprintf("%d", irods_common.GetDefaultTransferThreadNum())),
+				},
+			},
+			Required: []string{"path", "pattern"},
+		},
+	}
+}
+
+func (t *Grep) GetHandler() mcp.ToolHandler {
+	return t.Handler
+}
+
+func (t *Grep) GetAccessiblePaths(authValue *common.AuthValue) []string {
+	account, err := t.mcpServer.GetIRODSAccountFromAuthValue(authValue)
+	if err != nil {
+		return []string{}
+	}
+
+	homePath := irods_common.GetHomePath(t.config, account)
+	sharedPath := irods_common.GetSharedPath(t.config, account)
+
+	paths := []string{
+		sharedPath + "/*",
+	}
+
+	if !account.IsAnonymousUser() {
+		paths = append(paths, homePath)
+		paths = append(paths, homePath+"/*")
+	}
+
+	return paths
+}
+
+func (t *Grep) Handler(ctx context.Context, request *mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	// arguments
+	args := GrepInputArgs{}
+	err := irods_common.MarshalInputArguments(t.GetTool(), request, &args)
+	if err != nil {
+		outputErr := errors.Wrapf(err, "failed to marshal input arguments")
+		return irods_common.ToolErrorResult(outputErr), nil
+	}
+
+	if len(args.Pattern) == 0 {
+		outputErr := errors.Newf("pattern must not be empty")
+		return irods_common.ToolErrorResult(outputErr), nil
+	}
+
+	matcher, err := t.makeMatcher(&args)
+	if err != nil {
+		outputErr := errors.Wrapf(err, "failed to compile pattern %q", args.Pattern)
+		return irods_common.ToolErrorResult(outputErr), nil
+	}
+
+	// auth
+	authValue, err := common.GetAuthValue(ctx)
+	if err != nil {
+		outputErr := errors.Wrapf(err, "failed to get auth value")
+		return irods_common.ToolErrorResult(outputErr), nil
+	}
+
+	// make a irods filesystem client
+	fs, err := t.mcpServer.GetIRODSFSClientFromAuthValue(&authValue)
+	if err != nil {
+		outputErr := errors.Wrapf(err, "failed to create a irods fs client")
+		return irods_common.ToolErrorResult(outputErr), nil
+	}
+
+	irodsPath := irods_common.MakeIRODSPath(t.config, fs.GetAccount(), args.Path)
+
+	// check permission
+	if !irods_common.IsAccessAllowed(irodsPath, t.GetAccessiblePaths(&authValue)) {
+		outputErr := errors.Newf("%q request is not permitted for path %q", t.GetName(), irodsPath)
+		return irods_common.ToolErrorResult(outputErr), nil
+	}
+
+	// Get dir info
+	entry, err := fs.Stat(irodsPath)
+	if err != nil {
+		outputErr := errors.Wrapf(err, "failed to stat directory info for %q", irodsPath)
+		return irods_common.ToolErrorResult(outputErr), nil
+	}
+
+	if !entry.IsDir() {
+		outputErr := errors.Newf("path %q is not a directory (collection)", irodsPath)
+		return irods_common.ToolErrorResult(outputErr), nil
+	}
+
+	content, err := t.grep(ctx, fs, irodsPath, matcher, &args)
+	if err != nil {
+		outputErr := errors.Wrapf(err, "failed to search file contents under %q", irodsPath)
+		return irods_common.ToolErrorResult(outputErr), nil
+	}
+
+	return irods_common.ToolJSONResult(*content)
+}
+
+func (t *Grep) makeMatcher(args *GrepInputArgs) (*regexp.Regexp, error) {
+	pattern := args.Pattern
+	if !args.IsRegex {
+		pattern = regexp.QuoteMeta(pattern)
+	}
+
+	if args.CaseInsensitive {
+		pattern = "(?i)" + pattern
+	}
+
+	return regexp.Compile(pattern)
+}
+
+// grepState holds budgets shared between parallel file readers
+type grepState struct {
+	matcher       *regexp.Regexp
+	contextLines  int
+	maxFileSize   int64
+	maxTotalBytes int64
+	maxMatches    int64
+
+	bytesScanned int64 // atomic
+	matchCount   int64 // atomic
+	filesScanned int64 // atomic
+	filesSkipped int64 // atomic
+	truncated    int32 // atomic
+}
+
+func (state *grepState) setTruncated() {
+	atomic.StoreInt32(&state.truncated, 1)
+}
+
+func (state *grepState) isDone() bool {
+	return atomic.LoadInt64(&state.matchCount) >= state.maxMatches
+}
+
+func (t *Grep) grep(ctx context.Context, fs *irodsclient_fs.FileSystem, rootPath string, matcher *regexp.Regexp, args *GrepInputArgs) (*model.GrepOutput, error) {
+	state := &grepState{
+		matcher:       matcher,
+		contextLines:  args.ContextLines,
+		maxFileSize:   args.MaxFileSize,
+		maxTotalBytes: args.MaxTotalBytes,
+		maxMatches:    int64(args.MaxMatches),
+	}
+
+	if state.contextLines < 0 {
+		state.contextLines = 0
+	} else if state.contextLines > grepMaxContextLines {
+		state.contextLines = grepMaxContextLines
+	}
+
+	if state.maxFileSize <= 0 {
+		state.maxFileSize = grepMaxFileSizeDefault
+	}
+
+	if state.maxTotalBytes <= 0 {
+		state.maxTotalBytes = grepMaxTotalBytesDefault
+	}
+
+	if state.maxMatches <= 0 {
+		state.maxMatches = int64(grepMaxMatchesDefault)
+	} else if state.maxMatches > int64(grepMaxMatches) {
+		state.maxMatches = int64(grepMaxMatches)
+	}
+
+	// collect candidate files
+	candidates := []*irodsclient_fs.Entry{}
+	err := irods_common.WalkCollection(fs, rootPath, 0, func(entry *irodsclient_fs.Entry, depth int) error {
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+
+		relPath := irods_common.GetRelativeIRODSPath(rootPath, entry.Path)
+		if entry.IsDir() {
+			if irods_common.MatchGlobs(relPath, nil, args.Exclude) {
+				return nil
+			}
+			return irods_common.ErrSkipDir
+		}
+
+		if !irods_common.MatchGlobs(relPath, args.Include, args.Exclude) {
+			return nil
+		}
+
+		if entry.Size > state.maxFileSize {
+			atomic.AddInt64(&state.filesSkipped, 1)
+			return nil
+		}
+
+		if len(candidates) >= grepMaxFiles {
+			state.setTruncated()
+			return irods_common.ErrStopWalk
+		}
+
+		candidates = append(candidates, entry)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	results := make([][]model.GrepMatch, len(candidates))
+	errs := make([]error, len(candidates))
+
+	irods_common.RunParallel(len(candidates), irods_common.GetParallelism(args.Parallelism), func(idx int) {
+		if ctx.Err() != nil || state.isDone() {
+			return
+		}
+
+		matches, grepErr := t.grepFile(fs, candidates[idx], state)
+		if grepErr != nil {
+			errs[idx] = grepErr
+			return
+		}
+
+		results[idx] = matches
+	})
+
+	if ctx.Err() != nil {
+		return nil, ctx.Err()
+	}
+
+	matches := []model.GrepMatch{}
+	var firstErr error
+	errCount := 0
+	for idx := range candidates {
+		if errs[idx] != nil {
+			// unreadable files are skipped
+			if firstErr == nil {
+				firstErr = errs[idx]
+			}
+			errCount++
+			atomic.AddInt64(&state.filesSkipped, 1)
+			continue
+		}
+
+		matches = append(matches, results[idx]...)
+	}
+
+	if errCount > 0 && errCount == len(candidates) {
+		return nil, firstErr
+	}
+
+	sort.SliceStable(matches, func(i int, j int) bool {
+		if matches[i].Path != matches[j].Path {
+			return matches[i].Path < matches[j].Path
+		}
+		return matches[i].LineNumber < matches[j].LineNumber
+	})
+
+	if int64(len(matches)) > state.maxMatches {
+		matches = matches[:state.maxMatches]
+		state.setTruncated()
+	}
+
+	return &model.GrepOutput{
+		Path:         rootPath,
+		Pattern:      args.Pattern,
+		Matches:      matches,
+		FilesScanned: int(atomic.LoadInt64(&state.filesScanned)),
+		FilesSkipped: int(atomic.LoadInt64(&state.filesSkipped)),
+		BytesScanned: atomic.LoadInt64(&state.bytesScanned),
+		Truncated:    atomic.LoadInt32(&state.truncated) == 1,
+	}, nil
+}
+
+func (t *Grep) grepFile(fs *irodsclient_fs.FileSystem, entry *irodsclient_fs.Entry, state *grepState) ([]model.GrepMatch, error) {
+	if entry.Size == 0 {
+		atomic.AddInt64(&state.filesScanned, 1)
+		return nil, nil
+	}
+
+	// reserve byte budget
+	if atomic.AddInt64(&state.bytesScanned, entry.Size) > state.maxTotalBytes {
+		atomic.AddInt64(&state.bytesScanned, -entry.Size)
+		atomic.AddInt64(&state.filesSkipped, 1)
+		state.setTruncated()
+		return nil, nil
+	}
+
+	handle, err := fs.OpenFile(entry.Path, "", "r")
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to open file %q", entry.Path)
+	}
+	defer handle.Close()
+
+	reader := bufio.NewReader(io.LimitReader(handle, entry.Size))
+
+	// skip binary files
+	head, err := reader.Peek(int(irods_common.MIME_TYPE_READ_SIZE))
+	if err != nil && err != io.EOF && err != bufio.ErrBufferFull {
+		return nil, errors.Wrapf(err, "failed to read file %q", entry.Path)
+	}
+
+	mimeType := irods_common.DetectMimeTypeWithContent(entry.Path, 0, head)
+	if !irods_common.IsTextFile(mimeType) || bytes.IndexByte(head, 0) >= 0 {
+		atomic.AddInt64(&state.filesSkipped, 1)
+		return nil, nil
+	}
+
+	atomic.AddInt64(&state.filesScanned, 1)
+
+	resourceURI := irods_common.MakeResourceURI(entry.Path)
+	matches := []model.GrepMatch{}
+	before := []string{}
+	pending := []int{} // indices of matches waiting for after-context lines
+	lineNumber := int64(0)
+
+	for {
+		line, readErr := reader.ReadString('\n')
+		if len(line) == 0 && readErr != nil {
+			if readErr == io.EOF {
+				break
+			}
+			return nil, errors.Wrapf(readErr, "failed to read file %q", entry.Path)
+		}
+
+		lineNumber++
+		fullLine := strings.TrimRight(line, "\r\n")
+		line = truncateGrepLine(fullLine, 0)
+
+		// fill after-context of previous matches
+		stillPending := pending[:0]
+		for _, matchIdx := range pending {
+			matches[matchIdx].After = append(matches[matchIdx].After, line)
+			if len(matches[matchIdx].After) < state.contextLines {
+				stillPending = append(stillPending, matchIdx)
+			}
+		}
+		pending = stillPending
+
+		// match on the full line, only the returned lines are truncated
+		if matchLoc := state.matcher.FindStringIndex(fullLine); matchLoc != nil {
+			if state.isDone() {
+				state.setTruncated()
+				break
+			}
+
+			atomic.AddInt64(&state.matchCount, 1)
+
+			match := model.GrepMatch{
+				Path:        entry.Path,
+				ResourceURI: resourceURI,
+				LineNumber:  lineNumber,
+				Line:        truncateGrepLine(fullLine, matchLoc[0]),
+			}
+
+			if state.contextLines > 0 {
+				match.Before = append([]string{}, before...)
+				pending = append(pending, len(matches))
+			}
+
+			matches = append(matches, match)
+		}
+
+		if state.contextLines > 0 {
+			before = append(before, line)
+			if len(before) > state.contextLines {
+				before = before[1:]
+			}
+		}
+
+		if readErr != nil {
+			if readErr == io.EOF {
+				break
+			}
+			return nil, errors.Wrapf(readErr, "failed to read file %q", entry.Path)
+		}
+	}
+
+	return matches, nil
+}
+
+// truncateGrepLine cuts a long line to grepMaxLineLength bytes, keeping the byte at matchStart in the returned part
+func truncateGrepLine(line string, matchStart int) string {
+	if len(line) <= grepMaxLineLength {
+		return line
+	}
+
+	start := 0
+	if matchStart > grepMaxLineLength/2 {
+		start = matchStart - grepMaxLineLength/2
+		if start > len(line)-grepMaxLineLength {
+			start = len(line) - grepMaxLineLength
+		}
+	}
+	end := start + grepMaxLineLength
+
+	// cut at rune boundaries
+	for start > 0 && !utf8.RuneStart(line[start]) {
+		start--
+	}
+	for end < len(line) && !utf8.RuneStart(line[end]) {
+		end--
+	}
+
+	truncated := line[start:end]
+	if start > 0 {
+		truncated = "..." + truncated
+	}
+	if end < len(line) {
+		truncated += "..."
+	}
+	return truncated
+}
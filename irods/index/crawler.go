@@ -0,0 +1,195 @@
+package index
+
+import (
+	"path"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/cockroachdb/errors"
+	irodsclient_fs "github.com/cyverse/go-irodsclient/fs"
+	irodsclient_common "github.com/cyverse/go-irodsclient/irods/common"
+	irods_common "github.com/cyverse/irods-mcp-server/irods/common"
+	log "github.com/sirupsen/logrus"
+)
+
+const (
+	crawlPageSize     int = 10000 // data objects listed per catalog query
+	crawlSaveInterval int = 500   // save the index after this many documents are updated
+)
+
+// RefreshStats summarizes a refresh of the index
+type RefreshStats struct {
+	Listed    int
+	Updated   int
+	Unchanged int
+	Removed   int
+	Failed    int
+}
+
+// crawlEntry is a data object listed from the catalog
+type crawlEntry struct {
+	path       string
+	size       int64
+	modifyTime time.Time
+	checksum   string
+}
+
+// Refresh crawls data objects under the roots and updates the index for new or changed ones
+// documents under roots that are no longer listed are removed
+func (index *ContentIndex) Refresh(filesystem *irodsclient_fs.FileSystem, roots []string, option *ExtractOption) (*RefreshStats, error) {
+	logger := log.WithFields(log.Fields{
+		"roots": roots,
+	})
+
+	stats := &RefreshStats{}
+	seen := map[string]bool{}
+	refreshedRoots := []string{}
+	updatedSinceSave := 0
+
+	var lastErr error
+	for _, root := range roots {
+		err := index.crawlRoot(filesystem, root, func(entry *crawlEntry) {
+			seen[entry.path] = true
+			stats.Listed++
+
+			doc := index.GetDocument(entry.path)
+			if doc != nil && doc.IsSame(entry.size, entry.modifyTime, entry.checksum) {
+				stats.Unchanged++
+				return
+			}
+
+			text := ""
+			extractorType := GetExtractorType(entry.path, entry.size, option)
+			if extractorType != ExtractorTypeNone {
+				extracted, err := ExtractText(filesystem, entry.path, entry.size, extractorType)
+				if err != nil {
+					// binary content or unreadable, kept without text so it is not read again until changed
+					logger.WithError(err).Debugf("failed to extract text of %q", entry.path)
+					stats.Failed++
+				} else {
+					text = extracted
+				}
+			}
+
+			err := index.UpdateDocument(entry.path, entry.size, entry.modifyTime, entry.checksum, text)
+			if err != nil {
+				logger.WithError(err).Warnf("failed to index %q", entry.path)
+				stats.Failed++
+				return
+			}
+
+			stats.Updated++
+			updatedSinceSave++
+			if updatedSinceSave >= crawlSaveInterval {
+				updatedSinceSave = 0
+				if err := index.Save(); err != nil {
+					logger.WithError(err).Warn("failed to save content index")
+				}
+			}
+		})
+		if err != nil {
+			// keep documents under the root, the crawl was incomplete
+			logger.WithError(err).Warnf("failed to crawl %q", root)
+			lastErr = err
+			continue
+		}
+
+		refreshedRoots = append(refreshedRoots, root)
+	}
+
+	for _, docPath := range index.GetPaths() {
+		if seen[docPath] {
+			continue
+		}
+
+		for _, root := range refreshedRoots {
+			if isUnderRoot(root, docPath) {
+				index.RemoveDocument(docPath)
+				stats.Removed++
+				break
+			}
+		}
+	}
+
+	if len(refreshedRoots) == len(roots) {
+		index.SetIndexedAt(time.Now())
+	}
+
+	err := index.Save()
+	if err != nil {
+		return stats, err
+	}
+
+	if len(refreshedRoots) == 0 && lastErr != nil {
+		return stats, lastErr
+	}
+
+	return stats, nil
+}
+
+// crawlRoot lists good replicas of data objects under the root in pages
+func (index *ContentIndex) crawlRoot(filesystem *irodsclient_fs.FileSystem, root string, handle func(entry *crawlEntry)) error {
+	pathCondition, err := irods_common.MakeGenQueryPathCondition(root)
+	if err != nil {
+		return err
+	}
+
+	orderOption := irods_common.GenQuerySelectNormal | irods_common.GenQueryOrderBy
+	query := irods_common.GenQuery{
+		Selects: []irods_common.GenQueryColumn{
+			{Column: irodsclient_common.ICAT_COLUMN_COLL_NAME, Option: orderOption},
+			{Column: irodsclient_common.ICAT_COLUMN_DATA_NAME, Option: orderOption},
+			{Column: irodsclient_common.ICAT_COLUMN_DATA_SIZE, Option: irods_common.GenQuerySelectNormal},
+			{Column: irodsclient_common.ICAT_COLUMN_D_MODIFY_TIME, Option: irods_common.GenQuerySelectNormal},
+			{Column: irodsclient_common.ICAT_COLUMN_D_DATA_CHECKSUM, Option: irods_common.GenQuerySelectNormal},
+		},
+		Conditions: []irods_common.GenQueryCondition{
+			{Column: irodsclient_common.ICAT_COLUMN_COLL_NAME, Condition: pathCondition},
+			{Column: irodsclient_common.ICAT_COLUMN_D_REPL_STATUS, Condition: "= '1'"},
+		},
+		Limit: crawlPageSize,
+	}
+
+	lastPath := ""
+	for {
+		result, err := irods_common.RunGenQuery(filesystem, &query)
+		if err != nil {
+			return errors.Wrapf(err, "failed to list data objects under %q", root)
+		}
+
+		for _, row := range result.Rows {
+			entryPath := path.Join(row[0], row[1])
+
+			// good replicas of the same data object are listed one after another
+			if entryPath == lastPath {
+				continue
+			}
+			lastPath = entryPath
+
+			size, _ := strconv.ParseInt(row[2], 10, 64)
+			modifyTime := time.Time{}
+			if epoch, err := strconv.ParseInt(strings.TrimSpace(row[3]), 10, 64); err == nil {
+				modifyTime = time.Unix(epoch, 0).UTC()
+			}
+
+			handle(&crawlEntry{
+				path:       entryPath,
+				size:       size,
+				modifyTime: modifyTime,
+				checksum:   row[4],
+			})
+		}
+
+		if !result.HasMore {
+			return nil
+		}
+
+		query.Offset += len(result.Rows)
+	}
+}
+
+func isUnderRoot(root string, p string) bool {
+	root = strings.TrimSuffix(root, "/")
+	return p == root || strings.HasPrefix(p, root+"/")
+}
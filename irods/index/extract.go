@@ -0,0 +1,399 @@
+package index
+
+import (
+	"bytes"
+	"compress/zlib"
+	"encoding/json"
+	"io"
+	"path"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/cockroachdb/errors"
+	irodsclient_fs "github.com/cyverse/go-irodsclient/fs"
+	irods_common "github.com/cyverse/irods-mcp-server/irods/common"
+)
+
+const (
+	MaxExtractReadSize int64 = 16 * 1024 * 1024 // 16MB, larger files are not indexed
+	MaxIndexedTextSize int   = 1 * 1024 * 1024  // 1MB of extracted text per file
+)
+
+// ExtractorType is the type of text extraction for a file
+type ExtractorType string
+
+const (
+	ExtractorTypeNone     ExtractorType = ""
+	ExtractorTypeText     ExtractorType = "text"
+	ExtractorTypeNotebook ExtractorType = "notebook"
+	ExtractorTypePDF      ExtractorType = "pdf"
+)
+
+// ExtractOption determines which files text is extracted from
+type ExtractOption struct {
+	Notebooks bool
+	PDFs      bool
+}
+
+// GetExtractorType returns how to extract text from the file, or ExtractorTypeNone if it is not indexed
+func GetExtractorType(p string, size int64, option *ExtractOption) ExtractorType {
+	if size <= 0 || size > MaxExtractReadSize {
+		return ExtractorTypeNone
+	}
+
+	ext := strings.ToLower(path.Ext(p))
+	switch ext {
+	case ".ipynb":
+		if option.Notebooks {
+			return ExtractorTypeNotebook
+		}
+		return ExtractorTypeNone
+	case ".pdf":
+		if option.PDFs {
+			return ExtractorTypePDF
+		}
+		return ExtractorTypeNone
+	case "":
+		// e.g. README, checked for text when extracting
+		return ExtractorTypeText
+	}
+
+	// sequence data is large and not useful for full-text search
+	if len(irods_common.GetBioFormatFromPath(p)) > 0 {
+		return ExtractorTypeNone
+	}
+
+	if irods_common.IsTextFile(irods_common.DetectMimeTypeWithExtension(p)) {
+		return ExtractorTypeText
+	}
+
+	return ExtractorTypeNone
+}
+
+// ExtractText reads a data object and extracts its text
+func ExtractText(filesystem *irodsclient_fs.FileSystem, sourcePath string, size int64, extractorType ExtractorType) (string, error) {
+	readLen := size
+	if readLen <= 0 || readLen > MaxExtractReadSize {
+		readLen = MaxExtractReadSize
+	}
+
+	content, err := irods_common.ReadDataObject(filesystem, sourcePath, 0, readLen)
+	if err != nil {
+		return "", err
+	}
+
+	text := ""
+	switch extractorType {
+	case ExtractorTypeText:
+		chunk, err := irods_common.DecodeTextChunk(content, 0, "", true)
+		if err != nil {
+			return "", errors.Wrapf(err, "failed to decode text of file %q", sourcePath)
+		}
+		text = chunk.Text
+	case ExtractorTypeNotebook:
+		text, err = extractNotebookText(content)
+		if err != nil {
+			return "", errors.Wrapf(err, "failed to extract text of notebook %q", sourcePath)
+		}
+	case ExtractorTypePDF:
+		text = extractPDFText(content)
+	default:
+		return "", errors.Newf("unknown extractor type %q", extractorType)
+	}
+
+	return truncateText(text, MaxIndexedTextSize), nil
+}
+
+// truncateText cuts text at maxSize bytes on a character boundary
+func truncateText(text string, maxSize int) string {
+	if len(text) <= maxSize {
+		return text
+	}
+
+	end := maxSize
+	for end > 0 && !utf8.RuneStart(text[end]) {
+		end--
+	}
+	return text[:end]
+}
+
+// extractNotebookText returns the source of markdown and code cells of a Jupyter notebook
+func extractNotebookText(content []byte) (string, error) {
+	notebook := struct {
+		Cells []struct {
+			CellType string          `json:"cell_type"`
+			Source   json.RawMessage `json:"source"`
+		} `json:"cells"`
+	}{}
+
+	err := json.Unmarshal(content, &notebook)
+	if err != nil {
+		return "", errors.Wrapf(err, "failed to parse notebook JSON")
+	}
+
+	var sb strings.Builder
+	for _, cell := range notebook.Cells {
+		if cell.CellType != "markdown" && cell.CellType != "code" {
+			continue
+		}
+
+		// source is a string or a list of lines
+		var lines []string
+		if err := json.Unmarshal(cell.Source, &lines); err != nil {
+			var source string
+			if err := json.Unmarshal(cell.Source, &source); err != nil {
+				continue
+			}
+			lines = []string{source}
+		}
+
+		for _, line := range lines {
+			sb.WriteString(line)
+		}
+		sb.WriteString("\n\n")
+	}
+
+	return sb.String(), nil
+}
+
+// extractPDFText extracts text shown by content streams of a PDF, best effort
+// fonts with custom encodings produce no readable text
+func extractPDFText(content []byte) string {
+	var sb strings.Builder
+
+	pos := 0
+	for {
+		streamIdx := bytes.Index(content[pos:], []byte("stream"))
+		if streamIdx < 0 {
+			break
+		}
+		streamIdx += pos
+
+		// skip "endstream"
+		if streamIdx >= 3 && string(content[streamIdx-3:streamIdx]) == "end" {
+			pos = streamIdx + len("stream")
+			continue
+		}
+
+		dataStart := streamIdx + len("stream")
+		if dataStart < len(content) && content[dataStart] == '\r' {
+			dataStart++
+		}
+		if dataStart < len(content) && content[dataStart] == '\n' {
+			dataStart++
+		}
+
+		endIdx := bytes.Index(content[dataStart:], []byte("endstream"))
+		if endIdx < 0 {
+			break
+		}
+		dataEnd := dataStart + endIdx
+		pos = dataEnd + len("endstream")
+
+		// the stream dictionary is before the keyword
+		dictStart := bytes.LastIndex(content[:streamIdx], []byte("<<"))
+		dict := []byte{}
+		if dictStart >= 0 {
+			dict = content[dictStart:streamIdx]
+		}
+
+		data := content[dataStart:dataEnd]
+		if bytes.Contains(dict, []byte("/FlateDecode")) {
+			reader, err := zlib.NewReader(bytes.NewReader(data))
+			if err != nil {
+				continue
+			}
+
+			inflated, err := io.ReadAll(io.LimitReader(reader, MaxExtractReadSize))
+			reader.Close()
+			if err != nil && len(inflated) == 0 {
+				continue
+			}
+			data = inflated
+		} else if bytes.Contains(dict, []byte("/Filter")) {
+			// other filters are images or fonts
+			continue
+		}
+
+		extractPDFContentStreamText(data, &sb)
+		if sb.Len() > MaxIndexedTextSize {
+			break
+		}
+	}
+
+	return sb.String()
+}
+
+// extractPDFContentStreamText appends text of Tj, TJ, ' and " operators in a content stream
+func extractPDFContentStreamText(data []byte, sb *strings.Builder) {
+	inText := false
+	strs := []string{}
+
+	for i := 0; i < len(data); i++ {
+		c := data[i]
+		switch {
+		case c == '(':
+			str, next := readPDFLiteralString(data, i)
+			strs = append(strs, str)
+			i = next - 1
+		case c == '<' && i+1 < len(data) && data[i+1] != '<':
+			str, next := readPDFHexString(data, i)
+			strs = append(strs, str)
+			i = next - 1
+		case c == '%':
+			// comment
+			for i < len(data) && data[i] != '\n' && data[i] != '\r' {
+				i++
+			}
+		case isPDFRegularChar(c):
+			start := i
+			for i < len(data) && isPDFRegularChar(data[i]) {
+				i++
+			}
+			operator := string(data[start:i])
+			i--
+
+			switch operator {
+			case "BT":
+				inText = true
+				strs = strs[:0]
+			case "ET":
+				inText = false
+				sb.WriteString("\n")
+				strs = strs[:0]
+			case "Tj", "TJ", "'", "\"":
+				if inText {
+					if operator == "'" || operator == "\"" {
+						sb.WriteString("\n")
+					}
+					for _, str := range strs {
+						sb.WriteString(str)
+					}
+				}
+				strs = strs[:0]
+			case "Td", "TD", "T*", "Tm":
+				if inText {
+					sb.WriteString(" ")
+				}
+				strs = strs[:0]
+			default:
+				// kerning numbers in TJ arrays are kept as word gaps
+				if number, err := strconv.ParseFloat(operator, 64); err == nil {
+					if number < -200 && len(strs) > 0 {
+						strs = append(strs, " ")
+					}
+				} else {
+					strs = strs[:0]
+				}
+			}
+		}
+	}
+}
+
+func isPDFRegularChar(c byte) bool {
+	switch c {
+	case ' ', '\t', '\r', '\n', '\f', 0, '(', ')', '<', '>', '[', ']', '{', '}', '/', '%':
+		return false
+	}
+	return true
+}
+
+func readPDFLiteralString(data []byte, start int) (string, int) {
+	var sb strings.Builder
+	depth := 0
+
+	i := start
+	for ; i < len(data); i++ {
+		c := data[i]
+		switch c {
+		case '(':
+			depth++
+			if depth == 1 {
+				continue
+			}
+		case ')':
+			depth--
+			if depth == 0 {
+				return sb.String(), i + 1
+			}
+		case '\\':
+			i++
+			if i >= len(data) {
+				return sb.String(), i
+			}
+
+			switch e := data[i]; e {
+			case 'n', 'r':
+				sb.WriteByte('\n')
+			case 't':
+				sb.WriteByte('\t')
+			case 'b', 'f':
+			case '\r', '\n':
+				// line continuation
+			default:
+				if e >= '0' && e <= '7' {
+					// octal
+					end := i
+					for end < len(data) && end < i+3 && data[end] >= '0' && data[end] <= '7' {
+						end++
+					}
+					value, _ := strconv.ParseUint(string(data[i:end]), 8, 8)
+					writePDFByte(&sb, byte(value))
+					i = end - 1
+				} else {
+					sb.WriteByte(e)
+				}
+			}
+			continue
+		}
+
+		writePDFByte(&sb, c)
+	}
+
+	return sb.String(), i
+}
+
+func readPDFHexString(data []byte, start int) (string, int) {
+	end := bytes.IndexByte(data[start:], '>')
+	if end < 0 {
+		return "", len(data)
+	}
+	end += start
+
+	hexDigits := []byte{}
+	for _, c := range data[start+1 : end] {
+		if (c >= '0' && c <= '9') || (c >= 'a' && c <= 'f') || (c >= 'A' && c <= 'F') {
+			hexDigits = append(hexDigits, c)
+		}
+	}
+	if len(hexDigits)%2 == 1 {
+		hexDigits = append(hexDigits, '0')
+	}
+
+	var sb strings.Builder
+	printable := true
+	for i := 0; i < len(hexDigits); i += 2 {
+		value, _ := strconv.ParseUint(string(hexDigits[i:i+2]), 16, 8)
+		if value < 0x20 && value != '\n' && value != '\t' {
+			// two-byte glyph ids of embedded fonts
+			printable = false
+			break
+		}
+		writePDFByte(&sb, byte(value))
+	}
+
+	if !printable {
+		return "", end + 1
+	}
+	return sb.String(), end + 1
+}
+
+// writePDFByte writes a byte of PDFDocEncoding, treated as Latin-1
+func writePDFByte(sb *strings.Builder, c byte) {
+	if c < 0x80 {
+		sb.WriteByte(c)
+		return
+	}
+	sb.WriteRune(rune(c))
+}
@@ -0,0 +1,578 @@
+package index
+
+import (
+	"compress/gzip"
+	"encoding/gob"
+	"fmt"
+	"io"
+	"math"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+	"unicode"
+	"unicode/utf8"
+
+	"github.com/cockroachdb/errors"
+	log "github.com/sirupsen/logrus"
+)
+
+const (
+	indexFileName    string = "index.gob"
+	indexDocsDirName string = "docs"
+	indexVersion     int    = 1
+
+	// BM25 parameters
+	bm25K1 float64 = 1.2
+	bm25B  float64 = 0.75
+
+	snippetContext  int = 80 // bytes of text around a matched term
+	snippetMaxBytes int = 320
+)
+
+// Document is an indexed data object
+type Document struct {
+	ID         uint32
+	Path       string
+	Size       int64
+	ModifyTime time.Time
+	Checksum   string
+	Length     int // number of terms, 0 if no text was extracted
+}
+
+// IsSame returns true if the data object has not changed since it was indexed
+func (doc *Document) IsSame(size int64, modifyTime time.Time, checksum string) bool {
+	return doc.Size == size && doc.ModifyTime.Equal(modifyTime) && doc.Checksum == checksum
+}
+
+// Posting is an occurrence of a term in a document
+type Posting struct {
+	DocID     uint32
+	Frequency uint32
+}
+
+// SearchHit is a document matching a search with its relevance score
+type SearchHit struct {
+	Document *Document
+	Score    float64
+}
+
+// indexFile is the on-disk form of the index
+type indexFile struct {
+	Version   int
+	NextID    uint32
+	IndexedAt time.Time
+	Documents []*Document
+	Postings  map[string][]Posting
+}
+
+// ContentIndex is an inverted index of text extracted from data objects, stored in a local dir
+// text of documents is kept gzipped under the dir for making snippets
+type ContentIndex struct {
+	dir string
+
+	mutex       sync.RWMutex
+	documents   map[uint32]*Document
+	pathToID    map[string]uint32
+	postings    map[string][]Posting
+	nextID      uint32
+	totalLength int64
+	removed     int // documents removed since the last compaction
+	indexedAt   time.Time
+}
+
+// NewContentIndex creates a content index in the dir, loads the existing index if there is
+func NewContentIndex(dir string) (*ContentIndex, error) {
+	index := &ContentIndex{
+		dir:       dir,
+		documents: map[uint32]*Document{},
+		pathToID:  map[string]uint32{},
+		postings:  map[string][]Posting{},
+		nextID:    1,
+	}
+
+	err := os.MkdirAll(index.getDocsDir(), 0o700)
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to make content index dir %q", dir)
+	}
+
+	err = index.load()
+	if err != nil {
+		logger := log.WithFields(log.Fields{
+			"dir": dir,
+		})
+		// start over, the index is rebuilt by the next refresh
+		logger.WithError(err).Warn("failed to load content index, starting with an empty index")
+	}
+
+	return index, nil
+}
+
+func (index *ContentIndex) getIndexFilePath() string {
+	return filepath.Join(index.dir, indexFileName)
+}
+
+func (index *ContentIndex) getDocsDir() string {
+	return filepath.Join(index.dir, indexDocsDirName)
+}
+
+func (index *ContentIndex) getDocumentTextPath(id uint32) string {
+	return filepath.Join(index.getDocsDir(), fmt.Sprintf("%d.txt.gz", id))
+}
+
+func (index *ContentIndex) load() error {
+	f, err := os.Open(index.getIndexFilePath())
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil
+		}
+		return errors.Wrapf(err, "failed to open content index file")
+	}
+	defer f.Close()
+
+	stored := indexFile{}
+	err = gob.NewDecoder(f).Decode(&stored)
+	if err != nil {
+		return errors.Wrapf(err, "failed to decode content index file")
+	}
+
+	if stored.Version != indexVersion {
+		return errors.Newf("unsupported content index version %d", stored.Version)
+	}
+
+	index.mutex.Lock()
+	defer index.mutex.Unlock()
+
+	for _, doc := range stored.Documents {
+		index.documents[doc.ID] = doc
+		index.pathToID[doc.Path] = doc.ID
+		index.totalLength += int64(doc.Length)
+	}
+
+	if stored.Postings != nil {
+		index.postings = stored.Postings
+	}
+	index.nextID = stored.NextID
+	index.indexedAt = stored.IndexedAt
+	return nil
+}
+
+// Save writes the index to the dir
+func (index *ContentIndex) Save() error {
+	index.mutex.Lock()
+	defer index.mutex.Unlock()
+
+	// do not store postings of removed documents
+	index.compact()
+
+	stored := indexFile{
+		Version:   indexVersion,
+		NextID:    index.nextID,
+		IndexedAt: index.indexedAt,
+		Documents: make([]*Document, 0, len(index.documents)),
+		Postings:  index.postings,
+	}
+
+	for _, doc := range index.documents {
+		stored.Documents = append(stored.Documents, doc)
+	}
+
+	// write to a temp file then rename, so a crash does not leave a broken index
+	tempPath := index.getIndexFilePath() + ".tmp"
+	f, err := os.OpenFile(tempPath, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, 0o600)
+	if err != nil {
+		return errors.Wrapf(err, "failed to create content index file %q", tempPath)
+	}
+
+	err = gob.NewEncoder(f).Encode(&stored)
+	if err != nil {
+		f.Close()
+		return errors.Wrapf(err, "failed to encode content index file")
+	}
+
+	err = f.Close()
+	if err != nil {
+		return errors.Wrapf(err, "failed to close content index file %q", tempPath)
+	}
+
+	err = os.Rename(tempPath, index.getIndexFilePath())
+	if err != nil {
+		return errors.Wrapf(err, "failed to rename content index file %q", tempPath)
+	}
+
+	return nil
+}
+
+// compact drops postings of removed documents, the caller must hold the write lock
+func (index *ContentIndex) compact() {
+	if index.removed == 0 {
+		return
+	}
+
+	for term, postings := range index.postings {
+		kept := postings[:0]
+		for _, posting := range postings {
+			if _, ok := index.documents[posting.DocID]; ok {
+				kept = append(kept, posting)
+			}
+		}
+
+		if len(kept) == 0 {
+			delete(index.postings, term)
+		} else {
+			index.postings[term] = kept
+		}
+	}
+
+	index.removed = 0
+}
+
+// GetDocument returns the indexed document of the path, nil if not indexed
+func (index *ContentIndex) GetDocument(docPath string) *Document {
+	index.mutex.RLock()
+	defer index.mutex.RUnlock()
+
+	if id, ok := index.pathToID[docPath]; ok {
+		return index.documents[id]
+	}
+	return nil
+}
+
+// GetPaths returns paths of all indexed documents
+func (index *ContentIndex) GetPaths() []string {
+	index.mutex.RLock()
+	defer index.mutex.RUnlock()
+
+	paths := make([]string, 0, len(index.pathToID))
+	for docPath := range index.pathToID {
+		paths = append(paths, docPath)
+	}
+	return paths
+}
+
+// GetDocumentCount returns the number of indexed documents
+func (index *ContentIndex) GetDocumentCount() int {
+	index.mutex.RLock()
+	defer index.mutex.RUnlock()
+
+	return len(index.documents)
+}
+
+// GetIndexedAt returns the time of the last completed refresh
+func (index *ContentIndex) GetIndexedAt() time.Time {
+	index.mutex.RLock()
+	defer index.mutex.RUnlock()
+
+	return index.indexedAt
+}
+
+// SetIndexedAt records the time of a completed refresh
+func (index *ContentIndex) SetIndexedAt(t time.Time) {
+	index.mutex.Lock()
+	defer index.mutex.Unlock()
+
+	index.indexedAt = t
+}
+
+// UpdateDocument adds or replaces the document of the path with the text
+func (index *ContentIndex) UpdateDocument(docPath string, size int64, modifyTime time.Time, checksum string, text string) error {
+	frequencies := map[string]uint32{}
+	length := 0
+	for _, token := range Tokenize(text) {
+		frequencies[token.Term]++
+		length++
+	}
+
+	index.mutex.Lock()
+	id := index.nextID
+	index.nextID++
+	index.mutex.Unlock()
+
+	if length > 0 {
+		err := index.writeDocumentText(id, text)
+		if err != nil {
+			return err
+		}
+	}
+
+	doc := &Document{
+		ID:         id,
+		Path:       docPath,
+		Size:       size,
+		ModifyTime: modifyTime,
+		Checksum:   checksum,
+		Length:     length,
+	}
+
+	index.mutex.Lock()
+	defer index.mutex.Unlock()
+
+	index.removeDocumentInternal(docPath)
+
+	index.documents[id] = doc
+	index.pathToID[docPath] = id
+	index.totalLength += int64(length)
+
+	for term, frequency := range frequencies {
+		index.postings[term] = append(index.postings[term], Posting{DocID: id, Frequency: frequency})
+	}
+
+	return nil
+}
+
+// RemoveDocument removes the document of the path from the index
+func (index *ContentIndex) RemoveDocument(docPath string) {
+	index.mutex.Lock()
+	defer index.mutex.Unlock()
+
+	index.removeDocumentInternal(docPath)
+}
+
+// removeDocumentInternal removes a document, postings are dropped at the next compaction
+func (index *ContentIndex) removeDocumentInternal(docPath string) {
+	id, ok := index.pathToID[docPath]
+	if !ok {
+		return
+	}
+
+	doc := index.documents[id]
+	index.totalLength -= int64(doc.Length)
+
+	delete(index.documents, id)
+	delete(index.pathToID, docPath)
+	index.removed++
+
+	os.Remove(index.getDocumentTextPath(id))
+}
+
+func (index *ContentIndex) writeDocumentText(id uint32, text string) error {
+	textPath := index.getDocumentTextPath(id)
+	f, err := os.OpenFile(textPath, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, 0o600)
+	if err != nil {
+		return errors.Wrapf(err, "failed to create document text file %q", textPath)
+	}
+	defer f.Close()
+
+	writer := gzip.NewWriter(f)
+	_, err = io.WriteString(writer, text)
+	if err != nil {
+		return errors.Wrapf(err, "failed to write document text file %q", textPath)
+	}
+
+	err = writer.Close()
+	if err != nil {
+		return errors.Wrapf(err, "failed to write document text file %q", textPath)
+	}
+
+	return nil
+}
+
+// ReadDocumentText returns the text extracted from the document
+func (index *ContentIndex) ReadDocumentText(doc *Document) (string, error) {
+	textPath := index.getDocumentTextPath(doc.ID)
+	f, err := os.Open(textPath)
+	if err != nil {
+		return "", errors.Wrapf(err, "failed to open document text file %q", textPath)
+	}
+	defer f.Close()
+
+	reader, err := gzip.NewReader(f)
+	if err != nil {
+		return "", errors.Wrapf(err, "failed to read document text file %q", textPath)
+	}
+	defer reader.Close()
+
+	text, err := io.ReadAll(reader)
+	if err != nil {
+		return "", errors.Wrapf(err, "failed to read document text file %q", textPath)
+	}
+
+	return string(text), nil
+}
+
+// Search returns documents matching query terms ranked by BM25, filter selects documents to consider
+// if matchAll is set, documents must contain all terms
+func (index *ContentIndex) Search(terms []string, matchAll bool, filter func(doc *Document) bool) []SearchHit {
+	index.mutex.RLock()
+	defer index.mutex.RUnlock()
+
+	if len(terms) == 0 || len(index.documents) == 0 {
+		return []SearchHit{}
+	}
+
+	docCount := float64(len(index.documents))
+	avgLength := float64(index.totalLength) / docCount
+	if avgLength <= 0 {
+		avgLength = 1
+	}
+
+	scores := map[uint32]float64{}
+	matchedTerms := map[uint32]int{}
+	for _, term := range terms {
+		postings := index.postings[term]
+
+		// postings may include removed documents until compaction
+		liveDocs := 0
+		for _, posting := range postings {
+			if _, ok := index.documents[posting.DocID]; ok {
+				liveDocs++
+			}
+		}
+
+		if liveDocs == 0 {
+			continue
+		}
+
+		idf := math.Log(1 + (docCount-float64(liveDocs)+0.5)/(float64(liveDocs)+0.5))
+
+		for _, posting := range postings {
+			doc, ok := index.documents[posting.DocID]
+			if !ok {
+				continue
+			}
+
+			tf := float64(posting.Frequency)
+			norm := bm25K1 * (1 - bm25B + bm25B*float64(doc.Length)/avgLength)
+			scores[doc.ID] += idf * tf * (bm25K1 + 1) / (tf + norm)
+			matchedTerms[doc.ID]++
+		}
+	}
+
+	hits := []SearchHit{}
+	for id, score := range scores {
+		if matchAll && matchedTerms[id] < len(terms) {
+			continue
+		}
+
+		doc := index.documents[id]
+		if filter != nil && !filter(doc) {
+			continue
+		}
+
+		hits = append(hits, SearchHit{
+			Document: doc,
+			Score:    score,
+		})
+	}
+
+	sort.Slice(hits, func(i int, j int) bool {
+		if hits[i].Score != hits[j].Score {
+			return hits[i].Score > hits[j].Score
+		}
+		return hits[i].Document.Path < hits[j].Document.Path
+	})
+
+	return hits
+}
+
+// MakeSnippets returns up to maxSnippets excerpts of the document around query terms, in order of appearance
+func (index *ContentIndex) MakeSnippets(doc *Document, terms []string, maxSnippets int) ([]string, error) {
+	if doc.Length == 0 || maxSnippets <= 0 {
+		return []string{}, nil
+	}
+
+	text, err := index.ReadDocumentText(doc)
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			// removed from the index after the search
+			return []string{}, nil
+		}
+		return nil, err
+	}
+
+	return makeSnippets(text, terms, maxSnippets), nil
+}
+
+type snippetWindow struct {
+	start   int
+	end     int
+	terms   map[string]bool
+	matches int
+}
+
+func makeSnippets(text string, terms []string, maxSnippets int) []string {
+	termSet := map[string]bool{}
+	for _, term := range terms {
+		termSet[term] = true
+	}
+
+	// windows around matched tokens, merged when overlapping
+	windows := []*snippetWindow{}
+	for _, token := range Tokenize(text) {
+		if !termSet[token.Term] {
+			continue
+		}
+
+		start := token.Start - snippetContext
+		end := token.End + snippetContext
+
+		if len(windows) > 0 {
+			last := windows[len(windows)-1]
+			if start <= last.end && end-last.start <= snippetMaxBytes {
+				last.end = end
+				last.terms[token.Term] = true
+				last.matches++
+				continue
+			}
+		}
+
+		windows = append(windows, &snippetWindow{
+			start:   start,
+			end:     end,
+			terms:   map[string]bool{token.Term: true},
+			matches: 1,
+		})
+	}
+
+	// prefer windows with more distinct terms
+	ranked := make([]*snippetWindow, len(windows))
+	copy(ranked, windows)
+	sort.SliceStable(ranked, func(i int, j int) bool {
+		if len(ranked[i].terms) != len(ranked[j].terms) {
+			return len(ranked[i].terms) > len(ranked[j].terms)
+		}
+		return ranked[i].matches > ranked[j].matches
+	})
+
+	if len(ranked) > maxSnippets {
+		ranked = ranked[:maxSnippets]
+	}
+
+	sort.Slice(ranked, func(i int, j int) bool {
+		return ranked[i].start < ranked[j].start
+	})
+
+	snippets := []string{}
+	for _, window := range ranked {
+		snippets = append(snippets, makeSnippetText(text, window.start, window.end))
+	}
+
+	return snippets
+}
+
+// makeSnippetText cuts text at character boundaries and collapses whitespace
+func makeSnippetText(text string, start int, end int) string {
+	prefix := "..."
+	suffix := "..."
+
+	if start <= 0 {
+		start = 0
+		prefix = ""
+	}
+	if end >= len(text) {
+		end = len(text)
+		suffix = ""
+	}
+
+	for start > 0 && !utf8.RuneStart(text[start]) {
+		start--
+	}
+	for end < len(text) && !utf8.RuneStart(text[end]) {
+		end++
+	}
+
+	fields := strings.FieldsFunc(text[start:end], unicode.IsSpace)
+	return prefix + strings.Join(fields, " ") + suffix
+}
@@ -0,0 +1,76 @@
+package index
+
+import (
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+const (
+	minTermLength int = 2
+	maxTermLength int = 64
+)
+
+// Token is a term found in text with its byte position
+type Token struct {
+	Term  string
+	Start int
+	End   int
+}
+
+// Tokenize splits text into lower-cased terms of letters and digits
+func Tokenize(text string) []Token {
+	tokens := []Token{}
+
+	start := -1
+	for idx, r := range text {
+		if unicode.IsLetter(r) || unicode.IsDigit(r) {
+			if start < 0 {
+				start = idx
+			}
+			continue
+		}
+
+		if start >= 0 {
+			if token, ok := makeToken(text, start, idx); ok {
+				tokens = append(tokens, token)
+			}
+			start = -1
+		}
+	}
+
+	if start >= 0 {
+		if token, ok := makeToken(text, start, len(text)); ok {
+			tokens = append(tokens, token)
+		}
+	}
+
+	return tokens
+}
+
+func makeToken(text string, start int, end int) (Token, bool) {
+	length := utf8.RuneCountInString(text[start:end])
+	if length < minTermLength || length > maxTermLength {
+		return Token{}, false
+	}
+
+	return Token{
+		Term:  strings.ToLower(text[start:end]),
+		Start: start,
+		End:   end,
+	}, true
+}
+
+// GetTerms returns distinct terms in text, in order of appearance
+func GetTerms(text string) []string {
+	terms := []string{}
+	seen := map[string]bool{}
+	for _, token := range Tokenize(text) {
+		if !seen[token.Term] {
+			seen[token.Term] = true
+			terms = append(terms, token.Term)
+		}
+	}
+
+	return terms
+}
@@ -16,6 +16,7 @@ import (
 	"github.com/cyverse/go-irodsclient/irods/types"
 	"github.com/cyverse/irods-mcp-server/common"
 	irods_common "github.com/cyverse/irods-mcp-server/irods/common"
+	"github.com/cyverse/irods-mcp-server/irods/index"
 	"github.com/modelcontextprotocol/go-sdk/mcp"
 	log "github.com/sirupsen/logrus"
 )
@@ -24,6 +25,7 @@ type IRODSMCPServer struct {
 	config            *common.Config
 	mcpServer         *mcp.Server
 	irodsfsClientPool *irods_common.IRODSFSClientPool
+	contentIndex      *index.ContentIndex
 	resourceTemplates []ResourceTemplateAPI
 	tools             []ToolAPI
 }
@@ -37,6 +39,19 @@ func NewIRODSMCPServer(svr *mcp.Server, config *common.Config) (*IRODSMCPServer,
 		tools:             []ToolAPI{},
 	}
 
+	if config.IsContentIndexEnabled() {
+		err := config.MakeContentIndexDir()
+		if err != nil {
+			return nil, errors.Wrapf(err, "failed to make content index dir")
+		}
+
+		contentIndex, err := index.NewContentIndex(config.ContentIndexDir)
+		if err != nil {
+			return nil, errors.Wrapf(err, "failed to create content index")
+		}
+		s.contentIndex = contentIndex
+	}
+
 	err := s.registerResourceTemplates()
 	if err != nil {
 		return nil, err
@@ -55,6 +70,10 @@ func (svr *IRODSMCPServer) GetConfig() *common.Config {
 }
 
 func (svr *IRODSMCPServer) Start() error {
+	if svr.contentIndex != nil {
+		go svr.runContentIndexRefresh()
+	}
+
 	if svr.config.Remote {
 		err := svr.startHTTPServer()
 		if err != nil {
@@ -200,6 +219,58 @@ func (svr *IRODSMCPServer) startHTTPServer() error {
 	return nil
 }
 
+// runContentIndexRefresh refreshes the content index with the server account periodically
+func (svr *IRODSMCPServer) runContentIndexRefresh() {
+	interval := svr.config.GetContentIndexRefreshInterval()
+
+	logger := log.WithFields(log.Fields{
+		"dir":      svr.config.ContentIndexDir,
+		"interval": interval,
+	})
+
+	roots := []string{}
+	for _, indexPath := range svr.config.ContentIndexPaths {
+		indexPath = strings.TrimSpace(indexPath)
+		if len(indexPath) > 0 {
+			roots = append(roots, indexPath)
+		}
+	}
+
+	if len(roots) == 0 {
+		roots = append(roots, irods_common.GetSharedPath(svr.config, nil))
+	}
+
+	option := &index.ExtractOption{
+		Notebooks: svr.config.ContentIndexNotebooks,
+		PDFs:      svr.config.ContentIndexPDFs,
+	}
+
+	for {
+		logger.Infof("refreshing content index of %v", roots)
+
+		fs, err := svr.irodsfsClientPool.GetIRODSFSClient(irods_common.GetEmptyIRODSAccount(svr.config))
+		if err != nil {
+			logger.WithError(err).Error("failed to create a irods fs client for content indexing")
+		} else {
+			stats, err := svr.contentIndex.Refresh(fs, roots, option)
+			if err != nil {
+				logger.WithError(err).Error("failed to refresh content index")
+			}
+
+			if stats != nil {
+				logger.Infof("refreshed content index, listed %d, updated %d, unchanged %d, removed %d, failed %d files", stats.Listed, stats.Updated, stats.Unchanged, stats.Removed, stats.Failed)
+			}
+		}
+
+		time.Sleep(interval)
+	}
+}
+
+// GetContentIndex returns the content index, nil if content indexing is disabled
+func (svr *IRODSMCPServer) GetContentIndex() *index.ContentIndex {
+	return svr.contentIndex
+}
+
 func (svr *IRODSMCPServer) getAuthMiddleWare() mcp.Middleware {
 	return func(next mcp.MethodHandler) mcp.MethodHandler {
 		return func(ctx context.Context, method string, req mcp.Request) (mcp.Result, error) {
@@ -278,6 +349,7 @@ func (svr *IRODSMCPServer) GetMCPServer() *mcp.Server {
 func (svr *IRODSMCPServer) registerResourceTemplates() error {
 	// Register the resource templates with the server
 	svr.addResourceTemplate(NewIRODSResourceTemplate(svr))
+	svr.addResourceTemplate(NewSavedQueryResourceTemplate(svr))
 	return nil
 }
 
@@ -289,13 +361,33 @@ func (svr *IRODSMCPServer) registerTools() error {
 	svr.addTool(NewDirectoryTree(svr))
 	svr.addTool(NewSearchFiles(svr))
 	svr.addTool(NewSearchFilesByAVU(svr))
+	svr.addTool(NewSearchByAVU(svr))
+	svr.addTool(NewFind(svr))
+	svr.addTool(NewGenQuery(svr))
+	svr.addTool(NewSaveQuery(svr))
+	svr.addTool(NewListSavedQueries(svr))
+	svr.addTool(NewDeleteSavedQuery(svr))
+	if svr.contentIndex != nil {
+		svr.addTool(NewSearchContent(svr))
+	}
+	svr.addTool(NewGrep(svr))
 	svr.addTool(NewGetFileInfo(svr))
+	svr.addTool(NewChecksum(svr))
 	svr.addTool(NewReadFile(svr))
+	svr.addTool(NewTailFile(svr))
+	svr.addTool(NewPreviewTable(svr))
+	svr.addTool(NewSummarizeBioFile(svr))
 	svr.addTool(NewWriteFile(svr))
+	svr.addTool(NewWriteTextFile(svr))
+	svr.addTool(NewEditFile(svr))
+	svr.addTool(NewDiff(svr))
 	svr.addTool(NewListTickets(svr))
 	svr.addTool(NewGetTicketInfo(svr))
 	svr.addTool(NewMoveFile(svr))
 	svr.addTool(NewCopyFile(svr))
+	svr.addTool(NewSync(svr))
+	svr.addTool(NewArchiveCreate(svr))
+	svr.addTool(NewArchiveExtract(svr))
 	svr.addTool(NewMakeDirectory(svr))
 	svr.addTool(NewDeleteFile(svr))
 	svr.addTool(NewUploadFile(svr))
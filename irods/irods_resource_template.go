@@ -134,6 +134,23 @@ func (r *IRODSResourceTemplate) Handler(ctx context.Context, request *mcp.ReadRe
 
 	// file
 	webdavURL := irods_common.MakeWebdavURL(r.config, sourceEntry.Path, fs.GetAccount())
+	if sourceEntry.Size > irods_common.MaxBase64Size {
+		mimeType := irods_common.DetectMimeTypeWithExtension(irodsPath)
+		if irods_common.IsThumbnailSupported(mimeType) {
+			// image is too large to encode, return a thumbnail
+			thumbnail, err := irods_common.MakeImageThumbnail(fs, sourceEntry, irods_common.ThumbnailMaxDimensionDefault, irods_common.MaxBase64Size)
+			if err == nil {
+				result, err := irods_common.ResourceBlobResult(uri, thumbnail.MIMEType, thumbnail.Data)
+				if err != nil {
+					return nil, err
+				}
+
+				result.Contents[0].Meta = irods_common.MakeThumbnailMeta(thumbnail)
+				return result, nil
+			}
+		}
+	}
+
 	if sourceEntry.Size > irods_common.MaxInlineSize {
 		// file is too large to inline, return a reference to WebDAV URL
 		return irods_common.ResourceTextResult(uri, "text/plain",
@@ -148,8 +165,22 @@ func (r *IRODSResourceTemplate) Handler(ctx context.Context, request *mcp.ReadRe
 
 	mimeType := irods_common.DetectMimeTypeWithContent(irodsPath, 0, content)
 	if irods_common.IsTextFile(mimeType) {
-		// text file
-		return irods_common.ResourceTextResult(uri, mimeType, string(content))
+		// text file, converted to UTF-8
+		chunk, err := irods_common.DecodeTextChunk(content, 0, "", int64(len(content)) >= sourceEntry.Size)
+		if err != nil {
+			chunk, err = irods_common.DecodeTextChunk(content, 0, irods_common.TextEncodingUTF8, int64(len(content)) >= sourceEntry.Size)
+			if err != nil {
+				return nil, errors.Wrapf(err, "failed to decode text of %q", irodsPath)
+			}
+		}
+
+		result, err := irods_common.ResourceTextResult(uri, mimeType, chunk.Text)
+		if err != nil {
+			return nil, err
+		}
+
+		result.Contents[0].Meta = irods_common.MakeTextChunkMeta(chunk)
+		return result, nil
 	} else {
 		// binary file
 		if sourceEntry.Size <= irods_common.MaxBase64Size {
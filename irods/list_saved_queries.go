@@ -0,0 +1,111 @@
+package irods
+
+import (
+	"context"
+
+	"github.com/cockroachdb/errors"
+	"github.com/cyverse/irods-mcp-server/common"
+	irods_common "github.com/cyverse/irods-mcp-server/irods/common"
+	"github.com/cyverse/irods-mcp-server/irods/model"
+	"github.com/google/jsonschema-go/jsonschema"
+	"github.com/modelcontextprotocol/go-sdk/mcp"
+)
+
+const (
+	ListSavedQueriesName = irods_common.IRODSAPIPrefix + "list_saved_queries"
+)
+
+type ListSavedQueries struct {
+	mcpServer *IRODSMCPServer
+	config    *common.Config
+}
+
+func NewListSavedQueries(svr *IRODSMCPServer) ToolAPI {
+	return &ListSavedQueries{
+		mcpServer: svr,
+		config:    svr.GetConfig(),
+	}
+}
+
+func (t *ListSavedQueries) GetName() string {
+	return ListSavedQueriesName
+}
+
+func (t *ListSavedQueries) GetDescription() string {
+	return `List the user's saved queries with their conditions and resource URIs.
+	Read a query's resource URI to get the current list of matching files (data-objects) and directories (collections).`
+}
+
+func (t *ListSavedQueries) GetTool() *mcp.Tool {
+	return &mcp.Tool{
+		Name:        t.GetName(),
+		Description: t.GetDescription(),
+		InputSchema: &jsonschema.Schema{
+			Type:       "object",
+			Properties: map[string]*jsonschema.Schema{},
+		},
+	}
+}
+
+func (t *ListSavedQueries) GetHandler() mcp.ToolHandler {
+	return t.Handler
+}
+
+func (t *ListSavedQueries) GetAccessiblePaths(authValue *common.AuthValue) []string {
+	account, err := t.mcpServer.GetIRODSAccountFromAuthValue(authValue)
+	if err != nil {
+		return []string{}
+	}
+
+	homePath := irods_common.GetHomePath(t.config, account)
+
+	paths := []string{}
+	if !account.IsAnonymousUser() {
+		paths = append(paths, homePath)
+		paths = append(paths, homePath+"/*")
+	}
+
+	return paths
+}
+
+func (t *ListSavedQueries) Handler(ctx context.Context, request *mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	// auth
+	authValue, err := common.GetAuthValue(ctx)
+	if err != nil {
+		outputErr := errors.Wrapf(err, "failed to get auth value")
+		return irods_common.ToolErrorResult(outputErr), nil
+	}
+
+	// make a irods filesystem client
+	fs, err := t.mcpServer.GetIRODSFSClientFromAuthValue(&authValue)
+	if err != nil {
+		outputErr := errors.Wrapf(err, "failed to create a irods fs client")
+		return irods_common.ToolErrorResult(outputErr), nil
+	}
+
+	storagePath, err := getSavedQueriesPath(t.config, fs.GetAccount())
+	if err != nil {
+		return irods_common.ToolErrorResult(err), nil
+	}
+
+	savedQueries, err := loadSavedQueries(fs, storagePath)
+	if err != nil {
+		outputErr := errors.Wrapf(err, "failed to load saved queries")
+		return irods_common.ToolErrorResult(outputErr), nil
+	}
+
+	entries := []model.SavedQueryEntry{}
+	for _, query := range savedQueries.Queries {
+		entries = append(entries, model.SavedQueryEntry{
+			Query:       query,
+			ResourceURI: irods_common.MakeSavedQueryURI(query.Name),
+		})
+	}
+
+	content := model.ListSavedQueriesOutput{
+		Queries:     entries,
+		StoragePath: storagePath,
+	}
+
+	return irods_common.ToolJSONResult(content)
+}
@@ -2,6 +2,7 @@ package irods
 
 import (
 	"context"
+	"fmt"
 
 	"github.com/cockroachdb/errors"
 	irodsclient_fs "github.com/cyverse/go-irodsclient/fs"
@@ -17,7 +18,9 @@ const (
 )
 
 type MakeDirectoryInputArgs struct {
-	Path string `json:"path"`
+	Path        string   `json:"path,omitempty"`
+	Paths       []string `json:"paths,omitempty"`
+	Parallelism int      `json:"parallelism,omitempty"`
 }
 
 type MakeDirectory struct {
@@ -37,7 +40,8 @@ func (t *MakeDirectory) GetName() string {
 }
 
 func (t *MakeDirectory) GetDescription() string {
-	return `Make a new directory (collection).`
+	return `Make a new directory (collection).
+	To make many directories in a single call, give 'paths' instead of 'path'. Batch items are processed in parallel and each item reports its own result.`
 }
 
 func (t *MakeDirectory) GetTool() *mcp.Tool {
@@ -51,8 +55,13 @@ func (t *MakeDirectory) GetTool() *mcp.Tool {
 					Type:        "string",
 					Description: "The path to the new directory to create.",
 				},
+				"paths": {
+					Type:        "array",
+					Items:       &jsonschema.Schema{Type: "string"},
+					Description: fmt.Sprintf("The list of directory paths to create as a batch, used instead of 'path'. Maximum is %d items.", irods_common.MaxBatchItems),
+				},
+				"parallelism": getBatchParallelismSchema(),
 			},
-			Required: []string{"path"},
 		},
 	}
 }
@@ -105,22 +114,53 @@ func (t *MakeDirectory) Handler(ctx context.Context, request *mcp.CallToolReques
 		return irods_common.ToolErrorResult(outputErr), nil
 	}
 
-	irodsPath := irods_common.MakeIRODSPath(t.config, fs.GetAccount(), args.Path)
+	// batch
+	if len(args.Paths) > 0 {
+		if len(args.Path) > 0 {
+			outputErr := errors.Newf("paths cannot be given together with path")
+			return irods_common.ToolErrorResult(outputErr), nil
+		}
 
-	// check permission
-	if !irods_common.IsAccessAllowed(irodsPath, t.GetAccessiblePaths(&authValue)) {
-		outputErr := errors.Newf("%q request is not permitted for path %q", t.GetName(), irodsPath)
+		err = checkBatchSize(len(args.Paths))
+		if err != nil {
+			return irods_common.ToolErrorResult(err), nil
+		}
+
+		content := runBatch(ctx, len(args.Paths), args.Parallelism, func(idx int) (any, error) {
+			return t.makeDirectoryWithCheck(fs, &authValue, args.Paths[idx])
+		})
+
+		return irods_common.ToolJSONResult(*content)
+	}
+
+	if len(args.Path) == 0 {
+		outputErr := errors.Newf("either path or paths must be given")
 		return irods_common.ToolErrorResult(outputErr), nil
 	}
 
+	content, err := t.makeDirectoryWithCheck(fs, &authValue, args.Path)
+	if err != nil {
+		return irods_common.ToolErrorResult(err), nil
+	}
+
+	return irods_common.ToolJSONResult(*content)
+}
+
+func (t *MakeDirectory) makeDirectoryWithCheck(fs *irodsclient_fs.FileSystem, authValue *common.AuthValue, path string) (*model.MakeDirectoryOutput, error) {
+	irodsPath := irods_common.MakeIRODSPath(t.config, fs.GetAccount(), path)
+
+	// check permission
+	if !irods_common.IsAccessAllowed(irodsPath, t.GetAccessiblePaths(authValue)) {
+		return nil, errors.Newf("%q request is not permitted for path %q", t.GetName(), irodsPath)
+	}
+
 	// Make directory
 	content, err := t.makeDirectory(fs, irodsPath)
 	if err != nil {
-		outputErr := errors.Wrapf(err, "failed to make directory (collection) for %q", irodsPath)
-		return irods_common.ToolErrorResult(outputErr), nil
+		return nil, errors.Wrapf(err, "failed to make directory (collection) for %q", irodsPath)
 	}
 
-	return irods_common.ToolJSONResult(*content)
+	return content, nil
 }
 
 func (t *MakeDirectory) makeDirectory(fs *irodsclient_fs.FileSystem, path string) (*model.MakeDirectoryOutput, error) {
@@ -1,6 +1,8 @@
 package model
 
 import (
+	"time"
+
 	irodsclient_fs "github.com/cyverse/go-irodsclient/fs"
 	irodsclient_types "github.com/cyverse/go-irodsclient/irods/types"
 )
@@ -26,12 +28,149 @@ type ListDirectoryOutput struct {
 type SearchFilesOutput struct {
 	SearchPath      string            `json:"search_path"`
 	MatchingEntries []EntryWithAccess `json:"matching_entries"`
+	Total           int               `json:"total"`
+	Offset          int               `json:"offset"`
+	Limit           int               `json:"limit"`
+	Truncated       bool              `json:"truncated"`
+	NextCursor      string            `json:"next_cursor,omitempty"`
+}
+
+type AVUCondition struct {
+	Attribute  string         `json:"attribute,omitempty"`
+	Operator   string         `json:"operator,omitempty"`
+	Value      string         `json:"value,omitempty"`
+	Values     []string       `json:"values,omitempty"`
+	Unit       *string        `json:"unit,omitempty"`
+	Numeric    bool           `json:"numeric,omitempty"`
+	Match      string         `json:"match,omitempty"`
+	Conditions []AVUCondition `json:"conditions,omitempty"`
 }
 
 type SearchFilesByAVUOutput struct {
-	SearchAttribute string            `json:"search_attribute"`
-	SearchValue     string            `json:"search_value"`
+	SearchAttribute  string            `json:"search_attribute,omitempty"`
+	SearchValue      string            `json:"search_value,omitempty"`
+	SearchConditions *AVUCondition     `json:"search_conditions,omitempty"`
+	SearchPath       string            `json:"search_path,omitempty"`
+	EntityType       string            `json:"entity_type"`
+	MatchingEntries  []EntryWithAccess `json:"matching_entries"`
+	Total            int               `json:"total"`
+	Offset           int               `json:"offset"`
+	Limit            int               `json:"limit"`
+	NextCursor       string            `json:"next_cursor,omitempty"`
+}
+
+type AVUResourceEntry struct {
+	Name  string `json:"name"`
+	Zone  string `json:"zone"`
+	Type  string `json:"type"`
+	Class string `json:"class,omitempty"`
+	AVUs  []AVU  `json:"avus"`
+}
+
+type AVUUserEntry struct {
+	Name string `json:"name"`
+	Zone string `json:"zone"`
+	Type string `json:"type"`
+	AVUs []AVU  `json:"avus"`
+}
+
+type SearchByAVUOutput struct {
+	TargetType        string             `json:"target_type"`
+	SearchConditions  *AVUCondition      `json:"search_conditions"`
+	SearchPath        string             `json:"search_path,omitempty"`
+	EntityType        string             `json:"entity_type,omitempty"`
+	MatchingEntries   []EntryWithAccess  `json:"matching_entries,omitempty"`
+	MatchingResources []AVUResourceEntry `json:"matching_resources,omitempty"`
+	MatchingUsers     []AVUUserEntry     `json:"matching_users,omitempty"`
+	Total             int                `json:"total"`
+	Offset            int                `json:"offset"`
+	Limit             int                `json:"limit"`
+	NextCursor        string             `json:"next_cursor,omitempty"`
+}
+
+type FindOutput struct {
+	Path            string            `json:"path"`
+	MatchingEntries []EntryWithAccess `json:"matching_entries"`
+	Total           int               `json:"total"`
+	Offset          int               `json:"offset"`
+	Limit           int               `json:"limit"`
+	NextCursor      string            `json:"next_cursor,omitempty"`
+}
+
+type GenQueryOutput struct {
+	Path       string     `json:"path,omitempty"`
+	Roots      []string   `json:"roots"`
+	Columns    []string   `json:"columns"`
+	Rows       [][]string `json:"rows"`
+	Total      int        `json:"total"`
+	Offset     int        `json:"offset"`
+	Limit      int        `json:"limit"`
+	NextCursor string     `json:"next_cursor,omitempty"`
+}
+
+type SearchContentHit struct {
+	Entry       *irodsclient_fs.Entry `json:"entry_info"`
+	ResourceURI string                `json:"resource_uri"`
+	WebDAVURI   string                `json:"webdav_uri"`
+	Score       float64               `json:"score"`
+	Snippets    []string              `json:"snippets"`
+}
+
+type SearchContentOutput struct {
+	Query        string             `json:"query"`
+	Path         string             `json:"path,omitempty"`
+	Hits         []SearchContentHit `json:"hits"`
+	Offset       int                `json:"offset"`
+	Limit        int                `json:"limit"`
+	Truncated    bool               `json:"truncated"`
+	NextCursor   string             `json:"next_cursor,omitempty"`
+	IndexedFiles int                `json:"indexed_files"`
+	IndexedAt    *time.Time         `json:"indexed_at,omitempty"`
+}
+
+type SavedQuery struct {
+	Name        string        `json:"name"`
+	Description string        `json:"description,omitempty"`
+	Conditions  *AVUCondition `json:"conditions"`
+	NamePattern string        `json:"name_pattern,omitempty"`
+	Paths       []string      `json:"paths,omitempty"`
+	EntityType  string        `json:"entity_type,omitempty"`
+	CreatedAt   time.Time     `json:"created_at"`
+	ModifiedAt  time.Time     `json:"modified_at"`
+}
+
+type SavedQueries struct {
+	Queries []SavedQuery `json:"queries"`
+}
+
+type SavedQueryEntry struct {
+	Query       SavedQuery `json:"query"`
+	ResourceURI string     `json:"resource_uri"`
+}
+
+type SaveQueryOutput struct {
+	Query       SavedQuery `json:"query"`
+	ResourceURI string     `json:"resource_uri"`
+	Replaced    bool       `json:"replaced"`
+	StoragePath string     `json:"storage_path"`
+}
+
+type ListSavedQueriesOutput struct {
+	Queries     []SavedQueryEntry `json:"queries"`
+	StoragePath string            `json:"storage_path"`
+}
+
+type DeleteSavedQueryOutput struct {
+	Name        string `json:"name"`
+	StoragePath string `json:"storage_path"`
+}
+
+type SavedQueryResultOutput struct {
+	Query           SavedQuery        `json:"query"`
 	MatchingEntries []EntryWithAccess `json:"matching_entries"`
+	Total           int               `json:"total"`
+	Truncated       bool              `json:"truncated"`
+	QueriedAt       time.Time         `json:"queried_at"`
 }
 
 type GetFileInfoOutput struct {
@@ -44,10 +183,298 @@ type GetFileInfoOutput struct {
 	AVUs              []*irodsclient_types.IRODSMeta            `json:"avus,omitempty"`
 }
 
+type ReadFileLinesOutput struct {
+	Path        string `json:"path"`
+	ResourceURI string `json:"resource_uri"`
+	StartLine   int64  `json:"start_line"`
+	EndLine     int64  `json:"end_line"`
+	TotalLines  *int64 `json:"total_lines,omitempty"`
+	Truncated   bool   `json:"truncated,omitempty"`
+	Encoding    string `json:"encoding,omitempty"`
+	Content     string `json:"content"`
+}
+
+type ReadStreamLinesOutput struct {
+	Path        string `json:"path"`
+	ResourceURI string `json:"resource_uri"`
+	Member      string `json:"member,omitempty"`
+	StartLine   int64  `json:"start_line"`
+	EndLine     int64  `json:"end_line"`
+	TotalLines  *int64 `json:"total_lines,omitempty"`
+	Truncated   bool   `json:"truncated,omitempty"`
+	Encoding    string `json:"encoding,omitempty"`
+	Content     string `json:"content"`
+}
+
+type ArchiveMemberEntry struct {
+	Name       string    `json:"name"`
+	Type       string    `json:"type"`
+	Size       int64     `json:"size"`
+	ModifyTime time.Time `json:"modify_time"`
+}
+
+type ArchiveMembersOutput struct {
+	Path        string               `json:"path"`
+	ResourceURI string               `json:"resource_uri"`
+	Format      string               `json:"format"`
+	Members     []ArchiveMemberEntry `json:"members"`
+	Truncated   bool                 `json:"truncated,omitempty"`
+}
+
+type TailFileOutput struct {
+	Path        string `json:"path"`
+	ResourceURI string `json:"resource_uri"`
+	Status      string `json:"status"`
+	Offset      int64  `json:"offset"`
+	Size        int64  `json:"size"`
+	Content     string `json:"content"`
+	Truncated   bool   `json:"truncated,omitempty"`
+	Cursor      string `json:"cursor"`
+}
+
 type WriteFileOutput struct {
-	Path         string `json:"path"`
-	Offset       int64  `json:"offset"`
-	BytesWritten int    `json:"bytes_written"`
+	Path         string    `json:"path"`
+	Mode         string    `json:"mode"`
+	Offset       int64     `json:"offset"`
+	BytesWritten int       `json:"bytes_written"`
+	Size         int64     `json:"size"`
+	Checksum     string    `json:"checksum,omitempty"`
+	ModifyTime   time.Time `json:"modify_time"`
+}
+
+type EditFileOutput struct {
+	Path       string    `json:"path"`
+	Diff       string    `json:"diff"`
+	DryRun     bool      `json:"dry_run,omitempty"`
+	Size       int64     `json:"size"`
+	Checksum   string    `json:"checksum,omitempty"`
+	ModifyTime time.Time `json:"modify_time"`
+}
+
+type GrepMatch struct {
+	Path        string   `json:"path"`
+	ResourceURI string   `json:"resource_uri"`
+	LineNumber  int64    `json:"line_number"`
+	Line        string   `json:"line"`
+	Before      []string `json:"before,omitempty"`
+	After       []string `json:"after,omitempty"`
+}
+
+type GrepOutput struct {
+	Path         string      `json:"path"`
+	Pattern      string      `json:"pattern"`
+	Matches      []GrepMatch `json:"matches"`
+	FilesScanned int         `json:"files_scanned"`
+	FilesSkipped int         `json:"files_skipped"`
+	BytesScanned int64       `json:"bytes_scanned"`
+	Truncated    bool        `json:"truncated,omitempty"`
+}
+
+type TableColumn struct {
+	Name              string   `json:"name"`
+	Type              string   `json:"type"`
+	Count             int64    `json:"count"`
+	Nulls             int64    `json:"nulls"`
+	Distinct          int      `json:"distinct"`
+	DistinctTruncated bool     `json:"distinct_truncated,omitempty"`
+	Min               string   `json:"min,omitempty"`
+	Max               string   `json:"max,omitempty"`
+	Mean              *float64 `json:"mean,omitempty"`
+}
+
+type PreviewTableOutput struct {
+	Path          string        `json:"path"`
+	ResourceURI   string        `json:"resource_uri"`
+	Format        string        `json:"format"`
+	Delimiter     string        `json:"delimiter,omitempty"`
+	HasHeader     bool          `json:"has_header"`
+	Columns       []TableColumn `json:"columns"`
+	Rows          [][]string    `json:"rows"`
+	RowsScanned   int64         `json:"rows_scanned"`
+	RowCount      int64         `json:"row_count"`
+	RowCountExact bool          `json:"row_count_exact"`
+	BytesScanned  int64         `json:"bytes_scanned"`
+	Truncated     bool          `json:"truncated,omitempty"`
+}
+
+type BioDistribution struct {
+	Min    float64 `json:"min"`
+	Max    float64 `json:"max"`
+	Mean   float64 `json:"mean"`
+	P10    float64 `json:"p10"`
+	Median float64 `json:"median"`
+	P90    float64 `json:"p90"`
+}
+
+type BioSequenceStats struct {
+	Lengths    *BioDistribution `json:"lengths"`
+	N50        int64            `json:"n50"`
+	TotalBases int64            `json:"total_bases"`
+	GCContent  float64          `json:"gc_content"`
+	NContent   float64          `json:"n_content"`
+}
+
+type BioQualityStats struct {
+	Encoding          string           `json:"encoding"`
+	MeanQuality       float64          `json:"mean_quality"`
+	Q20Fraction       float64          `json:"q20_fraction"`
+	Q30Fraction       float64          `json:"q30_fraction"`
+	ReadMeanQualities *BioDistribution `json:"read_mean_qualities,omitempty"`
+}
+
+type BioVariantStats struct {
+	SNPs   int64 `json:"snps"`
+	Indels int64 `json:"indels"`
+	MNPs   int64 `json:"mnps"`
+	Others int64 `json:"others"`
+	Passed int64 `json:"passed"`
+}
+
+type BioAlignmentStats struct {
+	Mapped             int64    `json:"mapped"`
+	Unmapped           int64    `json:"unmapped"`
+	Secondary          int64    `json:"secondary"`
+	Supplementary      int64    `json:"supplementary"`
+	MeanMappingQuality float64  `json:"mean_mapping_quality"`
+	SortOrder          string   `json:"sort_order,omitempty"`
+	Programs           []string `json:"programs,omitempty"`
+}
+
+type BioContig struct {
+	Name    string `json:"name"`
+	Length  int64  `json:"length,omitempty"`
+	Records int64  `json:"records,omitempty"`
+}
+
+type SummarizeBioFileOutput struct {
+	Path             string             `json:"path"`
+	ResourceURI      string             `json:"resource_uri"`
+	Format           string             `json:"format"`
+	FormatVersion    string             `json:"format_version,omitempty"`
+	Compression      string             `json:"compression,omitempty"`
+	Size             int64              `json:"size"`
+	Records          int64              `json:"records"`
+	RecordCountExact bool               `json:"record_count_exact"`
+	RecordsScanned   int64              `json:"records_scanned"`
+	BytesScanned     int64              `json:"bytes_scanned"`
+	SequenceNames    []string           `json:"sequence_names,omitempty"`
+	SampleCount      int                `json:"sample_count,omitempty"`
+	Samples          []string           `json:"samples,omitempty"`
+	Contigs          []BioContig        `json:"contigs,omitempty"`
+	ContigsTruncated bool               `json:"contigs_truncated,omitempty"`
+	Sequences        *BioSequenceStats  `json:"sequences,omitempty"`
+	Quality          *BioQualityStats   `json:"quality,omitempty"`
+	Variants         *BioVariantStats   `json:"variants,omitempty"`
+	Alignments       *BioAlignmentStats `json:"alignments,omitempty"`
+	Truncated        bool               `json:"truncated,omitempty"`
+}
+
+type ReplicaChecksum struct {
+	Number            int64  `json:"number"`
+	ResourceName      string `json:"resource_name"`
+	ResourceHierarchy string `json:"resource_hierarchy,omitempty"`
+	Status            string `json:"status"`
+	Checksum          string `json:"checksum,omitempty"`
+	MatchesCatalog    bool   `json:"matches_catalog"`
+	Verified          *bool  `json:"verified,omitempty"`
+	Error             string `json:"error,omitempty"`
+}
+
+type ChecksumOutput struct {
+	Path             string            `json:"path"`
+	Size             int64             `json:"size"`
+	Checksum         string            `json:"checksum"`
+	Computed         bool              `json:"computed,omitempty"`
+	Replicas         []ReplicaChecksum `json:"replicas"`
+	AllReplicasMatch bool              `json:"all_replicas_match"`
+	LocalPath        string            `json:"local_path,omitempty"`
+	LocalChecksum    string            `json:"local_checksum,omitempty"`
+	LocalMatch       *bool             `json:"local_match,omitempty"`
+}
+
+type DiffFilesOutput struct {
+	Path       string `json:"path"`
+	TargetPath string `json:"target_path,omitempty"`
+	Identical  bool   `json:"identical"`
+	Diff       string `json:"diff,omitempty"`
+	Truncated  bool   `json:"truncated,omitempty"`
+}
+
+type DiffEntry struct {
+	RelativePath     string     `json:"relative_path"`
+	Type             string     `json:"type"`
+	Size             int64      `json:"size,omitempty"`
+	TargetSize       int64      `json:"target_size,omitempty"`
+	ModifyTime       *time.Time `json:"modify_time,omitempty"`
+	TargetModifyTime *time.Time `json:"target_modify_time,omitempty"`
+	Reasons          []string   `json:"reasons,omitempty"`
+}
+
+type DiffCollectionsOutput struct {
+	Path       string      `json:"path"`
+	TargetPath string      `json:"target_path"`
+	Added      []DiffEntry `json:"added"`
+	Removed    []DiffEntry `json:"removed"`
+	Changed    []DiffEntry `json:"changed"`
+	Unchanged  int         `json:"unchanged"`
+	Truncated  bool        `json:"truncated,omitempty"`
+}
+
+type SyncAction struct {
+	RelativePath string `json:"relative_path"`
+	Action       string `json:"action"`
+	Type         string `json:"type"`
+	Size         int64  `json:"size,omitempty"`
+	Error        string `json:"error,omitempty"`
+}
+
+type SyncOutput struct {
+	SourcePath      string       `json:"source_path"`
+	DestinationPath string       `json:"destination_path"`
+	DryRun          bool         `json:"dry_run,omitempty"`
+	Compare         string       `json:"compare"`
+	Actions         []SyncAction `json:"actions"`
+	CreatedDirs     int          `json:"created_dirs"`
+	Copied          int          `json:"copied"`
+	Updated         int          `json:"updated"`
+	Deleted         int          `json:"deleted"`
+	Unchanged       int          `json:"unchanged"`
+	Failed          int          `json:"failed"`
+	BytesCopied     int64        `json:"bytes_copied"`
+	Truncated       bool         `json:"truncated,omitempty"`
+}
+
+type BatchItemResult struct {
+	Index   int    `json:"index"`
+	Success bool   `json:"success"`
+	Error   string `json:"error,omitempty"`
+	Output  any    `json:"output,omitempty"`
+}
+
+type BatchOutput struct {
+	Results   []BatchItemResult `json:"results"`
+	Succeeded int               `json:"succeeded"`
+	Failed    int               `json:"failed"`
+}
+
+type ArchiveCreateOutput struct {
+	Path       string `json:"path"`
+	SourcePath string `json:"source_path"`
+	Format     string `json:"format"`
+	Files      int    `json:"files"`
+	Dirs       int    `json:"dirs"`
+	TotalSize  int64  `json:"total_size"`
+	Size       int64  `json:"size"`
+}
+
+type ArchiveExtractOutput struct {
+	Path            string   `json:"path"`
+	DestinationPath string   `json:"destination_path"`
+	Format          string   `json:"format"`
+	Files           int      `json:"files"`
+	Dirs            int      `json:"dirs"`
+	TotalSize       int64    `json:"total_size"`
+	SkippedPaths    []string `json:"skipped_paths,omitempty"`
 }
 
 type MoveFileOutput struct {
@@ -57,11 +484,19 @@ type MoveFileOutput struct {
 	NewEntryInfo *irodsclient_fs.Entry `json:"new_entry_info"`
 }
 
+type CopyFailure struct {
+	SourcePath      string `json:"source_path"`
+	DestinationPath string `json:"destination_path"`
+	Error           string `json:"error"`
+}
+
 type CopyFileOutput struct {
 	SourcePath          string                  `json:"source_path"`
 	DestinationPath     string                  `json:"destination_path"`
 	SourceEntryInfoList []*irodsclient_fs.Entry `json:"source_entry_info_list"`
 	CopiedEntryInfoList []*irodsclient_fs.Entry `json:"copied_entry_info_list"`
+	SkippedPaths        []string                `json:"skipped_paths,omitempty"`
+	FailedEntries       []CopyFailure           `json:"failed_entries,omitempty"`
 }
 
 type MakeDirectoryOutput struct {
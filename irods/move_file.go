@@ -2,6 +2,7 @@ package irods
 
 import (
 	"context"
+	"fmt"
 
 	"github.com/cockroachdb/errors"
 	irodsclient_fs "github.com/cyverse/go-irodsclient/fs"
@@ -16,11 +17,18 @@ const (
 	MoveFileName = irods_common.IRODSAPIPrefix + "move_file"
 )
 
-type MoveFileInputArgs struct {
+type MoveFileItem struct {
 	OldPath string `json:"old_path"`
 	NewPath string `json:"new_path"`
 }
 
+type MoveFileInputArgs struct {
+	OldPath     string         `json:"old_path,omitempty"`
+	NewPath     string         `json:"new_path,omitempty"`
+	Items       []MoveFileItem `json:"items,omitempty"`
+	Parallelism int            `json:"parallelism,omitempty"`
+}
+
 type MoveFile struct {
 	mcpServer *IRODSMCPServer
 	config    *common.Config
@@ -38,7 +46,8 @@ func (t *MoveFile) GetName() string {
 }
 
 func (t *MoveFile) GetDescription() string {
-	return `Move a file (data-object) or directory (collection) to a new location.`
+	return `Move a file (data-object) or directory (collection) to a new location.
+	To move many files and directories in a single call, give 'items' instead of 'old_path' and 'new_path'. Batch items are processed in parallel and each item reports its own result.`
 }
 
 func (t *MoveFile) GetTool() *mcp.Tool {
@@ -56,8 +65,26 @@ func (t *MoveFile) GetTool() *mcp.Tool {
 					Type:        "string",
 					Description: "The new, complete path to move the file (data-object) or directory (collection) to, including its new name. The path must not already exist.",
 				},
+				"items": {
+					Type:        "array",
+					Description: fmt.Sprintf("The list of moves to run as a batch, used instead of 'old_path' and 'new_path'. Maximum is %d items.", irods_common.MaxBatchItems),
+					Items: &jsonschema.Schema{
+						Type: "object",
+						Properties: map[string]*jsonschema.Schema{
+							"old_path": {
+								Type:        "string",
+								Description: "The old path to the file (data-object) or directory (collection).",
+							},
+							"new_path": {
+								Type:        "string",
+								Description: "The new, complete path to move to. The path must not already exist.",
+							},
+						},
+						Required: []string{"old_path", "new_path"},
+					},
+				},
+				"parallelism": getBatchParallelismSchema(),
 			},
-			Required: []string{"old_path", "new_path"},
 		},
 	}
 }
@@ -110,33 +137,62 @@ func (t *MoveFile) Handler(ctx context.Context, request *mcp.CallToolRequest) (*
 		return irods_common.ToolErrorResult(outputErr), nil
 	}
 
-	irodsOldPath := irods_common.MakeIRODSPath(t.config, fs.GetAccount(), args.OldPath)
-	irodsNewPath := irods_common.MakeIRODSPath(t.config, fs.GetAccount(), args.NewPath)
+	// batch
+	if len(args.Items) > 0 {
+		if len(args.OldPath) > 0 || len(args.NewPath) > 0 {
+			outputErr := errors.Newf("items cannot be given together with old_path and new_path")
+			return irods_common.ToolErrorResult(outputErr), nil
+		}
 
-	// check permission
-	if !irods_common.IsAccessAllowed(irodsOldPath, t.GetAccessiblePaths(&authValue)) {
-		outputErr := errors.Newf("%q request is not permitted for path %q", t.GetName(), irodsOldPath)
-		return irods_common.ToolErrorResult(outputErr), nil
+		err = checkBatchSize(len(args.Items))
+		if err != nil {
+			return irods_common.ToolErrorResult(err), nil
+		}
+
+		content := runBatch(ctx, len(args.Items), args.Parallelism, func(idx int) (any, error) {
+			return t.moveFileWithCheck(fs, &authValue, args.Items[idx].OldPath, args.Items[idx].NewPath)
+		})
+
+		return irods_common.ToolJSONResult(*content)
 	}
-	if !irods_common.IsAccessAllowed(irodsNewPath, t.GetAccessiblePaths(&authValue)) {
-		outputErr := errors.Newf("%q request is not permitted for path %q", t.GetName(), irodsNewPath)
+
+	if len(args.OldPath) == 0 || len(args.NewPath) == 0 {
+		outputErr := errors.Newf("either old_path and new_path, or items must be given")
 		return irods_common.ToolErrorResult(outputErr), nil
 	}
 
+	content, err := t.moveFileWithCheck(fs, &authValue, args.OldPath, args.NewPath)
+	if err != nil {
+		return irods_common.ToolErrorResult(err), nil
+	}
+
+	return irods_common.ToolJSONResult(*content)
+}
+
+func (t *MoveFile) moveFileWithCheck(fs *irodsclient_fs.FileSystem, authValue *common.AuthValue, oldPath string, newPath string) (*model.MoveFileOutput, error) {
+	irodsOldPath := irods_common.MakeIRODSPath(t.config, fs.GetAccount(), oldPath)
+	irodsNewPath := irods_common.MakeIRODSPath(t.config, fs.GetAccount(), newPath)
+
+	// check permission
+	if !irods_common.IsAccessAllowed(irodsOldPath, t.GetAccessiblePaths(authValue)) {
+		return nil, errors.Newf("%q request is not permitted for path %q", t.GetName(), irodsOldPath)
+	}
+	if !irods_common.IsAccessAllowed(irodsNewPath, t.GetAccessiblePaths(authValue)) {
+		return nil, errors.Newf("%q request is not permitted for path %q", t.GetName(), irodsNewPath)
+	}
+
 	// Move file
 	sourceEntry, err := fs.Stat(irodsOldPath)
 	if err != nil {
-		outputErr := errors.Wrapf(err, "failed to stat file or directory info for %q", irodsOldPath)
-		return irods_common.ToolErrorResult(outputErr), nil
+		return nil, errors.Wrapf(err, "failed to stat file or directory info for %q", irodsOldPath)
 	}
 
 	content, err := t.moveFile(fs, sourceEntry, irodsNewPath)
 	if err != nil {
-		outputErr := errors.Wrapf(err, "failed to move file (data-object) or directory (collection) from %q to %q", irodsOldPath, irodsNewPath)
-		return irods_common.ToolErrorResult(outputErr), nil
+		return nil, errors.Wrapf(err, "failed to move file (data-object) or directory (collection) from %q to %q", irodsOldPath, irodsNewPath)
 	}
 
-	return irods_common.ToolJSONResult(*content)
+	return content, nil
 }
 
 func (t *MoveFile) moveFile(fs *irodsclient_fs.FileSystem, sourceEntry *irodsclient_fs.Entry, newPath string) (*model.MoveFileOutput, error) {
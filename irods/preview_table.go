@@ -0,0 +1,574 @@
+package irods
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/cockroachdb/errors"
+	irodsclient_fs "github.com/cyverse/go-irodsclient/fs"
+	"github.com/cyverse/irods-mcp-server/common"
+	irods_common "github.com/cyverse/irods-mcp-server/irods/common"
+	"github.com/cyverse/irods-mcp-server/irods/model"
+	"github.com/google/jsonschema-go/jsonschema"
+	"github.com/modelcontextprotocol/go-sdk/mcp"
+)
+
+const (
+	PreviewTableName = irods_common.IRODSAPIPrefix + "preview_table"
+
+	previewTableRowsDefault     int   = 20
+	previewTableMaxRows         int   = 500
+	previewTableMaxBytesDefault int64 = 16 * 1024 * 1024  // 16MB
+	previewTableMaxBytes        int64 = 256 * 1024 * 1024 // 256MB
+	previewTableSniffSize       int   = 64 * 1024         // 64KB
+	previewTableReadSize        int   = 1024 * 1024       // 1MB
+	previewTableMaxCellLength   int   = 256
+	previewTableMaxColumns      int   = 1000
+	previewTableHeaderSniffRows int   = 20
+)
+
+type PreviewTableInputArgs struct {
+	Path      string `json:"path"`
+	Rows      int    `json:"rows,omitempty"`
+	Format    string `json:"format,omitempty"`
+	Delimiter string `json:"delimiter,omitempty"`
+	HasHeader *bool  `json:"has_header,omitempty"`
+	MaxBytes  int64  `json:"max_bytes,omitempty"`
+}
+
+type PreviewTable struct {
+	mcpServer *IRODSMCPServer
+	config    *common.Config
+}
+
+func NewPreviewTable(svr *IRODSMCPServer) ToolAPI {
+	return &PreviewTable{
+		mcpServer: svr,
+		config:    svr.GetConfig(),
+	}
+}
+
+func (t *PreviewTable) GetName() string {
+	return PreviewTableName
+}
+
+func (t *PreviewTable) GetDescription() string {
+	return `Preview a tabular data file (data-object) in CSV, TSV, or JSON lines format with the specified path.
+	The specified path must be an iRODS path. The delimiter, header, and column types are detected automatically. Gzip, bzip2 and zstd compressed files are decompressed on the fly.
+	Returns the columns with inferred types and basic statistics, the first rows, and an approximate row count in JSON format.
+	Statistics are computed over the rows read within the byte budget.`
+}
+
+func (t *PreviewTable) GetTool() *mcp.Tool {
+	formats := []interface{}{}
+	for _, format := range irods_common.GetTableFormats() {
+		formats = append(formats, string(format))
+	}
+
+	return &mcp.Tool{
+		Name:        t.GetName(),
+		Description: t.GetDescription(),
+		InputSchema: &jsonschema.Schema{
+			Type: "object",
+			Properties: map[string]*jsonschema.Schema{
+				"path": {
+					Type:        "string",
+					Description: "The path to the file (data-object) to preview.",
+				},
+				"rows": {
+					Type:        "number",
+					Description: fmt.Sprintf("The number of rows to return. Maximum is %d. Default is %d.", previewTableMaxRows, previewTableRowsDefault),
+					Default:     json.RawMessage(fmt.Sprintf("%d", previewTableRowsDefault)),
+				},
+				"format": {
+					Type:        "string",
+					Enum:        formats,
+					Description: "The format of the file. Default is detected from the extension and the content.",
+				},
+				"delimiter": {
+					Type:        "string",
+					Description: "The field delimiter of CSV or TSV files, such as ',', ';', '|', or '\\t' for tab. Default is detected from the content.",
+				},
+				"has_header": {
+					Type:        "boolean",
+					Description: "Set to true if the first row is a header, false if not. Default is detected from the content. Ignored for JSON lines.",
+				},
+				"max_bytes": {
+					Type:        "number",
+					Description: fmt.Sprintf("The maximum number of bytes to read from the file. Maximum is %d bytes. Default is %d bytes.", previewTableMaxBytes, previewTableMaxBytesDefault),
+					Default:     json.RawMessage(fmt.Sprintf("%d", previewTableMaxBytesDefault)),
+				},
+			},
+			Required: []string{"path"},
+		},
+	}
+}
+
+func (t *PreviewTable) GetHandler() mcp.ToolHandler {
+	return t.Handler
+}
+
+func (t *PreviewTable) GetAccessiblePaths(authValue *common.AuthValue) []string {
+	account, err := t.mcpServer.GetIRODSAccountFromAuthValue(authValue)
+	if err != nil {
+		return []string{}
+	}
+
+	homePath := irods_common.GetHomePath(t.config, account)
+	sharedPath := irods_common.GetSharedPath(t.config, account)
+
+	paths := []string{
+		sharedPath + "/*",
+	}
+
+	if !account.IsAnonymousUser() {
+		paths = append(paths, homePath)
+		paths = append(paths, homePath+"/*")
+	}
+
+	return paths
+}
+
+func (t *PreviewTable) Handler(ctx context.Context, request *mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	// arguments
+	args := PreviewTableInputArgs{}
+	err := irods_common.MarshalInputArguments(t.GetTool(), request, &args)
+	if err != nil {
+		outputErr := errors.Wrapf(err, "failed to marshal input arguments")
+		return irods_common.ToolErrorResult(outputErr), nil
+	}
+
+	if args.Rows <= 0 {
+		args.Rows = previewTableRowsDefault
+	} else if args.Rows > previewTableMaxRows {
+		args.Rows = previewTableMaxRows
+	}
+
+	if args.MaxBytes <= 0 {
+		args.MaxBytes = previewTableMaxBytesDefault
+	} else if args.MaxBytes > previewTableMaxBytes {
+		args.MaxBytes = previewTableMaxBytes
+	}
+
+	delimiter, err := t.parseDelimiter(args.Delimiter)
+	if err != nil {
+		return irods_common.ToolErrorResult(err), nil
+	}
+
+	// auth
+	authValue, err := common.GetAuthValue(ctx)
+	if err != nil {
+		outputErr := errors.Wrapf(err, "failed to get auth value")
+		return irods_common.ToolErrorResult(outputErr), nil
+	}
+
+	// make a irods filesystem client
+	fs, err := t.mcpServer.GetIRODSFSClientFromAuthValue(&authValue)
+	if err != nil {
+		outputErr := errors.Wrapf(err, "failed to create a irods fs client")
+		return irods_common.ToolErrorResult(outputErr), nil
+	}
+
+	irodsPath := irods_common.MakeIRODSPath(t.config, fs.GetAccount(), args.Path)
+
+	// check permission
+	if !irods_common.IsAccessAllowed(irodsPath, t.GetAccessiblePaths(&authValue)) {
+		outputErr := errors.Newf("%q request is not permitted for path %q", t.GetName(), irodsPath)
+		return irods_common.ToolErrorResult(outputErr), nil
+	}
+
+	entry, err := fs.Stat(irodsPath)
+	if err != nil {
+		outputErr := errors.Wrapf(err, "failed to stat file info for %q", irodsPath)
+		return irods_common.ToolErrorResult(outputErr), nil
+	}
+
+	if entry.IsDir() {
+		outputErr := errors.Newf("path %q is a directory (collection)", irodsPath)
+		return irods_common.ToolErrorResult(outputErr), nil
+	}
+
+	content, err := t.previewTable(fs, entry, delimiter, &args)
+	if err != nil {
+		outputErr := errors.Wrapf(err, "failed to preview table %q", irodsPath)
+		return irods_common.ToolErrorResult(outputErr), nil
+	}
+
+	return irods_common.ToolJSONResult(*content)
+}
+
+func (t *PreviewTable) parseDelimiter(delimiter string) (rune, error) {
+	switch strings.ToLower(delimiter) {
+	case "":
+		return 0, nil
+	case "\\t", "tab":
+		return '\t', nil
+	}
+
+	r, size := utf8.DecodeRuneInString(delimiter)
+	if size != len(delimiter) || r == utf8.RuneError || r == '"' || r == '\r' || r == '\n' {
+		return 0, errors.Newf("invalid delimiter %q, delimiter must be a single character", delimiter)
+	}
+	return r, nil
+}
+
+// tableState accumulates rows of a table
+type tableState struct {
+	columns     []*irods_common.ColumnStats
+	columnIndex map[string]int
+	rows        [][]string
+	maxRows     int
+	rowsScanned int64
+}
+
+func (state *tableState) addColumn(name string) int {
+	stats := irods_common.NewColumnStats(name)
+	// previous rows have no value for the new column, e.g., a key first appearing in a later JSON line
+	stats.AddNulls(state.rowsScanned)
+
+	newColumnIdx := len(state.columns)
+	for rowIdx, row := range state.rows {
+		for len(row) <= newColumnIdx {
+			row = append(row, "")
+		}
+		state.rows[rowIdx] = row
+	}
+
+	state.columns = append(state.columns, stats)
+	if state.columnIndex != nil {
+		state.columnIndex[name] = len(state.columns) - 1
+	}
+	return len(state.columns) - 1
+}
+
+func (state *tableState) addRow(values []string) {
+	for len(values) > len(state.columns) && len(state.columns) < previewTableMaxColumns {
+		state.addColumn(fmt.Sprintf("column_%d", len(state.columns)+1))
+	}
+
+	for idx, stats := range state.columns {
+		if idx < len(values) {
+			stats.Add(values[idx])
+		} else {
+			stats.AddNull()
+		}
+	}
+
+	if len(state.rows) < state.maxRows {
+		row := make([]string, 0, len(values))
+		for _, value := range values {
+			row = append(row, truncateCell(value))
+		}
+		state.rows = append(state.rows, row)
+	}
+
+	state.rowsScanned++
+}
+
+func truncateCell(value string) string {
+	if len(value) <= previewTableMaxCellLength {
+		return value
+	}
+
+	cut := previewTableMaxCellLength
+	for cut > 0 && !utf8.RuneStart(value[cut]) {
+		cut--
+	}
+	return value[:cut] + "..."
+}
+
+func (t *PreviewTable) previewTable(fs *irodsclient_fs.FileSystem, entry *irodsclient_fs.Entry, delimiter rune, args *PreviewTableInputArgs) (*model.PreviewTableOutput, error) {
+	handle, err := fs.OpenFile(entry.Path, "", "r")
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to open file %q", entry.Path)
+	}
+	defer handle.Close()
+
+	counter := irods_common.NewBudgetReader(handle, args.MaxBytes)
+
+	var reader *bufio.Reader = bufio.NewReaderSize(counter, previewTableReadSize)
+	contentPath := entry.Path
+
+	magic, _ := reader.Peek(irods_common.CompressionMagicReadSize)
+	compressionFormat := irods_common.DetectCompressionFormat(entry.Path, magic)
+	if len(compressionFormat) > 0 {
+		decompressionReader, err := irods_common.NewDecompressionReader(compressionFormat, reader)
+		if err != nil {
+			return nil, err
+		}
+		defer decompressionReader.Close()
+
+		reader = bufio.NewReaderSize(decompressionReader, previewTableReadSize)
+		contentPath = irods_common.TrimCompressionExtension(entry.Path)
+	}
+
+	head, _ := reader.Peek(previewTableSniffSize)
+
+	format, sniffedDelimiter := irods_common.SniffTableFormat(contentPath, head)
+	if len(args.Format) > 0 {
+		format = irods_common.TableFormat(args.Format)
+	}
+
+	if delimiter == 0 {
+		delimiter = sniffedDelimiter
+		if format == irods_common.TableFormatTSV {
+			delimiter = '\t'
+		} else if delimiter == 0 {
+			delimiter = ','
+		}
+	}
+
+	state := &tableState{
+		maxRows: args.Rows,
+	}
+
+	output := &model.PreviewTableOutput{
+		Path:        entry.Path,
+		ResourceURI: irods_common.MakeResourceURI(entry.Path),
+		Format:      string(format),
+	}
+
+	// the byte budget is exhausted if the data object is not read to the end
+	budgetExhausted := func() bool {
+		return counter.IsExhausted(entry.Size)
+	}
+
+	switch format {
+	case irods_common.TableFormatJSONL:
+		err = t.readJSONLines(reader, state, budgetExhausted)
+	case irods_common.TableFormatCSV, irods_common.TableFormatTSV:
+		output.Delimiter = string(delimiter)
+		output.HasHeader, err = t.readDelimited(reader, delimiter, args.HasHeader, state, budgetExhausted)
+	default:
+		err = errors.Newf("unknown table format %q", format)
+	}
+
+	if err != nil {
+		return nil, err
+	}
+
+	output.Columns = make([]model.TableColumn, 0, len(state.columns))
+	for _, stats := range state.columns {
+		column := model.TableColumn{
+			Name:              stats.Name,
+			Type:              string(stats.Type()),
+			Count:             stats.Count,
+			Nulls:             stats.Nulls,
+			Distinct:          stats.Distinct(),
+			DistinctTruncated: stats.DistinctTruncated,
+		}
+
+		if stats.Count > 0 {
+			column.Min = truncateCell(stats.Min())
+			column.Max = truncateCell(stats.Max())
+		}
+
+		if mean, ok := stats.Mean(); ok {
+			column.Mean = &mean
+		}
+
+		output.Columns = append(output.Columns, column)
+	}
+
+	output.Rows = state.rows
+	output.RowsScanned = state.rowsScanned
+	output.BytesScanned = counter.BytesRead()
+	output.RowCount = counter.EstimateTotal(state.rowsScanned, entry.Size)
+	output.RowCountExact = !budgetExhausted()
+	output.Truncated = budgetExhausted()
+
+	return output, nil
+}
+
+// readDelimited reads CSV or TSV rows, returns true if the first row is used as a header
+func (t *PreviewTable) readDelimited(reader io.Reader, delimiter rune, hasHeader *bool, state *tableState, budgetExhausted func() bool) (bool, error) {
+	csvReader := csv.NewReader(reader)
+	csvReader.Comma = delimiter
+	csvReader.LazyQuotes = true
+	csvReader.FieldsPerRecord = -1
+
+	// read records one ahead, the last record may be partial if the budget is exhausted
+	records := [][]string{}
+	header := false
+	headerDecided := false
+
+	for {
+		record, err := csvReader.Read()
+		if err != nil {
+			if err == io.EOF || budgetExhausted() {
+				break
+			}
+			return false, errors.Wrapf(err, "failed to read row %d", state.rowsScanned+int64(len(records))+1)
+		}
+
+		records = append(records, record)
+
+		if !headerDecided {
+			if len(records) < previewTableHeaderSniffRows {
+				continue
+			}
+
+			header = t.decideHeader(records, hasHeader, state)
+			headerDecided = true
+			if header {
+				records = records[1:]
+			}
+		}
+
+		// keep the last record pending
+		for len(records) > 1 {
+			state.addRow(records[0])
+			records = records[1:]
+		}
+	}
+
+	if !headerDecided && len(records) > 0 {
+		header = t.decideHeader(records, hasHeader, state)
+		if header {
+			records = records[1:]
+		}
+	}
+
+	if budgetExhausted() && len(records) > 0 {
+		// drop the partial record
+		records = records[:len(records)-1]
+	}
+
+	for _, record := range records {
+		state.addRow(record)
+	}
+
+	return header, nil
+}
+
+func (t *PreviewTable) decideHeader(records [][]string, hasHeader *bool, state *tableState) bool {
+	header := false
+	if hasHeader != nil {
+		header = *hasHeader
+	} else {
+		header = irods_common.LooksLikeHeader(records[0], records[1:])
+	}
+
+	if header {
+		for idx, name := range records[0] {
+			if idx >= previewTableMaxColumns {
+				break
+			}
+
+			name = strings.TrimSpace(name)
+			if len(name) == 0 {
+				name = fmt.Sprintf("column_%d", idx+1)
+			}
+			state.addColumn(name)
+		}
+	}
+
+	return header
+}
+
+func (t *PreviewTable) readJSONLines(reader *bufio.Reader, state *tableState, budgetExhausted func() bool) error {
+	state.columnIndex = map[string]int{}
+	lineNumber := 0
+
+	for {
+		line, readErr := reader.ReadBytes('\n')
+		if readErr != nil && readErr != io.EOF {
+			if budgetExhausted() {
+				// compressed stream ends unexpectedly when the byte budget is exhausted
+				return nil
+			}
+			return errors.Wrapf(readErr, "failed to read line %d", lineNumber+1)
+		}
+
+		if readErr == io.EOF && budgetExhausted() {
+			// the last line is partial
+			return nil
+		}
+
+		lineNumber++
+		line = bytes.TrimSpace(line)
+		if len(line) > 0 {
+			keys, values, err := decodeJSONObject(line)
+			if err != nil {
+				return errors.Wrapf(err, "failed to parse JSON object at line %d", lineNumber)
+			}
+
+			row := make([]string, len(state.columns))
+			for idx, key := range keys {
+				columnIdx, ok := state.columnIndex[key]
+				if !ok {
+					if len(state.columns) >= previewTableMaxColumns {
+						continue
+					}
+					columnIdx = state.addColumn(key)
+					row = append(row, "")
+				}
+				row[columnIdx] = values[idx]
+			}
+
+			state.addRow(row)
+		}
+
+		if readErr == io.EOF {
+			return nil
+		}
+	}
+}
+
+// decodeJSONObject decodes a JSON object keeping the order of keys, values are returned as strings
+func decodeJSONObject(line []byte) ([]string, []string, error) {
+	decoder := json.NewDecoder(bytes.NewReader(line))
+	decoder.UseNumber()
+
+	token, err := decoder.Token()
+	if err != nil {
+		return nil, nil, err
+	}
+
+	if delim, ok := token.(json.Delim); !ok || delim != '{' {
+		return nil, nil, errors.Newf("not a JSON object")
+	}
+
+	keys := []string{}
+	values := []string{}
+	for decoder.More() {
+		keyToken, err := decoder.Token()
+		if err != nil {
+			return nil, nil, err
+		}
+
+		key, ok := keyToken.(string)
+		if !ok {
+			return nil, nil, errors.Newf("invalid key %v", keyToken)
+		}
+
+		raw := json.RawMessage{}
+		err = decoder.Decode(&raw)
+		if err != nil {
+			return nil, nil, err
+		}
+
+		value := string(raw)
+		switch {
+		case value == "null":
+			value = ""
+		case strings.HasPrefix(value, "\""):
+			str := ""
+			if json.Unmarshal(raw, &str) == nil {
+				value = str
+			}
+		}
+
+		keys = append(keys, key)
+		values = append(values, value)
+	}
+
+	return keys, values, nil
+}
@@ -1,27 +1,51 @@
 package irods
 
 import (
+	"bufio"
 	"context"
 	"encoding/base64"
 	"encoding/json"
 	"fmt"
+	"io"
+	"path"
 
 	"github.com/cockroachdb/errors"
 	irodsclient_fs "github.com/cyverse/go-irodsclient/fs"
 	"github.com/cyverse/irods-mcp-server/common"
 	irods_common "github.com/cyverse/irods-mcp-server/irods/common"
+	"github.com/cyverse/irods-mcp-server/irods/model"
 	"github.com/google/jsonschema-go/jsonschema"
 	"github.com/modelcontextprotocol/go-sdk/mcp"
 )
 
 const (
 	ReadFileName = irods_common.IRODSAPIPrefix + "read_file"
+
+	maxArchiveMembersListed int = 1000
 )
 
 type ReadFileInputArgs struct {
-	Path   string `json:"path"`
-	Offset int64  `json:"offset,omitempty"`
-	Length int64  `json:"length,omitempty"`
+	Path         string `json:"path"`
+	Offset       int64  `json:"offset,omitempty"`
+	Length       int64  `json:"length,omitempty"`
+	StartLine    int64  `json:"start_line,omitempty"`
+	EndLine      int64  `json:"end_line,omitempty"`
+	Head         int64  `json:"head,omitempty"`
+	Tail         int64  `json:"tail,omitempty"`
+	LineNumbers  bool   `json:"line_numbers,omitempty"`
+	Decompress   bool   `json:"decompress,omitempty"`
+	Member       string `json:"member,omitempty"`
+	ListMembers  bool   `json:"list_members,omitempty"`
+	MaxDimension int    `json:"max_dimension,omitempty"`
+	Encoding     string `json:"encoding,omitempty"`
+}
+
+func (args *ReadFileInputArgs) IsLineMode() bool {
+	return args.StartLine > 0 || args.EndLine > 0 || args.Head > 0 || args.Tail > 0
+}
+
+func (args *ReadFileInputArgs) IsStreamMode() bool {
+	return args.Decompress || len(args.Member) > 0
 }
 
 type ReadFile struct {
@@ -43,6 +67,11 @@ func (t *ReadFile) GetName() string {
 func (t *ReadFile) GetDescription() string {
 	return `Read the partial content of a file (data-object) with the specified path and offset.
 	The specified path must be an iRODS path.
+	For text files, lines can be read instead of bytes using 'start_line'/'end_line', 'head', or 'tail'. The total line count is returned when the file is read to the end, e.g. with 'tail'.
+	Compressed files (gzip, bzip2, zstd) can be decompressed on the fly with 'decompress'. Members of tar, tar.gz, and zip archives can be listed with 'list_members' and read with 'member'.
+	Offsets and lines refer to the decompressed content in these cases.
+	Text is converted to UTF-8 from the encoding detected by the byte order mark and the content, or given by 'encoding'. The encoding and the offset to read the following content from are returned in metadata.
+	PNG, JPEG, and GIF images too large to be displayed inline are returned as downscaled thumbnails with their original dimensions.
 	If the file is too large to be displayed inline, use the WebDAV URI to access it.`
 }
 
@@ -67,6 +96,49 @@ func (t *ReadFile) GetTool() *mcp.Tool {
 					Description: fmt.Sprintf("The maximum length of the file to read. Default value is %d. Length must be greater than or equal to %d. Length must not be too large, otherwise the output may be too large. Maximum value is %d.", irods_common.MaxInlineSize, irods_common.MinReadLength, irods_common.MaxInlineSize),
 					Default:     json.RawMessage(fmt.Sprintf("%d", irods_common.MinReadLength)),
 				},
+				"start_line": {
+					Type:        "number",
+					Description: "The first line to read (1-based, inclusive). Reads lines instead of bytes if set.",
+				},
+				"end_line": {
+					Type:        "number",
+					Description: "The last line to read (1-based, inclusive). Reads lines instead of bytes if set. Default is the last line of the file.",
+				},
+				"head": {
+					Type:        "number",
+					Description: "The number of lines to read from the beginning of the file. Reads lines instead of bytes if set.",
+				},
+				"tail": {
+					Type:        "number",
+					Description: "The number of lines to read from the end of the file. Reads lines instead of bytes if set.",
+				},
+				"line_numbers": {
+					Type:        "boolean",
+					Description: "Set to true to prefix each line with its line number. Only used when reading lines. Default is false.",
+					Default:     json.RawMessage("false"),
+				},
+				"decompress": {
+					Type:        "boolean",
+					Description: "Set to true to decompress a gzip, bzip2 or zstd compressed file on the fly. The compression format is detected from the content. Default is false.",
+					Default:     json.RawMessage("false"),
+				},
+				"member": {
+					Type:        "string",
+					Description: "The path of a member in a tar, tar.gz, or zip archive file to read. The archive format is detected from the extension of 'path'.",
+				},
+				"encoding": {
+					Type:        "string",
+					Description: "The text encoding of the file, such as 'utf-8', 'utf-16le', 'utf-16be', 'iso-8859-1', or 'windows-1252'. Default is detected from the byte order mark and the content.",
+				},
+				"max_dimension": {
+					Type:        "number",
+					Description: fmt.Sprintf("The maximum width and height of the thumbnail in pixels for PNG, JPEG, and GIF images. If set, a thumbnail is returned even if the image is small enough to be displayed inline. Maximum is %d. Default is %d, used only for images too large to be displayed inline.", irods_common.ThumbnailMaxDimension, irods_common.ThumbnailMaxDimensionDefault),
+				},
+				"list_members": {
+					Type:        "boolean",
+					Description: fmt.Sprintf("Set to true to list members of a tar, tar.gz, or zip archive file. Up to %d members are returned. Default is false.", maxArchiveMembersListed),
+					Default:     json.RawMessage("false"),
+				},
 			},
 			Required: []string{"path"},
 		},
@@ -148,6 +220,46 @@ func (t *ReadFile) Handler(ctx context.Context, request *mcp.CallToolRequest) (*
 		return irods_common.ToolErrorResult(outputErr), nil
 	}
 
+	if args.ListMembers || args.IsStreamMode() {
+		if entry.IsDir() {
+			outputErr := errors.Newf("path %q is a directory (collection)", irodsPath)
+			return irods_common.ToolErrorResult(outputErr), nil
+		}
+
+		if args.ListMembers {
+			content, err := t.listArchiveMembers(fs, entry)
+			if err != nil {
+				outputErr := errors.Wrapf(err, "failed to list archive members of %q", irodsPath)
+				return irods_common.ToolErrorResult(outputErr), nil
+			}
+
+			return irods_common.ToolJSONResult(*content)
+		}
+
+		content, err := t.readStream(fs, entry, &args, inputLength)
+		if err != nil {
+			outputErr := errors.Wrapf(err, "failed to read file (data-object) for %q", irodsPath)
+			return irods_common.ToolErrorResult(outputErr), nil
+		}
+
+		return content, nil
+	}
+
+	if args.IsLineMode() {
+		if entry.IsDir() {
+			outputErr := errors.Newf("path %q is a directory (collection)", irodsPath)
+			return irods_common.ToolErrorResult(outputErr), nil
+		}
+
+		content, err := t.readFileLines(fs, entry, &args)
+		if err != nil {
+			outputErr := errors.Wrapf(err, "failed to read lines of file (data-object) for %q", irodsPath)
+			return irods_common.ToolErrorResult(outputErr), nil
+		}
+
+		return irods_common.ToolJSONResult(*content)
+	}
+
 	inputOffset := args.Offset
 	if inputOffset < 0 {
 		inputOffset = 0
@@ -155,7 +267,7 @@ func (t *ReadFile) Handler(ctx context.Context, request *mcp.CallToolRequest) (*
 		inputOffset = entry.Size
 	}
 
-	content, err := t.readFile(fs, entry, int64(inputOffset), int64(inputLength))
+	content, err := t.readFile(fs, entry, int64(inputOffset), int64(inputLength), args.MaxDimension, args.Encoding)
 	if err != nil {
 		outputErr := errors.Wrapf(err, "failed to read file (data-object) for %q", irodsPath)
 		return irods_common.ToolErrorResult(outputErr), nil
@@ -164,7 +276,7 @@ func (t *ReadFile) Handler(ctx context.Context, request *mcp.CallToolRequest) (*
 	return content, err
 }
 
-func (t *ReadFile) readFile(fs *irodsclient_fs.FileSystem, sourceEntry *irodsclient_fs.Entry, offset int64, readLength int64) (*mcp.CallToolResult, error) {
+func (t *ReadFile) readFile(fs *irodsclient_fs.FileSystem, sourceEntry *irodsclient_fs.Entry, offset int64, readLength int64, maxDimension int, encodingName string) (*mcp.CallToolResult, error) {
 	resourceURI := irods_common.MakeResourceURI(sourceEntry.Path)
 	webdavURI := irods_common.MakeWebdavURL(t.config, sourceEntry.Path, fs.GetAccount())
 
@@ -194,15 +306,86 @@ func (t *ReadFile) readFile(fs *irodsclient_fs.FileSystem, sourceEntry *irodscli
 	}
 
 	mimeType := irods_common.DetectMimeTypeWithContent(sourceEntry.Path, offset, content)
-	if irods_common.IsTextFile(mimeType) {
+	if irods_common.IsThumbnailSupported(mimeType) && (sourceEntry.Size > irods_common.MaxBase64Size || maxDimension > 0) {
+		thumbnailResult, err := t.makeThumbnailResult(fs, sourceEntry, maxDimension)
+		if err == nil {
+			return thumbnailResult, nil
+		}
+
+		if sourceEntry.Size > irods_common.MaxBase64Size {
+			return irods_common.ToolTextResult(fmt.Sprintf("Image file (%q, %d bytes) is too large to encode to base64 format and a thumbnail could not be made (%s). Access it via WebDAV URI: %q", mimeType, sourceEntry.Size, err.Error(), webdavURI)), nil
+		}
+	}
+
+	return t.makeContentResult(sourceEntry.Path, sourceEntry.Size, offset, content, encodingName, resourceURI, webdavURI), nil
+}
+
+func (t *ReadFile) makeThumbnailResult(fs *irodsclient_fs.FileSystem, sourceEntry *irodsclient_fs.Entry, maxDimension int) (*mcp.CallToolResult, error) {
+	thumbnail, err := irods_common.MakeImageThumbnail(fs, sourceEntry, maxDimension, irods_common.MaxBase64Size)
+	if err != nil {
+		return nil, err
+	}
+
+	return &mcp.CallToolResult{
+		Content: []mcp.Content{
+			&mcp.TextContent{
+				Text: fmt.Sprintf("Image file (data-object): %q (%q format, %d bytes, %dx%d pixels). Showing a thumbnail of %dx%d pixels.", sourceEntry.Path, thumbnail.OriginalFormat, sourceEntry.Size, thumbnail.OriginalWidth, thumbnail.OriginalHeight, thumbnail.Width, thumbnail.Height),
+			},
+			&mcp.ImageContent{
+				Meta:     irods_common.MakeThumbnailMeta(thumbnail),
+				Data:     []byte(base64.StdEncoding.EncodeToString(thumbnail.Data)),
+				MIMEType: thumbnail.MIMEType,
+			},
+		},
+		IsError: false,
+	}, nil
+}
+
+// makeContentResult makes a tool result for the content read from the file, contentPath is used to detect the type of content.
+// Text is decoded from encodingName, or the detected encoding if empty.
+func (t *ReadFile) makeContentResult(contentPath string, size int64, offset int64, content []byte, encodingName string, resourceURI string, webdavURI string) *mcp.CallToolResult {
+	mimeType := irods_common.DetectMimeTypeWithContent(contentPath, offset, content)
+
+	textEncoding := encodingName
+	if len(textEncoding) == 0 && !irods_common.IsTextFile(mimeType) && !irods_common.IsImageFile(mimeType) {
+		// UTF-16 text without byte order mark is not recognized by mime type
+		detectedEncoding := irods_common.DetectTextEncoding(content, offset)
+		if detectedEncoding == irods_common.TextEncodingUTF16LE || detectedEncoding == irods_common.TextEncodingUTF16BE {
+			textEncoding = detectedEncoding
+		}
+	}
+
+	if irods_common.IsTextFile(mimeType) || len(textEncoding) > 0 {
 		// text file
-		return irods_common.ToolTextResult(string(content)), nil
+		atEOF := offset+int64(len(content)) >= size
+		chunk, err := irods_common.DecodeTextChunk(content, offset, textEncoding, atEOF)
+		if err != nil {
+			if len(encodingName) > 0 {
+				return irods_common.ToolErrorResult(errors.Wrapf(err, "failed to decode text of %q", contentPath))
+			}
+
+			// keep bytes as they are
+			chunk, err = irods_common.DecodeTextChunk(content, offset, irods_common.TextEncodingUTF8, atEOF)
+			if err != nil {
+				return irods_common.ToolErrorResult(errors.Wrapf(err, "failed to decode text of %q", contentPath))
+			}
+		}
+
+		return &mcp.CallToolResult{
+			Content: []mcp.Content{
+				&mcp.TextContent{
+					Meta: irods_common.MakeTextChunkMeta(chunk),
+					Text: chunk.Text,
+				},
+			},
+			IsError: false,
+		}
 	} else if irods_common.IsImageFile(mimeType) {
-		if sourceEntry.Size <= irods_common.MaxBase64Size {
+		if size <= irods_common.MaxBase64Size {
 			return &mcp.CallToolResult{
 				Content: []mcp.Content{
 					&mcp.TextContent{
-						Text: fmt.Sprintf("Image file (data-object): %q (%q type, %d bytes)", sourceEntry.Path, mimeType, sourceEntry.Size),
+						Text: fmt.Sprintf("Image file (data-object): %q (%q type, %d bytes)", contentPath, mimeType, size),
 					},
 					&mcp.ImageContent{
 						Data:     []byte(base64.StdEncoding.EncodeToString(content)),
@@ -210,18 +393,18 @@ func (t *ReadFile) readFile(fs *irodsclient_fs.FileSystem, sourceEntry *irodscli
 					},
 				},
 				IsError: false,
-			}, nil
+			}
 		} else {
 			// Too large for base64, return a reference
-			return irods_common.ToolTextResult(fmt.Sprintf("Image file (%q, %d bytes) is too large to encode to base64 format. Access it via WebDAV URI: %q", mimeType, sourceEntry.Size, webdavURI)), nil
+			return irods_common.ToolTextResult(fmt.Sprintf("Image file (%q, %d bytes) is too large to encode to base64 format. Access it via WebDAV URI: %q", mimeType, size, webdavURI))
 		}
 	} else {
 		// binary file
-		if sourceEntry.Size <= irods_common.MaxBase64Size {
+		if size <= irods_common.MaxBase64Size {
 			return &mcp.CallToolResult{
 				Content: []mcp.Content{
 					&mcp.TextContent{
-						Text: fmt.Sprintf("Binary file (data-object): %q (%q type, %d bytes)", sourceEntry.Path, mimeType, sourceEntry.Size),
+						Text: fmt.Sprintf("Binary file (data-object): %q (%q type, %d bytes)", contentPath, mimeType, size),
 					},
 					&mcp.EmbeddedResource{
 						Resource: &mcp.ResourceContents{
@@ -232,9 +415,221 @@ func (t *ReadFile) readFile(fs *irodsclient_fs.FileSystem, sourceEntry *irodscli
 					},
 				},
 				IsError: false,
-			}, nil
+			}
 		} else {
-			return irods_common.ToolTextResult(fmt.Sprintf("Binary file (%q, %d bytes) is too large to encode to base64 format. Access it via WebDAV URI: %q", mimeType, sourceEntry.Size, webdavURI)), nil
+			return irods_common.ToolTextResult(fmt.Sprintf("Binary file (%q, %d bytes) is too large to encode to base64 format. Access it via WebDAV URI: %q", mimeType, size, webdavURI))
+		}
+	}
+}
+
+func (t *ReadFile) readFileLines(fs *irodsclient_fs.FileSystem, sourceEntry *irodsclient_fs.Entry, args *ReadFileInputArgs) (*model.ReadFileLinesOutput, error) {
+	startLine := args.StartLine
+	endLine := args.EndLine
+
+	if args.Head > 0 {
+		startLine = 1
+		endLine = args.Head
+	} else if args.Tail > 0 {
+		index, err := irods_common.GetLineIndex(fs, sourceEntry, 0)
+		if err != nil {
+			return nil, errors.Wrapf(err, "failed to index lines of file (data-object) %q", sourceEntry.Path)
+		}
+
+		startLine = index.TotalLines - args.Tail + 1
+		endLine = index.TotalLines
+	}
+
+	encodingName := args.Encoding
+	if len(encodingName) == 0 {
+		detectedEncoding, err := irods_common.DetectDataObjectTextEncoding(fs, sourceEntry.Path)
+		if err != nil {
+			return nil, errors.Wrapf(err, "failed to detect text encoding of file (data-object) %q", sourceEntry.Path)
+		}
+
+		encodingName = detectedEncoding
+		if len(encodingName) == 0 {
+			// binary, keep bytes as they are
+			encodingName = irods_common.TextEncodingUTF8
+		}
+	}
+
+	textLines, err := irods_common.ReadDataObjectLines(fs, sourceEntry, startLine, endLine, irods_common.MaxInlineSize)
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to read lines of file (data-object) %q", sourceEntry.Path)
+	}
+
+	lines, err := irods_common.DecodeTextLines(textLines.Lines, encodingName, textLines.StartLine == 1)
+	if err != nil {
+		return nil, err
+	}
+
+	readFileLinesOutput := &model.ReadFileLinesOutput{
+		Path:        sourceEntry.Path,
+		ResourceURI: irods_common.MakeResourceURI(sourceEntry.Path),
+		StartLine:   textLines.StartLine,
+		EndLine:     textLines.EndLine,
+		Truncated:   textLines.Truncated,
+		Encoding:    encodingName,
+		Content:     irods_common.FormatLines(textLines.StartLine, lines, args.LineNumbers),
+	}
+
+	if textLines.TotalLines > 0 {
+		totalLines := textLines.TotalLines
+		readFileLinesOutput.TotalLines = &totalLines
+	}
+
+	return readFileLinesOutput, nil
+}
+
+func (t *ReadFile) listArchiveMembers(fs *irodsclient_fs.FileSystem, sourceEntry *irodsclient_fs.Entry) (*model.ArchiveMembersOutput, error) {
+	format := irods_common.GetArchiveFormatFromPath(sourceEntry.Path)
+	if len(format) == 0 {
+		return nil, errors.Newf("file %q is not a tar, tar.gz, or zip archive", sourceEntry.Path)
+	}
+
+	members, truncated, err := irods_common.ListArchiveMembers(fs, sourceEntry, format, maxArchiveMembersListed)
+	if err != nil {
+		return nil, err
+	}
+
+	memberEntries := make([]model.ArchiveMemberEntry, 0, len(members))
+	for _, member := range members {
+		memberEntries = append(memberEntries, model.ArchiveMemberEntry{
+			Name:       member.Name,
+			Type:       member.Type,
+			Size:       member.Size,
+			ModifyTime: member.ModifyTime,
+		})
+	}
+
+	return &model.ArchiveMembersOutput{
+		Path:        sourceEntry.Path,
+		ResourceURI: irods_common.MakeResourceURI(sourceEntry.Path),
+		Format:      string(format),
+		Members:     memberEntries,
+		Truncated:   truncated,
+	}, nil
+}
+
+// readStream reads a member of an archive or decompressed content of the file, which can be read only sequentially
+func (t *ReadFile) readStream(fs *irodsclient_fs.FileSystem, sourceEntry *irodsclient_fs.Entry, args *ReadFileInputArgs, readLength int64) (*mcp.CallToolResult, error) {
+	resourceURI := irods_common.MakeResourceURI(sourceEntry.Path)
+	webdavURI := irods_common.MakeWebdavURL(t.config, sourceEntry.Path, fs.GetAccount())
+
+	var result *mcp.CallToolResult
+	read := func(contentPath string, size int64, reader io.Reader) error {
+		if args.IsLineMode() {
+			content, err := t.readStreamLines(sourceEntry, reader, args)
+			if err != nil {
+				return err
+			}
+
+			result, err = irods_common.ToolJSONResult(*content)
+			return err
+		}
+
+		offset := args.Offset
+		if offset < 0 {
+			offset = 0
+		}
+
+		content, err := irods_common.ReadStream(reader, offset, readLength)
+		if err != nil {
+			return err
+		}
+
+		if size < 0 {
+			// unknown size of decompressed content
+			size = offset + int64(len(content))
 		}
+
+		result = t.makeContentResult(contentPath, size, offset, content, args.Encoding, resourceURI, webdavURI)
+		return nil
+	}
+
+	if len(args.Member) > 0 {
+		format := irods_common.GetArchiveFormatFromPath(sourceEntry.Path)
+		if len(format) == 0 {
+			return nil, errors.Newf("file %q is not a tar, tar.gz, or zip archive", sourceEntry.Path)
+		}
+
+		err := irods_common.ReadArchiveMember(fs, sourceEntry, format, args.Member, func(member *irods_common.ArchiveMember, reader io.Reader) error {
+			return read(path.Join(sourceEntry.Path, member.Name), member.Size, reader)
+		})
+		if err != nil {
+			return nil, err
+		}
+
+		return result, nil
+	}
+
+	handle, err := fs.OpenFile(sourceEntry.Path, "", "r")
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to open file %q", sourceEntry.Path)
+	}
+	defer handle.Close()
+
+	bufferedReader := bufio.NewReaderSize(handle, irods_common.ArchiveIOBufferSize)
+	header, _ := bufferedReader.Peek(irods_common.CompressionMagicReadSize)
+
+	compressionFormat := irods_common.DetectCompressionFormat(sourceEntry.Path, header)
+	if len(compressionFormat) == 0 {
+		return nil, errors.Newf("file %q is not compressed in a supported format", sourceEntry.Path)
+	}
+
+	decompressionReader, err := irods_common.NewDecompressionReader(compressionFormat, bufferedReader)
+	if err != nil {
+		return nil, err
+	}
+	defer decompressionReader.Close()
+
+	err = read(irods_common.TrimCompressionExtension(sourceEntry.Path), -1, decompressionReader)
+	if err != nil {
+		return nil, err
 	}
+
+	return result, nil
+}
+
+func (t *ReadFile) readStreamLines(sourceEntry *irodsclient_fs.Entry, reader io.Reader, args *ReadFileInputArgs) (*model.ReadStreamLinesOutput, error) {
+	startLine := args.StartLine
+	endLine := args.EndLine
+
+	if args.Head > 0 {
+		startLine = 1
+		endLine = args.Head
+	}
+
+	textLines, err := irods_common.ReadStreamLines(reader, startLine, endLine, args.Tail, irods_common.MaxInlineSize)
+	if err != nil {
+		return nil, err
+	}
+
+	encodingName := args.Encoding
+	if len(encodingName) == 0 {
+		encodingName = irods_common.TextEncodingUTF8
+	}
+
+	lines, err := irods_common.DecodeTextLines(textLines.Lines, encodingName, textLines.StartLine == 1)
+	if err != nil {
+		return nil, err
+	}
+
+	readStreamLinesOutput := &model.ReadStreamLinesOutput{
+		Path:        sourceEntry.Path,
+		ResourceURI: irods_common.MakeResourceURI(sourceEntry.Path),
+		Member:      args.Member,
+		StartLine:   textLines.StartLine,
+		EndLine:     textLines.EndLine,
+		Truncated:   textLines.Truncated,
+		Encoding:    encodingName,
+		Content:     irods_common.FormatLines(textLines.StartLine, lines, args.LineNumbers),
+	}
+
+	if textLines.TotalLines > 0 {
+		totalLines := textLines.TotalLines
+		readStreamLinesOutput.TotalLines = &totalLines
+	}
+
+	return readStreamLinesOutput, nil
 }
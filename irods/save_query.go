@@ -0,0 +1,243 @@
+package irods
+
+import (
+	"context"
+	"encoding/json"
+	"strings"
+	"time"
+
+	"github.com/cockroachdb/errors"
+	"github.com/cyverse/irods-mcp-server/common"
+	irods_common "github.com/cyverse/irods-mcp-server/irods/common"
+	"github.com/cyverse/irods-mcp-server/irods/model"
+	"github.com/google/jsonschema-go/jsonschema"
+	"github.com/modelcontextprotocol/go-sdk/mcp"
+)
+
+const (
+	SaveQueryName = irods_common.IRODSAPIPrefix + "save_query"
+)
+
+type SaveQueryInputArgs struct {
+	Name        string               `json:"name"`
+	Description string               `json:"description,omitempty"`
+	Attribute   string               `json:"attribute,omitempty"`
+	Value       string               `json:"value,omitempty"`
+	Conditions  []model.AVUCondition `json:"conditions,omitempty"`
+	Match       string               `json:"match,omitempty"`
+	NamePattern string               `json:"name_pattern,omitempty"`
+	Paths       []string             `json:"paths,omitempty"`
+	EntityType  string               `json:"entity_type,omitempty"`
+	Overwrite   bool                 `json:"overwrite,omitempty"`
+}
+
+type SaveQuery struct {
+	mcpServer *IRODSMCPServer
+	config    *common.Config
+	// provides the condition arguments and accessible paths
+	pathSearch *SearchFilesByAVU
+}
+
+func NewSaveQuery(svr *IRODSMCPServer) ToolAPI {
+	return &SaveQuery{
+		mcpServer: svr,
+		config:    svr.GetConfig(),
+		pathSearch: &SearchFilesByAVU{
+			mcpServer: svr,
+			config:    svr.GetConfig(),
+		},
+	}
+}
+
+func (t *SaveQuery) GetName() string {
+	return SaveQueryName
+}
+
+func (t *SaveQuery) GetDescription() string {
+	return `Save an AVU (attribute-value-units) search under a name, to run it again later.
+	The search takes the same attribute, value, conditions, match and entity_type arguments as search_files_by_avu.
+	Set paths to search under several directories (collections), and name_pattern to return only entries whose names match a glob pattern.
+	Saved queries are stored per user in the home directory (collection). Each saved query is readable as the resource 'irods-query://<name>', whose content is the current list of matching files (data-objects) and directories (collections).
+	Set overwrite to replace an existing saved query with the same name.`
+}
+
+func (t *SaveQuery) GetTool() *mcp.Tool {
+	// same search arguments as search_files_by_avu, without pagination
+	searchSchema := t.pathSearch.GetTool().InputSchema.(*jsonschema.Schema)
+
+	properties := map[string]*jsonschema.Schema{
+		"name": {
+			Type:        "string",
+			Description: "The name of the query, used in its resource URI. Letters, digits, '.', '_' and '-', up to 64 characters.",
+		},
+		"description": {
+			Type:        "string",
+			Description: "A description of what the query finds.",
+		},
+		"name_pattern": {
+			Type:        "string",
+			Description: "A glob pattern matched against names of the entries, e.g., '*.fastq.gz'. Omit to return all names.",
+		},
+		"paths": {
+			Type:        "array",
+			Description: "The collection paths to search in. Omit to search everywhere accessible.",
+			Items: &jsonschema.Schema{
+				Type: "string",
+			},
+		},
+		"overwrite": {
+			Type:        "boolean",
+			Description: "Replace an existing saved query with the same name.",
+			Default:     json.RawMessage("false"),
+		},
+	}
+	for _, name := range []string{"attribute", "value", "conditions", "match", "entity_type"} {
+		if property, ok := searchSchema.Properties[name]; ok {
+			properties[name] = property
+		}
+	}
+
+	return &mcp.Tool{
+		Name:        t.GetName(),
+		Description: t.GetDescription(),
+		InputSchema: &jsonschema.Schema{
+			Type:       "object",
+			Properties: properties,
+			Required:   []string{"name"},
+		},
+	}
+}
+
+func (t *SaveQuery) GetHandler() mcp.ToolHandler {
+	return t.Handler
+}
+
+func (t *SaveQuery) GetAccessiblePaths(authValue *common.AuthValue) []string {
+	return t.pathSearch.GetAccessiblePaths(authValue)
+}
+
+func (t *SaveQuery) Handler(ctx context.Context, request *mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	// arguments
+	args := SaveQueryInputArgs{}
+	err := irods_common.MarshalInputArguments(t.GetTool(), request, &args)
+	if err != nil {
+		outputErr := errors.Wrapf(err, "failed to marshal input arguments")
+		return irods_common.ToolErrorResult(outputErr), nil
+	}
+
+	err = validateSavedQueryName(args.Name)
+	if err != nil {
+		return irods_common.ToolErrorResult(err), nil
+	}
+
+	rootCondition := makeAVURootCondition(args.Attribute, args.Value, args.Match, args.Conditions)
+	if len(rootCondition.Conditions) == 0 {
+		outputErr := errors.Newf("either attribute or conditions must be given")
+		return irods_common.ToolErrorResult(outputErr), nil
+	}
+
+	err = normalizeAVUCondition(rootCondition, 0)
+	if err != nil {
+		outputErr := errors.Wrapf(err, "invalid conditions")
+		return irods_common.ToolErrorResult(outputErr), nil
+	}
+
+	err = validateSavedQueryNamePattern(args.NamePattern)
+	if err != nil {
+		return irods_common.ToolErrorResult(err), nil
+	}
+
+	_, err = getAVUEntityTypes(args.EntityType)
+	if err != nil {
+		outputErr := errors.Wrapf(err, "invalid entity type")
+		return irods_common.ToolErrorResult(outputErr), nil
+	}
+
+	// auth
+	authValue, err := common.GetAuthValue(ctx)
+	if err != nil {
+		outputErr := errors.Wrapf(err, "failed to get auth value")
+		return irods_common.ToolErrorResult(outputErr), nil
+	}
+
+	// make a irods filesystem client
+	fs, err := t.mcpServer.GetIRODSFSClientFromAuthValue(&authValue)
+	if err != nil {
+		outputErr := errors.Wrapf(err, "failed to create a irods fs client")
+		return irods_common.ToolErrorResult(outputErr), nil
+	}
+
+	storagePath, err := getSavedQueriesPath(t.config, fs.GetAccount())
+	if err != nil {
+		return irods_common.ToolErrorResult(err), nil
+	}
+
+	searchPaths := []string{}
+	for _, argPath := range args.Paths {
+		searchPath := irods_common.MakeIRODSPath(t.config, fs.GetAccount(), argPath)
+
+		// check permission
+		if !irods_common.IsAccessAllowed(searchPath, t.GetAccessiblePaths(&authValue)) {
+			outputErr := errors.Newf("%q request is not permitted for path %q", t.GetName(), searchPath)
+			return irods_common.ToolErrorResult(outputErr), nil
+		}
+
+		searchPaths = append(searchPaths, searchPath)
+	}
+
+	now := time.Now().UTC()
+	query := model.SavedQuery{
+		Name:        args.Name,
+		Description: args.Description,
+		Conditions:  rootCondition,
+		NamePattern: args.NamePattern,
+		Paths:       searchPaths,
+		EntityType:  strings.ToLower(args.EntityType),
+		CreatedAt:   now,
+		ModifiedAt:  now,
+	}
+
+	savedQueriesLock.Lock()
+	defer savedQueriesLock.Unlock()
+
+	savedQueries, err := loadSavedQueries(fs, storagePath)
+	if err != nil {
+		outputErr := errors.Wrapf(err, "failed to load saved queries")
+		return irods_common.ToolErrorResult(outputErr), nil
+	}
+
+	replaced := false
+	idx := findSavedQuery(savedQueries, args.Name)
+	if idx >= 0 {
+		if !args.Overwrite {
+			outputErr := errors.Newf("query %q already exists, set overwrite to replace it", args.Name)
+			return irods_common.ToolErrorResult(outputErr), nil
+		}
+
+		query.CreatedAt = savedQueries.Queries[idx].CreatedAt
+		savedQueries.Queries[idx] = query
+		replaced = true
+	} else {
+		if len(savedQueries.Queries) >= savedQueriesMaxCount {
+			outputErr := errors.Newf("cannot save more than %d queries, delete unused ones first", savedQueriesMaxCount)
+			return irods_common.ToolErrorResult(outputErr), nil
+		}
+
+		savedQueries.Queries = append(savedQueries.Queries, query)
+	}
+
+	err = storeSavedQueries(fs, storagePath, savedQueries)
+	if err != nil {
+		outputErr := errors.Wrapf(err, "failed to store saved queries")
+		return irods_common.ToolErrorResult(outputErr), nil
+	}
+
+	content := model.SaveQueryOutput{
+		Query:       query,
+		ResourceURI: irods_common.MakeSavedQueryURI(query.Name),
+		Replaced:    replaced,
+		StoragePath: storagePath,
+	}
+
+	return irods_common.ToolJSONResult(content)
+}
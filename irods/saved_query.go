@@ -0,0 +1,252 @@
+package irods
+
+import (
+	"encoding/json"
+	"path"
+	"regexp"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/cockroachdb/errors"
+	irodsclient_fs "github.com/cyverse/go-irodsclient/fs"
+	irodsclient_types "github.com/cyverse/go-irodsclient/irods/types"
+	"github.com/cyverse/irods-mcp-server/common"
+	irods_common "github.com/cyverse/irods-mcp-server/irods/common"
+	"github.com/cyverse/irods-mcp-server/irods/model"
+)
+
+const (
+	savedQueriesFilename    = ".irods-mcp-server-queries.json"
+	savedQueriesMaxSize     = irods_common.MaxInlineSize
+	savedQueriesMaxCount    = 200
+	savedQueryMaxResults    = 500 // entries returned when reading a saved query resource
+	savedQueryMaxNameLength = 64
+)
+
+var savedQueryNameRegexp = regexp.MustCompile(`^[A-Za-z0-9][A-Za-z0-9._-]*$`)
+
+// savedQueriesLock serializes read-modify-write of saved queries files in this server
+var savedQueriesLock sync.Mutex
+
+// getSavedQueriesPath returns the path of the data object storing the user's saved queries
+func getSavedQueriesPath(config *common.Config, account *irodsclient_types.IRODSAccount) (string, error) {
+	if account.IsAnonymousUser() {
+		return "", errors.New("saved queries are not available for anonymous users")
+	}
+
+	homePath := irods_common.GetHomePath(config, account)
+	return path.Join(homePath, savedQueriesFilename), nil
+}
+
+// validateSavedQueryName checks that the name can be used in a resource URI
+func validateSavedQueryName(name string) error {
+	if len(name) == 0 {
+		return errors.New("query name is empty")
+	}
+
+	if len(name) > savedQueryMaxNameLength {
+		return errors.Newf("query name %q is longer than %d characters", name, savedQueryMaxNameLength)
+	}
+
+	if !savedQueryNameRegexp.MatchString(name) {
+		return errors.Newf("query name %q must start with a letter or digit and contain only letters, digits, '.', '_' and '-'", name)
+	}
+
+	return nil
+}
+
+// validateSavedQueryNamePattern checks that the glob pattern is well-formed and matches names, not paths
+func validateSavedQueryNamePattern(pattern string) error {
+	if len(pattern) == 0 {
+		return nil
+	}
+
+	if strings.Contains(pattern, "/") {
+		return errors.Newf("name pattern %q must not contain '/'", pattern)
+	}
+
+	_, err := path.Match(pattern, "")
+	if err != nil {
+		return errors.Wrapf(err, "invalid name pattern %q", pattern)
+	}
+
+	return nil
+}
+
+// loadSavedQueries reads saved queries of the user, a missing file means no saved queries
+func loadSavedQueries(fs *irodsclient_fs.FileSystem, storagePath string) (*model.SavedQueries, error) {
+	savedQueries := &model.SavedQueries{
+		Queries: []model.SavedQuery{},
+	}
+
+	entry, err := fs.Stat(storagePath)
+	if err != nil {
+		if irodsclient_types.IsFileNotFoundError(err) {
+			return savedQueries, nil
+		}
+		return nil, errors.Wrapf(err, "failed to stat %q", storagePath)
+	}
+
+	if entry.IsDir() {
+		return nil, errors.Newf("%q is a directory (collection)", storagePath)
+	}
+
+	if entry.Size > savedQueriesMaxSize {
+		return nil, errors.Newf("saved queries file %q is too large (%d bytes)", storagePath, entry.Size)
+	}
+
+	content, err := irods_common.ReadDataObject(fs, storagePath, 0, savedQueriesMaxSize)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(content) == 0 {
+		return savedQueries, nil
+	}
+
+	err = json.Unmarshal(content, savedQueries)
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to parse saved queries file %q", storagePath)
+	}
+
+	if savedQueries.Queries == nil {
+		savedQueries.Queries = []model.SavedQuery{}
+	}
+
+	return savedQueries, nil
+}
+
+// storeSavedQueries writes saved queries of the user, sorted by name
+func storeSavedQueries(fs *irodsclient_fs.FileSystem, storagePath string, savedQueries *model.SavedQueries) error {
+	sort.Slice(savedQueries.Queries, func(i, j int) bool {
+		return savedQueries.Queries[i].Name < savedQueries.Queries[j].Name
+	})
+
+	content, err := json.MarshalIndent(savedQueries, "", "  ")
+	if err != nil {
+		return errors.Wrapf(err, "failed to marshal saved queries")
+	}
+
+	if int64(len(content)) > savedQueriesMaxSize {
+		return errors.Newf("saved queries exceed %d bytes", savedQueriesMaxSize)
+	}
+
+	return irods_common.WriteDataObject(fs, storagePath, irods_common.WriteModeOverwrite, 0, content)
+}
+
+// findSavedQuery returns the index of the saved query with the name, or -1
+func findSavedQuery(savedQueries *model.SavedQueries, name string) int {
+	for idx, query := range savedQueries.Queries {
+		if query.Name == name {
+			return idx
+		}
+	}
+	return -1
+}
+
+// runSavedQuery searches entries matching the saved query, up to savedQueryMaxResults
+// matches are filtered by the name pattern and accessible paths before counting, so the total counts returned entries only
+func runSavedQuery(pathSearch *SearchFilesByAVU, fs *irodsclient_fs.FileSystem, accessiblePaths []string, query *model.SavedQuery) (*model.SavedQueryResultOutput, error) {
+	if query.Conditions == nil {
+		return nil, errors.Newf("saved query %q has no conditions", query.Name)
+	}
+
+	// the stored conditions may have been edited by hand
+	rootCondition := *query.Conditions
+	err := normalizeAVUCondition(&rootCondition, 0)
+	if err != nil {
+		return nil, errors.Wrapf(err, "invalid conditions in saved query %q", query.Name)
+	}
+
+	err = validateSavedQueryNamePattern(query.NamePattern)
+	if err != nil {
+		return nil, errors.Wrapf(err, "invalid name pattern in saved query %q", query.Name)
+	}
+
+	entityTypes, err := getAVUEntityTypes(query.EntityType)
+	if err != nil {
+		return nil, errors.Wrapf(err, "invalid entity type in saved query %q", query.Name)
+	}
+
+	// search only under accessible paths
+	searchRoots := irods_common.GetAccessibleRoots(accessiblePaths)
+	if len(query.Paths) > 0 {
+		for _, queryPath := range query.Paths {
+			// check permission
+			if !irods_common.IsAccessAllowed(queryPath, accessiblePaths) {
+				return nil, errors.Newf("saved query %q is not permitted for path %q", query.Name, queryPath)
+			}
+		}
+
+		searchRoots = query.Paths
+	}
+
+	if len(searchRoots) == 0 {
+		return nil, errors.Newf("no path is accessible for saved query %q", query.Name)
+	}
+
+	queriedAt := time.Now().UTC()
+
+	avuSearch := &avuQuery{
+		fs:          fs,
+		entityTypes: entityTypes,
+		roots:       searchRoots,
+	}
+
+	matches, total, err := avuSearch.search(&rootCondition, 0, maxAVUConditionMatches)
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to run saved query %q", query.Name)
+	}
+
+	// matches beyond the limit are not filtered
+	truncated := total > len(matches)
+
+	outputEntries := []model.EntryWithAccess{}
+	matched := 0
+	for _, match := range matches {
+		// check permission
+		// filter out entries not in accessible paths
+		if !irods_common.IsAccessAllowed(match.path, accessiblePaths) {
+			continue
+		}
+
+		if len(query.NamePattern) > 0 {
+			nameMatched, _ := path.Match(query.NamePattern, path.Base(match.path))
+			if !nameMatched {
+				continue
+			}
+		}
+
+		matched++
+		if len(outputEntries) >= savedQueryMaxResults {
+			truncated = true
+			continue
+		}
+
+		entry, err := fs.Stat(match.path)
+		if err != nil {
+			if irodsclient_types.IsFileNotFoundError(err) {
+				// removed after the query
+				matched--
+				continue
+			}
+			return nil, errors.Wrapf(err, "failed to stat %q", match.path)
+		}
+
+		outputEntries = append(outputEntries, model.EntryWithAccess{
+			Entry:       entry,
+			ResourceURI: irods_common.MakeResourceURI(entry.Path),
+			WebDAVURI:   irods_common.MakeWebdavURL(pathSearch.config, entry.Path, fs.GetAccount()),
+		})
+	}
+
+	return &model.SavedQueryResultOutput{
+		Query:           *query,
+		MatchingEntries: outputEntries,
+		Total:           matched,
+		Truncated:       truncated,
+		QueriedAt:       queriedAt,
+	}, nil
+}
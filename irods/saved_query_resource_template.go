@@ -0,0 +1,123 @@
+package irods
+
+import (
+	"context"
+	"net/url"
+	"strings"
+
+	"github.com/cockroachdb/errors"
+	"github.com/cyverse/irods-mcp-server/common"
+	irods_common "github.com/cyverse/irods-mcp-server/irods/common"
+	"github.com/modelcontextprotocol/go-sdk/mcp"
+)
+
+const (
+	SavedQueryResourceTemplateName = "iRODS Saved Query"
+)
+
+type SavedQueryResourceTemplate struct {
+	mcpServer *IRODSMCPServer
+	config    *common.Config
+	// provides accessible paths of the saved AVU search
+	pathSearch *SearchFilesByAVU
+}
+
+func NewSavedQueryResourceTemplate(svr *IRODSMCPServer) ResourceTemplateAPI {
+	return &SavedQueryResourceTemplate{
+		mcpServer: svr,
+		config:    svr.GetConfig(),
+		pathSearch: &SearchFilesByAVU{
+			mcpServer: svr,
+			config:    svr.GetConfig(),
+		},
+	}
+}
+
+func (r *SavedQueryResourceTemplate) GetScheme() string {
+	return irods_common.SavedQueryScheme
+}
+
+func (r *SavedQueryResourceTemplate) GetURITemplate() string {
+	return r.GetScheme() + "://{name}"
+}
+
+func (r *SavedQueryResourceTemplate) GetName() string {
+	return SavedQueryResourceTemplateName
+}
+
+func (r *SavedQueryResourceTemplate) GetDescription() string {
+	return `Current results of a saved query on the iRODS, the files (data-objects) and directories (collections) matching its AVU conditions`
+}
+
+func (r *SavedQueryResourceTemplate) GetResourceTemplate() *mcp.ResourceTemplate {
+	return &mcp.ResourceTemplate{
+		Name:        r.GetName(),
+		Description: r.GetDescription(),
+		URITemplate: r.GetURITemplate(),
+	}
+}
+
+func (r *SavedQueryResourceTemplate) GetHandler() mcp.ResourceHandler {
+	return r.Handler
+}
+
+func (r *SavedQueryResourceTemplate) GetAccessiblePaths(authValue *common.AuthValue) []string {
+	return r.pathSearch.GetAccessiblePaths(authValue)
+}
+
+func (r *SavedQueryResourceTemplate) Handler(ctx context.Context, request *mcp.ReadResourceRequest) (*mcp.ReadResourceResult, error) {
+	uri, err := url.PathUnescape(request.Params.URI)
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to unescape URI %q", request.Params.URI)
+	}
+
+	parsedURL, err := url.Parse(uri)
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to parse URI %q", uri)
+	}
+
+	// Check if the URI is valid
+	if strings.ToLower(parsedURL.Scheme) != r.GetScheme() {
+		return nil, errors.Newf("unsupported URI scheme %q", parsedURL.Scheme)
+	}
+
+	name := strings.Trim(parsedURL.Host+parsedURL.Path, "/")
+	err = validateSavedQueryName(name)
+	if err != nil {
+		return nil, err
+	}
+
+	// auth
+	authValue, err := common.GetAuthValue(ctx)
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to get auth value")
+	}
+
+	// make a irods filesystem client
+	fs, err := r.mcpServer.GetIRODSFSClientFromAuthValue(&authValue)
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to create a irods fs client")
+	}
+
+	storagePath, err := getSavedQueriesPath(r.config, fs.GetAccount())
+	if err != nil {
+		return nil, err
+	}
+
+	savedQueries, err := loadSavedQueries(fs, storagePath)
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to load saved queries")
+	}
+
+	idx := findSavedQuery(savedQueries, name)
+	if idx < 0 {
+		return nil, errors.Newf("saved query %q is not found", name)
+	}
+
+	output, err := runSavedQuery(r.pathSearch, fs, r.GetAccessiblePaths(&authValue), &savedQueries.Queries[idx])
+	if err != nil {
+		return nil, err
+	}
+
+	return irods_common.ResourceJSONResult(uri, output)
+}
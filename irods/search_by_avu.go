@@ -0,0 +1,457 @@
+package irods
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/cockroachdb/errors"
+	irodsclient_fs "github.com/cyverse/go-irodsclient/fs"
+	irodsclient_common "github.com/cyverse/go-irodsclient/irods/common"
+	"github.com/cyverse/irods-mcp-server/common"
+	irods_common "github.com/cyverse/irods-mcp-server/irods/common"
+	"github.com/cyverse/irods-mcp-server/irods/model"
+	"github.com/google/jsonschema-go/jsonschema"
+	"github.com/modelcontextprotocol/go-sdk/mcp"
+)
+
+const (
+	SearchByAVUName = irods_common.IRODSAPIPrefix + "search_by_avu"
+
+	searchByAVULimitDefault int = 100
+	searchByAVUMaxLimit     int = 500
+	searchByAVUDetailBatch  int = 50 // names per catalog query when reading details of matches
+
+	searchByAVUTargetPath     = "path"
+	searchByAVUTargetResource = "resource"
+	searchByAVUTargetUser     = "user"
+)
+
+type SearchByAVUInputArgs struct {
+	TargetType string               `json:"target_type,omitempty"`
+	Attribute  string               `json:"attribute,omitempty"`
+	Value      string               `json:"value,omitempty"`
+	Conditions []model.AVUCondition `json:"conditions,omitempty"`
+	Match      string               `json:"match,omitempty"`
+	Path       string               `json:"path,omitempty"`
+	EntityType string               `json:"entity_type,omitempty"`
+	Offset     int                  `json:"offset,omitempty"`
+	Limit      int                  `json:"limit,omitempty"`
+	Cursor     string               `json:"cursor,omitempty"`
+}
+
+type SearchByAVU struct {
+	mcpServer *IRODSMCPServer
+	config    *common.Config
+	// searches files (data-objects) and directories (collections) for the path target type
+	pathSearch *SearchFilesByAVU
+}
+
+func NewSearchByAVU(svr *IRODSMCPServer) ToolAPI {
+	return &SearchByAVU{
+		mcpServer: svr,
+		config:    svr.GetConfig(),
+		pathSearch: &SearchFilesByAVU{
+			mcpServer: svr,
+			config:    svr.GetConfig(),
+		},
+	}
+}
+
+func (t *SearchByAVU) GetName() string {
+	return SearchByAVUName
+}
+
+func (t *SearchByAVU) GetDescription() string {
+	return `Search for files (data-objects) and directories (collections), resources, or users matching iRODS AVU (attribute-value-units) conditions.
+	Use target_type to choose what to search for, 'path', 'resource' or 'user', as in list_avus.
+	Use attribute and value for a simple exact match, or conditions for operators (=, !=, like, not like, <, <=, >, >=, between, in), unit matching and numeric comparison.
+	Conditions are combined with match ('all' or 'any'), and a condition with nested conditions forms a group with its own match.
+	Path and entity_type only apply to the 'path' target type. Users are returned as name#zone.
+	Results are paginated, pass next_cursor to get the next page. Resources and users are returned with their AVUs.`
+}
+
+func (t *SearchByAVU) GetTool() *mcp.Tool {
+	// same arguments as search_files_by_avu, with the target type
+	pathSchema := t.pathSearch.GetTool().InputSchema.(*jsonschema.Schema)
+
+	properties := map[string]*jsonschema.Schema{
+		"target_type": {
+			Type:        "string",
+			Description: "The type of targets to search for.",
+			Enum:        []interface{}{searchByAVUTargetPath, searchByAVUTargetResource, searchByAVUTargetUser},
+			Default:     json.RawMessage(`"path"`),
+		},
+	}
+	for name, property := range pathSchema.Properties {
+		properties[name] = property
+	}
+
+	properties["path"] = &jsonschema.Schema{
+		Type:        "string",
+		Description: "For the 'path' target type, the collection path to search in. Omit to search everywhere accessible.",
+	}
+	properties["entity_type"] = &jsonschema.Schema{
+		Type:        "string",
+		Description: "For the 'path' target type, the type of entries to return.",
+		Enum:        []interface{}{avuEntityAll, avuEntityDataObject, avuEntityCollection},
+		Default:     json.RawMessage(`"all"`),
+	}
+	properties["limit"] = &jsonschema.Schema{
+		Type:        "number",
+		Description: fmt.Sprintf("Maximum number of matches to return (for pagination). Default: %d, max: %d.", searchByAVULimitDefault, searchByAVUMaxLimit),
+		Default:     json.RawMessage(fmt.Sprintf("%d", searchByAVULimitDefault)),
+	}
+
+	return &mcp.Tool{
+		Name:        t.GetName(),
+		Description: t.GetDescription(),
+		InputSchema: &jsonschema.Schema{
+			Type:       "object",
+			Properties: properties,
+		},
+	}
+}
+
+func (t *SearchByAVU) GetHandler() mcp.ToolHandler {
+	return t.Handler
+}
+
+func (t *SearchByAVU) GetAccessiblePaths(authValue *common.AuthValue) []string {
+	return t.pathSearch.GetAccessiblePaths(authValue)
+}
+
+func (t *SearchByAVU) Handler(ctx context.Context, request *mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	// arguments
+	args := SearchByAVUInputArgs{}
+	err := irods_common.MarshalInputArguments(t.GetTool(), request, &args)
+	if err != nil {
+		outputErr := errors.Wrapf(err, "failed to marshal input arguments")
+		return irods_common.ToolErrorResult(outputErr), nil
+	}
+
+	// auth
+	authValue, err := common.GetAuthValue(ctx)
+	if err != nil {
+		outputErr := errors.Wrapf(err, "failed to get auth value")
+		return irods_common.ToolErrorResult(outputErr), nil
+	}
+
+	// make a irods filesystem client
+	fs, err := t.mcpServer.GetIRODSFSClientFromAuthValue(&authValue)
+	if err != nil {
+		outputErr := errors.Wrapf(err, "failed to create a irods fs client")
+		return irods_common.ToolErrorResult(outputErr), nil
+	}
+
+	targetType := strings.ToLower(args.TargetType)
+	if len(targetType) == 0 {
+		targetType = searchByAVUTargetPath
+	}
+
+	rootCondition := makeAVURootCondition(args.Attribute, args.Value, args.Match, args.Conditions)
+	if len(rootCondition.Conditions) == 0 {
+		outputErr := errors.Newf("either attribute or conditions must be given")
+		return irods_common.ToolErrorResult(outputErr), nil
+	}
+
+	err = normalizeAVUCondition(rootCondition, 0)
+	if err != nil {
+		outputErr := errors.Wrapf(err, "invalid conditions")
+		return irods_common.ToolErrorResult(outputErr), nil
+	}
+
+	accessiblePaths := t.GetAccessiblePaths(&authValue)
+
+	searchPath := ""
+	searchRoots := []string{}
+	var entityTypes []string
+	switch targetType {
+	case searchByAVUTargetPath:
+		entityTypes, err = getAVUEntityTypes(args.EntityType)
+		if err != nil {
+			outputErr := errors.Wrapf(err, "invalid entity type")
+			return irods_common.ToolErrorResult(outputErr), nil
+		}
+
+		// search only under accessible paths
+		searchRoots = irods_common.GetAccessibleRoots(accessiblePaths)
+		if len(args.Path) > 0 {
+			searchPath = irods_common.MakeIRODSPath(t.config, fs.GetAccount(), args.Path)
+
+			// check permission
+			if !irods_common.IsAccessAllowed(searchPath, accessiblePaths) {
+				outputErr := errors.Newf("%q request is not permitted for path %q", t.GetName(), searchPath)
+				return irods_common.ToolErrorResult(outputErr), nil
+			}
+
+			searchRoots = []string{searchPath}
+		}
+
+		if len(searchRoots) == 0 {
+			outputErr := errors.Newf("no path is accessible for %q request", t.GetName())
+			return irods_common.ToolErrorResult(outputErr), nil
+		}
+	case searchByAVUTargetResource, searchByAVUTargetUser:
+		if len(args.Path) > 0 {
+			outputErr := errors.Newf("path cannot be used for %q target type", targetType)
+			return irods_common.ToolErrorResult(outputErr), nil
+		}
+
+		if len(args.EntityType) > 0 && strings.ToLower(args.EntityType) != avuEntityAll {
+			outputErr := errors.Newf("entity type cannot be used for %q target type", targetType)
+			return irods_common.ToolErrorResult(outputErr), nil
+		}
+
+		entityTypes = []string{targetType}
+	default:
+		outputErr := errors.Newf("invalid target_type %q", args.TargetType)
+		return irods_common.ToolErrorResult(outputErr), nil
+	}
+
+	// apply pagination defaults/bounds
+	offset := args.Offset
+	if offset < 0 {
+		offset = 0
+	}
+	limit := args.Limit
+	if limit <= 0 {
+		limit = searchByAVULimitDefault
+	} else if limit > searchByAVUMaxLimit {
+		limit = searchByAVUMaxLimit
+	}
+
+	fingerprint := irods_common.MakePageFingerprint(targetType, rootCondition, entityTypes, searchRoots)
+	if len(args.Cursor) > 0 {
+		offset, err = irods_common.DecodePageCursor(args.Cursor, fingerprint)
+		if err != nil {
+			outputErr := errors.Wrapf(err, "failed to decode cursor")
+			return irods_common.ToolErrorResult(outputErr), nil
+		}
+	}
+
+	// search
+	var content *model.SearchByAVUOutput
+	switch targetType {
+	case searchByAVUTargetPath:
+		content, err = t.searchPaths(fs, accessiblePaths, rootCondition, entityTypes, searchRoots, offset, limit)
+	case searchByAVUTargetResource:
+		content, err = t.searchResources(fs, rootCondition, offset, limit)
+	case searchByAVUTargetUser:
+		content, err = t.searchUsers(fs, rootCondition, offset, limit)
+	}
+	if err != nil {
+		outputErr := errors.Wrapf(err, "failed to search %ss matching AVU conditions", targetType)
+		return irods_common.ToolErrorResult(outputErr), nil
+	}
+
+	content.TargetType = targetType
+	content.SearchConditions = rootCondition
+	content.SearchPath = searchPath
+	if targetType == searchByAVUTargetPath && len(args.EntityType) > 0 {
+		content.EntityType = strings.ToLower(args.EntityType)
+	}
+
+	if offset+limit < content.Total {
+		content.NextCursor = irods_common.EncodePageCursor(offset+limit, fingerprint)
+	}
+
+	return irods_common.ToolJSONResult(*content)
+}
+
+func (t *SearchByAVU) searchPaths(fs *irodsclient_fs.FileSystem, accessiblePaths []string, rootCondition *model.AVUCondition, entityTypes []string, searchRoots []string, offset int, limit int) (*model.SearchByAVUOutput, error) {
+	pathContent, err := t.pathSearch.search(fs, accessiblePaths, rootCondition, entityTypes, searchRoots, offset, limit)
+	if err != nil {
+		return nil, err
+	}
+
+	return &model.SearchByAVUOutput{
+		EntityType:      pathContent.EntityType,
+		MatchingEntries: pathContent.MatchingEntries,
+		Total:           pathContent.Total,
+		Offset:          offset,
+		Limit:           limit,
+	}, nil
+}
+
+func (t *SearchByAVU) searchResources(fs *irodsclient_fs.FileSystem, rootCondition *model.AVUCondition, offset int, limit int) (*model.SearchByAVUOutput, error) {
+	query := &avuQuery{
+		fs:          fs,
+		entityTypes: []string{avuEntityResource},
+	}
+
+	matches, total, err := query.search(rootCondition, offset, limit)
+	if err != nil {
+		return nil, err
+	}
+
+	names := make([]string, 0, len(matches))
+	for _, match := range matches {
+		names = append(names, match.path)
+	}
+
+	// details and AVUs of the resources in the page
+	rows, err := queryAVUEntityDetails(fs, names, irodsclient_common.ICAT_COLUMN_R_RESC_NAME, []irodsclient_common.ICATColumnNumber{
+		irodsclient_common.ICAT_COLUMN_R_RESC_NAME,
+		irodsclient_common.ICAT_COLUMN_R_ZONE_NAME,
+		irodsclient_common.ICAT_COLUMN_R_TYPE_NAME,
+		irodsclient_common.ICAT_COLUMN_R_CLASS_NAME,
+		irodsclient_common.ICAT_COLUMN_META_RESC_ATTR_ID,
+		irodsclient_common.ICAT_COLUMN_META_RESC_ATTR_NAME,
+		irodsclient_common.ICAT_COLUMN_META_RESC_ATTR_VALUE,
+		irodsclient_common.ICAT_COLUMN_META_RESC_ATTR_UNITS,
+	})
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to read resources")
+	}
+
+	resources := map[string]*model.AVUResourceEntry{}
+	for _, row := range rows {
+		resource, ok := resources[row[0]]
+		if !ok {
+			resource = &model.AVUResourceEntry{
+				Name:  row[0],
+				Zone:  row[1],
+				Type:  row[2],
+				Class: row[3],
+				AVUs:  []model.AVU{},
+			}
+			resources[row[0]] = resource
+		}
+
+		resource.AVUs = append(resource.AVUs, makeAVUFromRow(row[4:]))
+	}
+
+	outputResources := []model.AVUResourceEntry{}
+	for _, name := range names {
+		if resource, ok := resources[name]; ok {
+			outputResources = append(outputResources, *resource)
+		}
+	}
+
+	return &model.SearchByAVUOutput{
+		MatchingResources: outputResources,
+		Total:             total,
+		Offset:            offset,
+		Limit:             limit,
+	}, nil
+}
+
+func (t *SearchByAVU) searchUsers(fs *irodsclient_fs.FileSystem, rootCondition *model.AVUCondition, offset int, limit int) (*model.SearchByAVUOutput, error) {
+	query := &avuQuery{
+		fs:          fs,
+		entityTypes: []string{avuEntityUser},
+	}
+
+	matches, total, err := query.search(rootCondition, offset, limit)
+	if err != nil {
+		return nil, err
+	}
+
+	userNames := make([]string, 0, len(matches))
+	names := []string{}
+	seenNames := map[string]bool{}
+	for _, match := range matches {
+		userNames = append(userNames, match.path)
+
+		name, _, _ := strings.Cut(match.path, "#")
+		if !seenNames[name] {
+			seenNames[name] = true
+			names = append(names, name)
+		}
+	}
+
+	// details and AVUs of the users in the page, users of other zones with the same names are left out below
+	rows, err := queryAVUEntityDetails(fs, names, irodsclient_common.ICAT_COLUMN_USER_NAME, []irodsclient_common.ICATColumnNumber{
+		irodsclient_common.ICAT_COLUMN_USER_NAME,
+		irodsclient_common.ICAT_COLUMN_USER_ZONE,
+		irodsclient_common.ICAT_COLUMN_USER_TYPE,
+		irodsclient_common.ICAT_COLUMN_META_USER_ATTR_ID,
+		irodsclient_common.ICAT_COLUMN_META_USER_ATTR_NAME,
+		irodsclient_common.ICAT_COLUMN_META_USER_ATTR_VALUE,
+		irodsclient_common.ICAT_COLUMN_META_USER_ATTR_UNITS,
+	})
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to read users")
+	}
+
+	users := map[string]*model.AVUUserEntry{}
+	for _, row := range rows {
+		userName := row[0] + "#" + row[1]
+		user, ok := users[userName]
+		if !ok {
+			user = &model.AVUUserEntry{
+				Name: row[0],
+				Zone: row[1],
+				Type: row[2],
+				AVUs: []model.AVU{},
+			}
+			users[userName] = user
+		}
+
+		user.AVUs = append(user.AVUs, makeAVUFromRow(row[3:]))
+	}
+
+	outputUsers := []model.AVUUserEntry{}
+	for _, userName := range userNames {
+		if user, ok := users[userName]; ok {
+			outputUsers = append(outputUsers, *user)
+		}
+	}
+
+	return &model.SearchByAVUOutput{
+		MatchingUsers: outputUsers,
+		Total:         total,
+		Offset:        offset,
+		Limit:         limit,
+	}, nil
+}
+
+// queryAVUEntityDetails selects columns of entities with the given names, in batches
+func queryAVUEntityDetails(fs *irodsclient_fs.FileSystem, names []string, nameColumn irodsclient_common.ICATColumnNumber, columns []irodsclient_common.ICATColumnNumber) ([][]string, error) {
+	rows := [][]string{}
+
+	for start := 0; start < len(names); start += searchByAVUDetailBatch {
+		end := start + searchByAVUDetailBatch
+		if end > len(names) {
+			end = len(names)
+		}
+
+		nameCondition, err := irods_common.MakeGenQueryValueCondition(avuOperatorIn, "", names[start:end])
+		if err != nil {
+			return nil, err
+		}
+
+		selects := make([]irods_common.GenQueryColumn, 0, len(columns))
+		for _, column := range columns {
+			selects = append(selects, irods_common.GenQueryColumn{Column: column, Option: irods_common.GenQuerySelectNormal})
+		}
+
+		result, err := irods_common.RunGenQuery(fs, &irods_common.GenQuery{
+			Selects: selects,
+			Conditions: []irods_common.GenQueryCondition{
+				{Column: nameColumn, Condition: nameCondition},
+			},
+		})
+		if err != nil {
+			return nil, err
+		}
+
+		rows = append(rows, result.Rows...)
+	}
+
+	return rows, nil
+}
+
+// makeAVUFromRow makes an AVU from id, attribute, value and unit columns
+func makeAVUFromRow(row []string) model.AVU {
+	id, _ := strconv.ParseInt(row[0], 10, 64)
+	return model.AVU{
+		ID:        id,
+		Attribute: row[1],
+		Value:     row[2],
+		Unit:      row[3],
+	}
+}
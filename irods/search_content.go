@@ -0,0 +1,326 @@
+package irods
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/cockroachdb/errors"
+	irodsclient_fs "github.com/cyverse/go-irodsclient/fs"
+	irodsclient_types "github.com/cyverse/go-irodsclient/irods/types"
+	"github.com/cyverse/irods-mcp-server/common"
+	irods_common "github.com/cyverse/irods-mcp-server/irods/common"
+	"github.com/cyverse/irods-mcp-server/irods/index"
+	"github.com/cyverse/irods-mcp-server/irods/model"
+	"github.com/google/jsonschema-go/jsonschema"
+	"github.com/modelcontextprotocol/go-sdk/mcp"
+)
+
+const (
+	SearchContentName = irods_common.IRODSAPIPrefix + "search_content"
+
+	searchContentLimitDefault    int = 10
+	searchContentMaxLimit        int = 50
+	searchContentSnippetsDefault int = 3
+	searchContentMaxSnippets     int = 10
+	searchContentMaxChecks       int = 1000 // ranked hits to check against the user's permissions per call
+
+	searchContentMatchAll = "all"
+	searchContentMatchAny = "any"
+)
+
+type SearchContentInputArgs struct {
+	Query       string `json:"query"`
+	Path        string `json:"path,omitempty"`
+	Match       string `json:"match,omitempty"`
+	MaxSnippets int    `json:"max_snippets,omitempty"`
+	Offset      int    `json:"offset,omitempty"`
+	Limit       int    `json:"limit,omitempty"`
+	Cursor      string `json:"cursor,omitempty"`
+}
+
+type SearchContent struct {
+	mcpServer *IRODSMCPServer
+	config    *common.Config
+}
+
+func NewSearchContent(svr *IRODSMCPServer) ToolAPI {
+	return &SearchContent{
+		mcpServer: svr,
+		config:    svr.GetConfig(),
+	}
+}
+
+func (t *SearchContent) GetName() string {
+	return SearchContentName
+}
+
+func (t *SearchContent) GetDescription() string {
+	return `Search the text content of files (data-objects) using the server's full-text index.
+	The index covers text files (and notebooks or PDFs if enabled) under indexed collections and is refreshed periodically, so recent changes may be missing.
+	Files are ranked by relevance to the query words and returned with snippets of matching text. Only files the user has read access to are returned.
+	If truncated is true, pass next_cursor to get the next page. A page may have fewer hits than the limit, or none, when many files are not readable.`
+}
+
+func (t *SearchContent) GetTool() *mcp.Tool {
+	return &mcp.Tool{
+		Name:        t.GetName(),
+		Description: t.GetDescription(),
+		InputSchema: &jsonschema.Schema{
+			Type: "object",
+			Properties: map[string]*jsonschema.Schema{
+				"query": {
+					Type:        "string",
+					Description: "The words to search for. Words are matched case-insensitively, punctuation is ignored.",
+				},
+				"path": {
+					Type:        "string",
+					Description: "The iRODS path of a directory (collection) to search under. Searches all accessible files if not given.",
+				},
+				"match": {
+					Type:        "string",
+					Description: "Whether files must contain all words or any of the words.",
+					Enum:        []interface{}{searchContentMatchAll, searchContentMatchAny},
+					Default:     json.RawMessage(`"all"`),
+				},
+				"max_snippets": {
+					Type:        "number",
+					Description: fmt.Sprintf("Maximum number of snippets per file. Default: %d, max: %d.", searchContentSnippetsDefault, searchContentMaxSnippets),
+					Default:     json.RawMessage(fmt.Sprintf("%d", searchContentSnippetsDefault)),
+				},
+				"offset": {
+					Type:        "number",
+					Description: "Number of matching files to skip (for pagination). Default: 0. Prefer next_cursor for later pages.",
+					Default:     json.RawMessage("0"),
+				},
+				"limit": {
+					Type:        "number",
+					Description: fmt.Sprintf("Maximum number of files to return (for pagination). Default: %d, max: %d.", searchContentLimitDefault, searchContentMaxLimit),
+					Default:     json.RawMessage(fmt.Sprintf("%d", searchContentLimitDefault)),
+				},
+				"cursor": {
+					Type:        "string",
+					Description: "The next_cursor returned by a previous call with the same arguments, to get the next page. Overrides offset.",
+				},
+			},
+			Required: []string{"query"},
+		},
+	}
+}
+
+func (t *SearchContent) GetHandler() mcp.ToolHandler {
+	return t.Handler
+}
+
+func (t *SearchContent) GetAccessiblePaths(authValue *common.AuthValue) []string {
+	account, err := t.mcpServer.GetIRODSAccountFromAuthValue(authValue)
+	if err != nil {
+		return []string{}
+	}
+
+	homePath := irods_common.GetHomePath(t.config, account)
+	sharedPath := irods_common.GetSharedPath(t.config, account)
+
+	paths := []string{
+		sharedPath,
+		sharedPath + "/*",
+	}
+
+	if !account.IsAnonymousUser() {
+		paths = append(paths, homePath)
+		paths = append(paths, homePath+"/*")
+	}
+
+	return paths
+}
+
+func (t *SearchContent) Handler(ctx context.Context, request *mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	// arguments
+	args := SearchContentInputArgs{}
+	err := irods_common.MarshalInputArguments(t.GetTool(), request, &args)
+	if err != nil {
+		outputErr := errors.Wrapf(err, "failed to marshal input arguments")
+		return irods_common.ToolErrorResult(outputErr), nil
+	}
+
+	contentIndex := t.mcpServer.GetContentIndex()
+	if contentIndex == nil {
+		outputErr := errors.New("content index is not enabled")
+		return irods_common.ToolErrorResult(outputErr), nil
+	}
+
+	terms := index.GetTerms(args.Query)
+	if len(terms) == 0 {
+		outputErr := errors.Newf("query %q has no words to search for", args.Query)
+		return irods_common.ToolErrorResult(outputErr), nil
+	}
+
+	matchAll := true
+	switch strings.ToLower(args.Match) {
+	case "", searchContentMatchAll:
+	case searchContentMatchAny:
+		matchAll = false
+	default:
+		outputErr := errors.Newf("unknown match %q", args.Match)
+		return irods_common.ToolErrorResult(outputErr), nil
+	}
+
+	// auth
+	authValue, err := common.GetAuthValue(ctx)
+	if err != nil {
+		outputErr := errors.Wrapf(err, "failed to get auth value")
+		return irods_common.ToolErrorResult(outputErr), nil
+	}
+
+	// make a irods filesystem client
+	fs, err := t.mcpServer.GetIRODSFSClientFromAuthValue(&authValue)
+	if err != nil {
+		outputErr := errors.Wrapf(err, "failed to create a irods fs client")
+		return irods_common.ToolErrorResult(outputErr), nil
+	}
+
+	accessiblePaths := t.GetAccessiblePaths(&authValue)
+
+	irodsPath := ""
+	if len(args.Path) > 0 {
+		irodsPath = irods_common.MakeIRODSPath(t.config, fs.GetAccount(), args.Path)
+
+		// check permission
+		if !irods_common.IsAccessAllowed(irodsPath, accessiblePaths) {
+			outputErr := errors.Newf("%q request is not permitted for path %q", t.GetName(), irodsPath)
+			return irods_common.ToolErrorResult(outputErr), nil
+		}
+	}
+
+	// apply pagination defaults/bounds
+	offset := args.Offset
+	if offset < 0 {
+		offset = 0
+	}
+	limit := args.Limit
+	if limit <= 0 {
+		limit = searchContentLimitDefault
+	} else if limit > searchContentMaxLimit {
+		limit = searchContentMaxLimit
+	}
+	maxSnippets := args.MaxSnippets
+	if maxSnippets <= 0 {
+		maxSnippets = searchContentSnippetsDefault
+	} else if maxSnippets > searchContentMaxSnippets {
+		maxSnippets = searchContentMaxSnippets
+	}
+
+	// the cursor is the position in ranked hits where the next page starts, offset counts readable files
+	hitStart := 0
+	skip := offset
+	fingerprint := irods_common.MakePageFingerprint(terms, matchAll, irodsPath)
+	if len(args.Cursor) > 0 {
+		hitStart, err = irods_common.DecodePageCursor(args.Cursor, fingerprint)
+		if err != nil {
+			outputErr := errors.Wrapf(err, "failed to decode cursor")
+			return irods_common.ToolErrorResult(outputErr), nil
+		}
+		skip = 0
+	}
+
+	filter := func(doc *index.Document) bool {
+		if len(irodsPath) > 0 && !irods_common.IsIRODSSubPath(irodsPath, doc.Path) {
+			return false
+		}
+		return irods_common.IsAccessAllowed(doc.Path, accessiblePaths)
+	}
+
+	hits := contentIndex.Search(terms, matchAll, filter)
+
+	content, nextHit, err := t.makeOutput(fs, contentIndex, hits, terms, maxSnippets, hitStart, skip, limit)
+	if err != nil {
+		outputErr := errors.Wrapf(err, "failed to search content for %q", args.Query)
+		return irods_common.ToolErrorResult(outputErr), nil
+	}
+
+	content.Query = args.Query
+	content.Path = irodsPath
+	content.Offset = offset
+	if nextHit >= 0 {
+		content.Truncated = true
+		content.NextCursor = irods_common.EncodePageCursor(nextHit, fingerprint)
+	}
+
+	return irods_common.ToolJSONResult(*content)
+}
+
+// makeOutput returns readable hits from hitStart in the ranked hits after skipping skip readable ones, and the position of the next page or -1
+// the index is built with the server account, so each hit is checked with the user's client, up to searchContentMaxChecks per call
+func (t *SearchContent) makeOutput(fs *irodsclient_fs.FileSystem, contentIndex *index.ContentIndex, hits []index.SearchHit, terms []string, maxSnippets int, hitStart int, skip int, limit int) (*model.SearchContentOutput, int, error) {
+	outputHits := []model.SearchContentHit{}
+	nextHit := -1
+
+	userNames, err := irods_common.GetAccessUserNames(fs)
+	if err != nil {
+		return nil, -1, err
+	}
+
+	skipped := 0
+	checks := 0
+	for idx := hitStart; idx < len(hits); idx++ {
+		hit := hits[idx]
+
+		if len(outputHits) >= limit || checks >= searchContentMaxChecks {
+			// continue from here in the next page
+			nextHit = idx
+			break
+		}
+		checks++
+
+		// the catalog does not return entries the user has no access to at all
+		entry, err := fs.Stat(hit.Document.Path)
+		if err != nil {
+			if irodsclient_types.IsFileNotFoundError(err) {
+				continue
+			}
+			return nil, -1, errors.Wrapf(err, "failed to stat %q", hit.Document.Path)
+		}
+
+		// entries may be listed to the user with less than read access, e.g., read_metadata
+		readable, err := irods_common.HasReadAccess(fs, entry.Path, userNames)
+		if err != nil {
+			return nil, -1, err
+		}
+
+		if !readable {
+			continue
+		}
+
+		if skipped < skip {
+			skipped++
+			continue
+		}
+
+		snippets, err := contentIndex.MakeSnippets(hit.Document, terms, maxSnippets)
+		if err != nil {
+			return nil, -1, err
+		}
+
+		outputHits = append(outputHits, model.SearchContentHit{
+			Entry:       entry,
+			ResourceURI: irods_common.MakeResourceURI(entry.Path),
+			WebDAVURI:   irods_common.MakeWebdavURL(t.config, entry.Path, fs.GetAccount()),
+			Score:       hit.Score,
+			Snippets:    snippets,
+		})
+	}
+
+	output := &model.SearchContentOutput{
+		Hits:         outputHits,
+		Limit:        limit,
+		IndexedFiles: contentIndex.GetDocumentCount(),
+	}
+
+	indexedAt := contentIndex.GetIndexedAt()
+	if !indexedAt.IsZero() {
+		output.IndexedAt = &indexedAt
+	}
+
+	return output, nextHit, nil
+}
@@ -2,10 +2,15 @@ package irods
 
 import (
 	"context"
+	"encoding/json"
+	"fmt"
+	"path"
 	"strings"
 
 	"github.com/cockroachdb/errors"
 	irodsclient_fs "github.com/cyverse/go-irodsclient/fs"
+	irodsclient_common "github.com/cyverse/go-irodsclient/irods/common"
+	irodsclient_types "github.com/cyverse/go-irodsclient/irods/types"
 	"github.com/cyverse/irods-mcp-server/common"
 	irods_common "github.com/cyverse/irods-mcp-server/irods/common"
 	"github.com/cyverse/irods-mcp-server/irods/model"
@@ -15,10 +20,25 @@ import (
 
 const (
 	SearchFilesName = irods_common.IRODSAPIPrefix + "search_files"
+
+	searchFilesLimitDefault int = 100
+	searchFilesMaxLimit     int = 500
+	searchFilesMaxScanRows  int = 50000 // rows to scan when the pattern cannot be matched by the catalog
+
+	searchFilesTypeAll       = "all"
+	searchFilesTypeFile      = "file"
+	searchFilesTypeDirectory = "directory"
 )
 
 type SearchFilesInputArgs struct {
-	Path string `json:"path"`
+	Path            string `json:"path"`
+	Type            string `json:"type,omitempty"`
+	CaseInsensitive bool   `json:"case_insensitive,omitempty"`
+	Sort            string `json:"sort,omitempty"`
+	Order           string `json:"order,omitempty"`
+	Offset          int    `json:"offset,omitempty"`
+	Limit           int    `json:"limit,omitempty"`
+	Cursor          string `json:"cursor,omitempty"`
 }
 
 type SearchFiles struct {
@@ -39,8 +59,9 @@ func (t *SearchFiles) GetName() string {
 
 func (t *SearchFiles) GetDescription() string {
 	return `Recursively search for files (data-objects) and directories (collections) matching a pattern.
-	The specified search root path must be an iRODS path. Use unix wildcards, such as '?' and '*', for the search pattern. 
-	The matching entries are returned in JSON format.`
+	The specified search root path must be an iRODS path. Use unix wildcards, such as '?' and '*', for the search pattern, wildcards do not match '/'. 
+	Results are sorted by path, name, size or mtime and paginated, directories come before files. If truncated is true, pass next_cursor to get the next page.
+	The matching entries are returned in JSON format with the total number of matches.`
 }
 
 func (t *SearchFiles) GetTool() *mcp.Tool {
@@ -54,6 +75,43 @@ func (t *SearchFiles) GetTool() *mcp.Tool {
 					Type:        "string",
 					Description: "The search path, which may include wildcard patterns such as '?' and '*'.",
 				},
+				"type": {
+					Type:        "string",
+					Description: "The type of entries to search for.",
+					Enum:        []interface{}{searchFilesTypeAll, searchFilesTypeFile, searchFilesTypeDirectory},
+					Default:     json.RawMessage(`"all"`),
+				},
+				"case_insensitive": {
+					Type:        "boolean",
+					Description: "Match the pattern case-insensitively.",
+					Default:     json.RawMessage(`false`),
+				},
+				"sort": {
+					Type:        "string",
+					Description: "The field to sort by. Directories are sorted by path when sorting by size.",
+					Enum:        []interface{}{catalogSortPath, catalogSortName, catalogSortSize, catalogSortMtime},
+					Default:     json.RawMessage(`"path"`),
+				},
+				"order": {
+					Type:        "string",
+					Description: "The sort order.",
+					Enum:        []interface{}{"asc", "desc"},
+					Default:     json.RawMessage(`"asc"`),
+				},
+				"offset": {
+					Type:        "number",
+					Description: "Number of matching entries to skip (for pagination). Default: 0.",
+					Default:     json.RawMessage("0"),
+				},
+				"limit": {
+					Type:        "number",
+					Description: fmt.Sprintf("Maximum number of entries to return (for pagination). Default: %d, max: %d.", searchFilesLimitDefault, searchFilesMaxLimit),
+					Default:     json.RawMessage(fmt.Sprintf("%d", searchFilesLimitDefault)),
+				},
+				"cursor": {
+					Type:        "string",
+					Description: "The next_cursor returned by a previous call with the same arguments, to get the next page. Overrides offset.",
+				},
 			},
 			Required: []string{"path"},
 		},
@@ -127,44 +185,154 @@ func (t *SearchFiles) Handler(ctx context.Context, request *mcp.CallToolRequest)
 		return irods_common.ToolErrorResult(outputErr), nil
 	}
 
+	var searchTypes []string
+	switch strings.ToLower(args.Type) {
+	case "", searchFilesTypeAll:
+		searchTypes = []string{searchFilesTypeDirectory, searchFilesTypeFile}
+	case searchFilesTypeFile:
+		searchTypes = []string{searchFilesTypeFile}
+	case searchFilesTypeDirectory:
+		searchTypes = []string{searchFilesTypeDirectory}
+	default:
+		outputErr := errors.Newf("unknown type %q", args.Type)
+		return irods_common.ToolErrorResult(outputErr), nil
+	}
+
+	sort := strings.ToLower(args.Sort)
+	switch sort {
+	case "":
+		sort = catalogSortPath
+	case catalogSortPath, catalogSortName, catalogSortSize, catalogSortMtime:
+	default:
+		outputErr := errors.Newf("unknown sort field %q", args.Sort)
+		return irods_common.ToolErrorResult(outputErr), nil
+	}
+
+	// apply pagination defaults/bounds
+	offset := args.Offset
+	if offset < 0 {
+		offset = 0
+	}
+	limit := args.Limit
+	if limit <= 0 {
+		limit = searchFilesLimitDefault
+	} else if limit > searchFilesMaxLimit {
+		limit = searchFilesMaxLimit
+	}
+
+	fingerprint := irods_common.MakePageFingerprint(irodsPath, searchTypes, args.CaseInsensitive, sort, args.Order)
+	if len(args.Cursor) > 0 {
+		offset, err = irods_common.DecodePageCursor(args.Cursor, fingerprint)
+		if err != nil {
+			outputErr := errors.Wrapf(err, "failed to decode cursor")
+			return irods_common.ToolErrorResult(outputErr), nil
+		}
+	}
+
 	// search
-	content, err := t.search(fs, irodsPath)
+	content, err := t.search(fs, irodsPath, searchTypes, args.CaseInsensitive, sort, strings.ToLower(args.Order) == "desc", offset, limit)
 	if err != nil {
 		outputErr := errors.Wrapf(err, "failed to search files (data-objects) or directories (collections) matching %q", irodsPath)
 		return irods_common.ToolErrorResult(outputErr), nil
 	}
 
+	if offset+limit < content.Total {
+		content.Truncated = true
+		content.NextCursor = irods_common.EncodePageCursor(offset+limit, fingerprint)
+	}
+
 	return irods_common.ToolJSONResult(*content)
 }
 
-func (t *SearchFiles) search(fs *irodsclient_fs.FileSystem, searchPath string) (*model.SearchFilesOutput, error) {
+func (t *SearchFiles) search(fs *irodsclient_fs.FileSystem, searchPath string, searchTypes []string, caseInsensitive bool, sort string, descending bool, offset int, limit int) (*model.SearchFilesOutput, error) {
 	outputEntries := []model.EntryWithAccess{}
 
-	dirEntries, err := fs.SearchDirUnixWildcard(searchPath)
-	if err != nil {
-		return nil, errors.Wrapf(err, "failed to search directories (collections) %q", searchPath)
+	likePattern, exact := irods_common.GlobToGenQueryLike(searchPath)
+
+	options := 0
+	if caseInsensitive {
+		// the catalog compares upper-cased columns
+		options = irods_common.GenQueryOptionUpperCaseWhere
+		likePattern = strings.ToUpper(likePattern)
 	}
 
-	fileEntries, err := fs.SearchFileUnixWildcard(searchPath)
-	if err != nil {
-		return nil, errors.Wrapf(err, "failed to search files (data-objects) %q", searchPath)
+	queries := []catalogPageQuery{}
+	for _, searchType := range searchTypes {
+		selects, pathColumns := makeCatalogSelects(searchType == searchFilesTypeFile, sort, descending)
+
+		collPattern := likePattern
+		if searchType == searchFilesTypeFile {
+			collPattern = path.Dir(likePattern)
+		}
+
+		collCondition, err := makeLikeCondition(irodsclient_common.ICAT_COLUMN_COLL_NAME, collPattern)
+		if err != nil {
+			return nil, err
+		}
+
+		// wildcards do not match '/'
+		depthCondition, err := irods_common.MakeGenQueryDepthCondition(collPattern)
+		if err != nil {
+			return nil, err
+		}
+
+		conditions := []irods_common.GenQueryCondition{
+			collCondition,
+			{Column: irodsclient_common.ICAT_COLUMN_COLL_NAME, Condition: depthCondition},
+		}
+
+		if searchType == searchFilesTypeFile {
+			nameCondition, err := makeLikeCondition(irodsclient_common.ICAT_COLUMN_DATA_NAME, path.Base(likePattern))
+			if err != nil {
+				return nil, err
+			}
+
+			conditions = append(conditions, nameCondition)
+		}
+
+		queries = append(queries, catalogPageQuery{
+			entryType: searchType,
+			query: &irods_common.GenQuery{
+				Selects:    selects,
+				Conditions: conditions,
+				Options:    options,
+			},
+			pathColumns: pathColumns,
+		})
 	}
 
-	for _, dirEntry := range dirEntries {
-		entryStruct := model.EntryWithAccess{
-			Entry:       dirEntry,
-			ResourceURI: irods_common.MakeResourceURI(dirEntry.Path),
-			WebDAVURI:   irods_common.MakeWebdavURL(t.config, dirEntry.Path, fs.GetAccount()),
+	var matches []string
+	var total int
+	var err error
+	if exact {
+		matches, total, err = queryCatalogPage(fs, queries, searchFilesMaxScanRows, offset, limit)
+	} else {
+		// character classes are matched after the catalog query
+		patternRegexp, regexpErr := irods_common.GlobToRegexp(searchPath, caseInsensitive)
+		if regexpErr != nil {
+			return nil, regexpErr
 		}
 
-		outputEntries = append(outputEntries, entryStruct)
+		matches, total, err = scanCatalogPage(fs, queries, patternRegexp.MatchString, searchFilesMaxScanRows, offset, limit)
+	}
+	if err != nil {
+		return nil, err
 	}
 
-	for _, fileEntry := range fileEntries {
+	for _, match := range matches {
+		entry, err := fs.Stat(match)
+		if err != nil {
+			if irodsclient_types.IsFileNotFoundError(err) {
+				// removed after the query
+				continue
+			}
+			return nil, errors.Wrapf(err, "failed to stat %q", match)
+		}
+
 		entryStruct := model.EntryWithAccess{
-			Entry:       fileEntry,
-			ResourceURI: irods_common.MakeResourceURI(fileEntry.Path),
-			WebDAVURI:   irods_common.MakeWebdavURL(t.config, fileEntry.Path, fs.GetAccount()),
+			Entry:       entry,
+			ResourceURI: irods_common.MakeResourceURI(entry.Path),
+			WebDAVURI:   irods_common.MakeWebdavURL(t.config, entry.Path, fs.GetAccount()),
 		}
 
 		outputEntries = append(outputEntries, entryStruct)
@@ -173,7 +341,19 @@ func (t *SearchFiles) search(fs *irodsclient_fs.FileSystem, searchPath string) (
 	searchFilesOutput := &model.SearchFilesOutput{
 		SearchPath:      searchPath,
 		MatchingEntries: outputEntries,
+		Total:           total,
+		Offset:          offset,
+		Limit:           limit,
 	}
 
 	return searchFilesOutput, nil
 }
+
+func makeLikeCondition(column irodsclient_common.ICATColumnNumber, likePattern string) (irods_common.GenQueryCondition, error) {
+	quotedPattern, err := irods_common.QuoteGenQueryValue(likePattern)
+	if err != nil {
+		return irods_common.GenQueryCondition{}, err
+	}
+
+	return irods_common.GenQueryCondition{Column: column, Condition: "like " + quotedPattern}, nil
+}
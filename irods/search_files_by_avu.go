@@ -2,9 +2,13 @@ package irods
 
 import (
 	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
 
 	"github.com/cockroachdb/errors"
 	irodsclient_fs "github.com/cyverse/go-irodsclient/fs"
+	irodsclient_types "github.com/cyverse/go-irodsclient/irods/types"
 	"github.com/cyverse/irods-mcp-server/common"
 	irods_common "github.com/cyverse/irods-mcp-server/irods/common"
 	"github.com/cyverse/irods-mcp-server/irods/model"
@@ -14,11 +18,26 @@ import (
 
 const (
 	SearchFilesByAVUName = irods_common.IRODSAPIPrefix + "search_files_by_avu"
+
+	searchFilesByAVULimitDefault int = 100
+	searchFilesByAVUMaxLimit     int = 500
 )
 
 type SearchFilesByAVUInputArgs struct {
-	Attribute string `json:"attribute"`
-	Value     string `json:"value"`
+	Attribute  string               `json:"attribute,omitempty"`
+	Value      string               `json:"value,omitempty"`
+	Conditions []model.AVUCondition `json:"conditions,omitempty"`
+	Match      string               `json:"match,omitempty"`
+	Path       string               `json:"path,omitempty"`
+	EntityType string               `json:"entity_type,omitempty"`
+	Offset     int                  `json:"offset,omitempty"`
+	Limit      int                  `json:"limit,omitempty"`
+	Cursor     string               `json:"cursor,omitempty"`
+}
+
+// GetRootCondition returns a condition tree combining the attribute/value pair and the conditions
+func (args *SearchFilesByAVUInputArgs) GetRootCondition() *model.AVUCondition {
+	return makeAVURootCondition(args.Attribute, args.Value, args.Match, args.Conditions)
 }
 
 type SearchFilesByAVU struct {
@@ -38,8 +57,12 @@ func (t *SearchFilesByAVU) GetName() string {
 }
 
 func (t *SearchFilesByAVU) GetDescription() string {
-	return `Search for files (data-objects) and directories (collections) matching iRODS AVU (attribute-value-units) using specified attribute and value.
-	The matching entries are returned in JSON format.`
+	return `Search for files (data-objects) and directories (collections) matching iRODS AVU (attribute-value-units) conditions.
+	Use attribute and value for a simple exact match, or conditions for operators (=, !=, like, not like, <, <=, >, >=, between, in), unit matching and numeric comparison.
+	Conditions are combined with match ('all' or 'any'), and a condition with nested conditions forms a group with its own match.
+	Results can be limited to a path and to an entity type (data_object or collection).
+	Results are paginated, directories (collections) come first, each ordered by path. Pass next_cursor to get the next page.
+	The matching entries are returned in JSON format with the total number of matches.`
 }
 
 func (t *SearchFilesByAVU) GetTool() *mcp.Tool {
@@ -51,14 +74,94 @@ func (t *SearchFilesByAVU) GetTool() *mcp.Tool {
 			Properties: map[string]*jsonschema.Schema{
 				"attribute": {
 					Type:        "string",
-					Description: "The attribute to search for.",
+					Description: "The attribute to search for, matched with value exactly.",
 				},
 				"value": {
 					Type:        "string",
 					Description: "The value of the attribute to search for.",
 				},
+				"conditions": {
+					Type:        "array",
+					Description: "AVU conditions. Each condition has attribute, operator, value (or values for 'between' and 'in'), optional unit and numeric, or match and nested conditions for a group.",
+					Items: &jsonschema.Schema{
+						Type: "object",
+						Properties: map[string]*jsonschema.Schema{
+							"attribute": {
+								Type:        "string",
+								Description: "The attribute name, matched exactly.",
+							},
+							"operator": {
+								Type:        "string",
+								Description: "The operator to compare the value.",
+								Enum:        avuOperators,
+								Default:     json.RawMessage(`"="`),
+							},
+							"value": {
+								Type:        "string",
+								Description: "The value to compare. Use % and _ as wildcards for 'like'.",
+							},
+							"values": {
+								Type:        "array",
+								Description: "Lower and upper bounds for 'between', or candidates for 'in'.",
+								Items: &jsonschema.Schema{
+									Type: "string",
+								},
+							},
+							"unit": {
+								Type:        "string",
+								Description: "The unit to match exactly. Omit to match any unit.",
+							},
+							"numeric": {
+								Type:        "boolean",
+								Description: "Compare values as numbers instead of strings. Values that are not numbers do not match.",
+								Default:     json.RawMessage(`false`),
+							},
+							"match": {
+								Type:        "string",
+								Description: "For a group, how to combine nested conditions.",
+								Enum:        []interface{}{avuMatchAll, avuMatchAny},
+							},
+							"conditions": {
+								Type:        "array",
+								Description: "Nested conditions of a group.",
+								Items: &jsonschema.Schema{
+									Type: "object",
+								},
+							},
+						},
+					},
+				},
+				"match": {
+					Type:        "string",
+					Description: "How to combine conditions, 'all' (AND) or 'any' (OR).",
+					Enum:        []interface{}{avuMatchAll, avuMatchAny},
+					Default:     json.RawMessage(`"all"`),
+				},
+				"path": {
+					Type:        "string",
+					Description: "The collection path to search in. Omit to search everywhere accessible.",
+				},
+				"entity_type": {
+					Type:        "string",
+					Description: "The type of entries to return.",
+					Enum:        []interface{}{avuEntityAll, avuEntityDataObject, avuEntityCollection},
+					Default:     json.RawMessage(`"all"`),
+				},
+				"offset": {
+					Type:        "number",
+					Description: "Number of matching entries to skip (for pagination). Default: 0.",
+					Default:     json.RawMessage("0"),
+				},
+				"limit": {
+					Type:        "number",
+					Description: fmt.Sprintf("Maximum number of entries to return (for pagination). Default: %d, max: %d.", searchFilesByAVULimitDefault, searchFilesByAVUMaxLimit),
+					Default:     json.RawMessage(fmt.Sprintf("%d", searchFilesByAVULimitDefault)),
+				},
+				"cursor": {
+					Type:        "string",
+					Description: "The next_cursor returned by a previous call with the same conditions, to get the next page. Overrides offset.",
+				},
 			},
-			Required: []string{"attribute", "value"},
 		},
 	}
 }
@@ -114,42 +217,136 @@ func (t *SearchFilesByAVU) Handler(ctx context.Context, request *mcp.CallToolReq
 
 	accessiblePaths := t.GetAccessiblePaths(&authValue)
 
+	rootCondition := args.GetRootCondition()
+	if len(rootCondition.Conditions) == 0 {
+		outputErr := errors.Newf("either attribute or conditions must be given")
+		return irods_common.ToolErrorResult(outputErr), nil
+	}
+
+	err = normalizeAVUCondition(rootCondition, 0)
+	if err != nil {
+		outputErr := errors.Wrapf(err, "invalid conditions")
+		return irods_common.ToolErrorResult(outputErr), nil
+	}
+
+	entityTypes, err := getAVUEntityTypes(args.EntityType)
+	if err != nil {
+		outputErr := errors.Wrapf(err, "invalid entity type")
+		return irods_common.ToolErrorResult(outputErr), nil
+	}
+
+	// search only under accessible paths
+	searchPath := ""
+	searchRoots := irods_common.GetAccessibleRoots(accessiblePaths)
+	if len(args.Path) > 0 {
+		searchPath = irods_common.MakeIRODSPath(t.config, fs.GetAccount(), args.Path)
+
+		// check permission
+		if !irods_common.IsAccessAllowed(searchPath, accessiblePaths) {
+			outputErr := errors.Newf("%q request is not permitted for path %q", t.GetName(), searchPath)
+			return irods_common.ToolErrorResult(outputErr), nil
+		}
+
+		searchRoots = []string{searchPath}
+	}
+
+	if len(searchRoots) == 0 {
+		outputErr := errors.Newf("no path is accessible for %q request", t.GetName())
+		return irods_common.ToolErrorResult(outputErr), nil
+	}
+
+	// apply pagination defaults/bounds
+	offset := args.Offset
+	if offset < 0 {
+		offset = 0
+	}
+	limit := args.Limit
+	if limit <= 0 {
+		limit = searchFilesByAVULimitDefault
+	} else if limit > searchFilesByAVUMaxLimit {
+		limit = searchFilesByAVUMaxLimit
+	}
+
+	fingerprint := irods_common.MakePageFingerprint(rootCondition, entityTypes, searchRoots)
+	if len(args.Cursor) > 0 {
+		offset, err = irods_common.DecodePageCursor(args.Cursor, fingerprint)
+		if err != nil {
+			outputErr := errors.Wrapf(err, "failed to decode cursor")
+			return irods_common.ToolErrorResult(outputErr), nil
+		}
+	}
+
 	// search
-	content, err := t.search(fs, accessiblePaths, args.Attribute, args.Value)
+	content, err := t.search(fs, accessiblePaths, rootCondition, entityTypes, searchRoots, offset, limit)
 	if err != nil {
-		outputErr := errors.Wrapf(err, "failed to search files (data-objects) or directories (collections) matching attribute %q and value %q", args.Attribute, args.Value)
+		outputErr := errors.Wrapf(err, "failed to search files (data-objects) or directories (collections) matching AVU conditions")
 		return irods_common.ToolErrorResult(outputErr), nil
 	}
 
+	if len(args.Conditions) == 0 {
+		content.SearchAttribute = args.Attribute
+		content.SearchValue = args.Value
+	} else {
+		content.SearchConditions = rootCondition
+	}
+
+	content.SearchPath = searchPath
+	if len(args.EntityType) > 0 {
+		content.EntityType = strings.ToLower(args.EntityType)
+	}
+
+	if offset+limit < content.Total {
+		content.NextCursor = irods_common.EncodePageCursor(offset+limit, fingerprint)
+	}
+
 	return irods_common.ToolJSONResult(*content)
 }
 
-func (t *SearchFilesByAVU) search(fs *irodsclient_fs.FileSystem, accessiblePaths []string, attribute string, value string) (*model.SearchFilesByAVUOutput, error) {
+func (t *SearchFilesByAVU) search(fs *irodsclient_fs.FileSystem, accessiblePaths []string, rootCondition *model.AVUCondition, entityTypes []string, searchRoots []string, offset int, limit int) (*model.SearchFilesByAVUOutput, error) {
 	outputEntries := []model.EntryWithAccess{}
 
-	entries, err := fs.SearchByMeta(attribute, value)
+	query := &avuQuery{
+		fs:          fs,
+		entityTypes: entityTypes,
+		roots:       searchRoots,
+	}
+
+	matches, total, err := query.search(rootCondition, offset, limit)
 	if err != nil {
-		return nil, errors.Wrapf(err, "failed to search by AVU %q=%q", attribute, value)
+		return nil, err
 	}
 
-	for _, entry := range entries {
+	for _, match := range matches {
 		// check permission
 		// filter out entries not in accessible paths
-		if irods_common.IsAccessAllowed(entry.Path, accessiblePaths) {
-			entryStruct := model.EntryWithAccess{
-				Entry:       entry,
-				ResourceURI: irods_common.MakeResourceURI(entry.Path),
-				WebDAVURI:   irods_common.MakeWebdavURL(t.config, entry.Path, fs.GetAccount()),
+		if !irods_common.IsAccessAllowed(match.path, accessiblePaths) {
+			continue
+		}
+
+		entry, err := fs.Stat(match.path)
+		if err != nil {
+			if irodsclient_types.IsFileNotFoundError(err) {
+				// removed after the query
+				continue
 			}
+			return nil, errors.Wrapf(err, "failed to stat %q", match.path)
+		}
 
-			outputEntries = append(outputEntries, entryStruct)
+		entryStruct := model.EntryWithAccess{
+			Entry:       entry,
+			ResourceURI: irods_common.MakeResourceURI(entry.Path),
+			WebDAVURI:   irods_common.MakeWebdavURL(t.config, entry.Path, fs.GetAccount()),
 		}
+
+		outputEntries = append(outputEntries, entryStruct)
 	}
 
 	searchFilesOutput := &model.SearchFilesByAVUOutput{
-		SearchAttribute: attribute,
-		SearchValue:     value,
+		EntityType:      avuEntityAll,
 		MatchingEntries: outputEntries,
+		Total:           total,
+		Offset:          offset,
+		Limit:           limit,
 	}
 
 	return searchFilesOutput, nil
@@ -0,0 +1,869 @@
+package irods
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/cockroachdb/errors"
+	irodsclient_fs "github.com/cyverse/go-irodsclient/fs"
+	"github.com/cyverse/irods-mcp-server/common"
+	irods_common "github.com/cyverse/irods-mcp-server/irods/common"
+	"github.com/cyverse/irods-mcp-server/irods/model"
+	"github.com/google/jsonschema-go/jsonschema"
+	"github.com/modelcontextprotocol/go-sdk/mcp"
+)
+
+const (
+	SummarizeBioFileName = irods_common.IRODSAPIPrefix + "summarize_bio_file"
+
+	bioSummaryMaxBytesDefault int64 = 64 * 1024 * 1024   // 64MB
+	bioSummaryMaxBytes        int64 = 1024 * 1024 * 1024 // 1GB
+	bioSummaryReadSize        int   = 1024 * 1024        // 1MB, longer lines are read in fragments
+	bioSummarySniffSize       int   = 64 * 1024          // 64KB
+	bioSummaryMaxNames        int   = 20
+	bioSummaryMaxContigs      int   = 1000
+	bioSummaryMaxSamples      int   = 1000
+	bioSummaryMaxNameLength   int   = 200
+	bioSummaryMaxSampleValues int   = 1000000
+)
+
+type SummarizeBioFileInputArgs struct {
+	Path     string `json:"path"`
+	Format   string `json:"format,omitempty"`
+	MaxBytes int64  `json:"max_bytes,omitempty"`
+}
+
+type SummarizeBioFile struct {
+	mcpServer *IRODSMCPServer
+	config    *common.Config
+}
+
+func NewSummarizeBioFile(svr *IRODSMCPServer) ToolAPI {
+	return &SummarizeBioFile{
+		mcpServer: svr,
+		config:    svr.GetConfig(),
+	}
+}
+
+func (t *SummarizeBioFile) GetName() string {
+	return SummarizeBioFileName
+}
+
+func (t *SummarizeBioFile) GetDescription() string {
+	return `Summarize a bioinformatics file (data-object) in FASTA, FASTQ, VCF, or SAM format with the specified path.
+	The specified path must be an iRODS path. The format is detected from the content and the extension. Gzip (including bgzip), bzip2 and zstd compressed files are decompressed on the fly.
+	Only a bounded sample from the beginning of the file is read. Record counts are estimated for files larger than the sample.
+	Returns record counts, sequence length and quality distributions, sample names, and contigs in JSON format.`
+}
+
+func (t *SummarizeBioFile) GetTool() *mcp.Tool {
+	formats := []interface{}{}
+	for _, format := range irods_common.GetBioFormats() {
+		formats = append(formats, string(format))
+	}
+
+	return &mcp.Tool{
+		Name:        t.GetName(),
+		Description: t.GetDescription(),
+		InputSchema: &jsonschema.Schema{
+			Type: "object",
+			Properties: map[string]*jsonschema.Schema{
+				"path": {
+					Type:        "string",
+					Description: "The path to the file (data-object) to summarize.",
+				},
+				"format": {
+					Type:        "string",
+					Enum:        formats,
+					Description: "The format of the file. Default is detected from the content and the extension.",
+				},
+				"max_bytes": {
+					Type:        "number",
+					Description: fmt.Sprintf("The maximum number of bytes to read from the file. For compressed files, this is the size of compressed data. Maximum is %d bytes. Default is %d bytes.", bioSummaryMaxBytes, bioSummaryMaxBytesDefault),
+					Default:     json.RawMessage(fmt.Sprintf("%d", bioSummaryMaxBytesDefault)),
+				},
+			},
+			Required: []string{"path"},
+		},
+	}
+}
+
+func (t *SummarizeBioFile) GetHandler() mcp.ToolHandler {
+	return t.Handler
+}
+
+func (t *SummarizeBioFile) GetAccessiblePaths(authValue *common.AuthValue) []string {
+	account, err := t.mcpServer.GetIRODSAccountFromAuthValue(authValue)
+	if err != nil {
+		return []string{}
+	}
+
+	homePath := irods_common.GetHomePath(t.config, account)
+	sharedPath := irods_common.GetSharedPath(t.config, account)
+
+	paths := []string{
+		sharedPath + "/*",
+	}
+
+	if !account.IsAnonymousUser() {
+		paths = append(paths, homePath)
+		paths = append(paths, homePath+"/*")
+	}
+
+	return paths
+}
+
+func (t *SummarizeBioFile) Handler(ctx context.Context, request *mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	// arguments
+	args := SummarizeBioFileInputArgs{}
+	err := irods_common.MarshalInputArguments(t.GetTool(), request, &args)
+	if err != nil {
+		outputErr := errors.Wrapf(err, "failed to marshal input arguments")
+		return irods_common.ToolErrorResult(outputErr), nil
+	}
+
+	if args.MaxBytes <= 0 {
+		args.MaxBytes = bioSummaryMaxBytesDefault
+	} else if args.MaxBytes > bioSummaryMaxBytes {
+		args.MaxBytes = bioSummaryMaxBytes
+	}
+
+	// auth
+	authValue, err := common.GetAuthValue(ctx)
+	if err != nil {
+		outputErr := errors.Wrapf(err, "failed to get auth value")
+		return irods_common.ToolErrorResult(outputErr), nil
+	}
+
+	// make a irods filesystem client
+	fs, err := t.mcpServer.GetIRODSFSClientFromAuthValue(&authValue)
+	if err != nil {
+		outputErr := errors.Wrapf(err, "failed to create a irods fs client")
+		return irods_common.ToolErrorResult(outputErr), nil
+	}
+
+	irodsPath := irods_common.MakeIRODSPath(t.config, fs.GetAccount(), args.Path)
+
+	// check permission
+	if !irods_common.IsAccessAllowed(irodsPath, t.GetAccessiblePaths(&authValue)) {
+		outputErr := errors.Newf("%q request is not permitted for path %q", t.GetName(), irodsPath)
+		return irods_common.ToolErrorResult(outputErr), nil
+	}
+
+	entry, err := fs.Stat(irodsPath)
+	if err != nil {
+		outputErr := errors.Wrapf(err, "failed to stat file info for %q", irodsPath)
+		return irods_common.ToolErrorResult(outputErr), nil
+	}
+
+	if entry.IsDir() {
+		outputErr := errors.Newf("path %q is a directory (collection)", irodsPath)
+		return irods_common.ToolErrorResult(outputErr), nil
+	}
+
+	content, err := t.summarize(fs, entry, &args)
+	if err != nil {
+		outputErr := errors.Wrapf(err, "failed to summarize file (data-object) %q", irodsPath)
+		return irods_common.ToolErrorResult(outputErr), nil
+	}
+
+	return irods_common.ToolJSONResult(*content)
+}
+
+func (t *SummarizeBioFile) summarize(fs *irodsclient_fs.FileSystem, entry *irodsclient_fs.Entry, args *SummarizeBioFileInputArgs) (*model.SummarizeBioFileOutput, error) {
+	handle, err := fs.OpenFile(entry.Path, "", "r")
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to open file %q", entry.Path)
+	}
+	defer handle.Close()
+
+	counter := irods_common.NewBudgetReader(handle, args.MaxBytes)
+	reader := bufio.NewReaderSize(counter, bioSummaryReadSize)
+	contentPath := entry.Path
+
+	output := &model.SummarizeBioFileOutput{
+		Path:        entry.Path,
+		ResourceURI: irods_common.MakeResourceURI(entry.Path),
+		Size:        entry.Size,
+	}
+
+	magic, _ := reader.Peek(irods_common.CompressionMagicReadSize)
+	compressionFormat := irods_common.DetectCompressionFormat(entry.Path, magic)
+	if len(compressionFormat) > 0 {
+		decompressionReader, err := irods_common.NewDecompressionReader(compressionFormat, reader)
+		if err != nil {
+			return nil, err
+		}
+		defer decompressionReader.Close()
+
+		reader = bufio.NewReaderSize(decompressionReader, bioSummaryReadSize)
+		contentPath = irods_common.TrimCompressionExtension(entry.Path)
+		output.Compression = string(compressionFormat)
+	}
+
+	head, _ := reader.Peek(bioSummarySniffSize)
+	if bytes.HasPrefix(head, []byte("BAM\x01")) {
+		return nil, errors.Newf("BAM format is not supported, only SAM is supported")
+	}
+
+	format := irods_common.BioFormat(args.Format)
+	if len(format) == 0 {
+		format = irods_common.DetectBioFormat(contentPath, head)
+		if len(format) == 0 {
+			return nil, errors.Newf("failed to detect the format of %q, format must be one of FASTA, FASTQ, VCF, or SAM", entry.Path)
+		}
+	}
+
+	output.Format = string(format)
+
+	lineReader := &bioLineReader{
+		reader: reader,
+	}
+
+	budgetExhausted := func() bool {
+		return counter.IsExhausted(entry.Size)
+	}
+
+	switch format {
+	case irods_common.BioFormatFASTA:
+		err = t.summarizeFASTA(lineReader, budgetExhausted, output)
+	case irods_common.BioFormatFASTQ:
+		err = t.summarizeFASTQ(lineReader, budgetExhausted, output)
+	case irods_common.BioFormatVCF:
+		err = t.summarizeVCF(lineReader, budgetExhausted, output)
+	case irods_common.BioFormatSAM:
+		err = t.summarizeSAM(lineReader, budgetExhausted, output)
+	default:
+		err = errors.Newf("unknown format %q", format)
+	}
+
+	if err != nil {
+		return nil, err
+	}
+
+	output.BytesScanned = counter.BytesRead()
+	output.Truncated = budgetExhausted()
+	output.RecordCountExact = !output.Truncated
+	output.Records = counter.EstimateTotal(output.RecordsScanned, entry.Size)
+
+	return output, nil
+}
+
+// bioLineReader reads lines in fragments, so very long lines (such as unwrapped genome sequences) are not buffered entirely
+type bioLineReader struct {
+	reader      *bufio.Reader
+	lineNumber  int64
+	inLine      bool
+	partialLine bool // true if the last line read ends without a line ending at EOF
+}
+
+// next returns the next fragment of a line, start is true for the first fragment of a line.
+// The fragment is valid until the next call, line endings are trimmed.
+func (reader *bioLineReader) next() ([]byte, bool, error) {
+	fragment, err := reader.reader.ReadSlice('\n')
+	if err != nil && err != bufio.ErrBufferFull {
+		// compressed stream ends unexpectedly when the byte budget is exhausted
+		if err != io.EOF && err != io.ErrUnexpectedEOF {
+			return nil, false, err
+		}
+
+		if len(fragment) == 0 {
+			return nil, false, io.EOF
+		}
+		reader.partialLine = true
+	}
+
+	start := !reader.inLine
+	if start {
+		reader.lineNumber++
+	}
+
+	// line continues if the buffer is full
+	reader.inLine = err == bufio.ErrBufferFull
+	return bytes.TrimRight(fragment, "\r\n"), start, nil
+}
+
+// bioValueCollector collects values for a distribution, values beyond the sample size are counted but not sampled
+type bioValueCollector struct {
+	count  int64
+	sum    float64
+	min    float64
+	max    float64
+	sample []float64
+}
+
+func (collector *bioValueCollector) add(value float64) {
+	if collector.count == 0 || value < collector.min {
+		collector.min = value
+	}
+	if collector.count == 0 || value > collector.max {
+		collector.max = value
+	}
+
+	collector.count++
+	collector.sum += value
+
+	if len(collector.sample) < bioSummaryMaxSampleValues {
+		collector.sample = append(collector.sample, value)
+	}
+}
+
+func (collector *bioValueCollector) distribution() *model.BioDistribution {
+	if collector.count == 0 {
+		return nil
+	}
+
+	sorted := append([]float64{}, collector.sample...)
+	sort.Float64s(sorted)
+
+	// nearest-rank percentile
+	percentile := func(p float64) float64 {
+		rank := int(math.Ceil(p*float64(len(sorted)))) - 1
+		if rank < 0 {
+			rank = 0
+		}
+		return sorted[rank]
+	}
+
+	return &model.BioDistribution{
+		Min:    collector.min,
+		Max:    collector.max,
+		Mean:   collector.sum / float64(collector.count),
+		P10:    percentile(0.1),
+		Median: percentile(0.5),
+		P90:    percentile(0.9),
+	}
+}
+
+// n50 returns N50 of sampled lengths
+func (collector *bioValueCollector) n50() int64 {
+	sorted := append([]float64{}, collector.sample...)
+	sort.Sort(sort.Reverse(sort.Float64Slice(sorted)))
+
+	total := float64(0)
+	for _, value := range sorted {
+		total += value
+	}
+
+	sum := float64(0)
+	for _, value := range sorted {
+		sum += value
+		if sum*2 >= total {
+			return int64(value)
+		}
+	}
+	return 0
+}
+
+// bioSequenceCollector collects statistics of sequences
+type bioSequenceCollector struct {
+	lengths    bioValueCollector
+	totalBases int64
+	gcBases    int64
+	nBases     int64
+}
+
+func (collector *bioSequenceCollector) addBases(bases []byte) {
+	collector.totalBases += int64(len(bases))
+	for _, base := range bases {
+		switch base {
+		case 'G', 'C', 'g', 'c', 'S', 's':
+			collector.gcBases++
+		case 'N', 'n':
+			collector.nBases++
+		}
+	}
+}
+
+func (collector *bioSequenceCollector) stats() *model.BioSequenceStats {
+	if collector.lengths.count == 0 {
+		return nil
+	}
+
+	stats := &model.BioSequenceStats{
+		Lengths:    collector.lengths.distribution(),
+		N50:        collector.lengths.n50(),
+		TotalBases: collector.totalBases,
+	}
+
+	if collector.totalBases > 0 {
+		stats.GCContent = float64(collector.gcBases) / float64(collector.totalBases)
+		stats.NContent = float64(collector.nBases) / float64(collector.totalBases)
+	}
+
+	return stats
+}
+
+func truncateBioName(name []byte) string {
+	if len(name) > bioSummaryMaxNameLength {
+		name = name[:bioSummaryMaxNameLength]
+	}
+	return string(name)
+}
+
+func (t *SummarizeBioFile) summarizeFASTA(reader *bioLineReader, budgetExhausted func() bool, output *model.SummarizeBioFileOutput) error {
+	sequences := bioSequenceCollector{}
+	inRecord := false
+	recordLength := int64(0)
+
+	for {
+		fragment, start, err := reader.next()
+		if err != nil {
+			if err == io.EOF {
+				break
+			}
+			return errors.Wrapf(err, "failed to read line %d", reader.lineNumber)
+		}
+
+		if start && bytes.HasPrefix(fragment, []byte(">")) {
+			if inRecord {
+				sequences.lengths.add(float64(recordLength))
+				output.RecordsScanned++
+			}
+
+			inRecord = true
+			recordLength = 0
+
+			if len(output.SequenceNames) < bioSummaryMaxNames {
+				name, _, _ := bytes.Cut(bytes.TrimSpace(fragment[1:]), []byte(" "))
+				output.SequenceNames = append(output.SequenceNames, truncateBioName(name))
+			}
+			continue
+		}
+
+		if start && bytes.HasPrefix(fragment, []byte(";")) {
+			// comment
+			continue
+		}
+
+		if !inRecord {
+			if len(bytes.TrimSpace(fragment)) == 0 {
+				continue
+			}
+			return errors.Newf("malformed FASTA, sequence without header at line %d", reader.lineNumber)
+		}
+
+		fragment = bytes.TrimSpace(fragment)
+		recordLength += int64(len(fragment))
+		sequences.addBases(fragment)
+	}
+
+	// the last record is partial if the budget is exhausted
+	if inRecord && !budgetExhausted() {
+		sequences.lengths.add(float64(recordLength))
+		output.RecordsScanned++
+	}
+
+	output.Sequences = sequences.stats()
+	return nil
+}
+
+func (t *SummarizeBioFile) summarizeFASTQ(reader *bioLineReader, budgetExhausted func() bool, output *model.SummarizeBioFileOutput) error {
+	sequences := bioSequenceCollector{}
+	readQualities := bioValueCollector{}
+
+	lineInRecord := -1
+	sequenceLength := int64(0)
+	qualitySum := int64(0)
+	qualityLength := int64(0)
+	minQualityChar := byte(0xff)
+	maxQualityChar := byte(0)
+	q20Bases := int64(0)
+	q30Bases := int64(0)
+	totalQualityBases := int64(0)
+
+	// quality scores are counted in phred+33 and adjusted later if the encoding is phred+64
+	q20Bases64 := int64(0)
+	q30Bases64 := int64(0)
+
+	finishRecord := func() {
+		sequences.lengths.add(float64(sequenceLength))
+		if qualityLength > 0 {
+			readQualities.add(float64(qualitySum)/float64(qualityLength) - 33)
+		}
+		output.RecordsScanned++
+	}
+
+	for {
+		fragment, start, err := reader.next()
+		if err != nil {
+			if err == io.EOF {
+				break
+			}
+			return errors.Wrapf(err, "failed to read line %d", reader.lineNumber)
+		}
+
+		if start {
+			if lineInRecord < 0 && len(fragment) == 0 {
+				// blank lines between records
+				continue
+			}
+
+			lineInRecord++
+			if lineInRecord == 4 {
+				finishRecord()
+				lineInRecord = 0
+			}
+
+			switch lineInRecord {
+			case 0:
+				if !bytes.HasPrefix(fragment, []byte("@")) {
+					if len(fragment) == 0 {
+						lineInRecord = -1
+						continue
+					}
+					return errors.Newf("malformed FASTQ, record header expected at line %d", reader.lineNumber)
+				}
+
+				sequenceLength = 0
+				qualitySum = 0
+				qualityLength = 0
+
+				if len(output.SequenceNames) < bioSummaryMaxNames {
+					name, _, _ := bytes.Cut(bytes.TrimSpace(fragment[1:]), []byte(" "))
+					output.SequenceNames = append(output.SequenceNames, truncateBioName(name))
+				}
+				continue
+			case 2:
+				if !bytes.HasPrefix(fragment, []byte("+")) {
+					return errors.Newf("malformed FASTQ, separator '+' expected at line %d", reader.lineNumber)
+				}
+				continue
+			}
+		}
+
+		switch lineInRecord {
+		case 1:
+			sequenceLength += int64(len(fragment))
+			sequences.addBases(fragment)
+		case 3:
+			for _, quality := range fragment {
+				qualitySum += int64(quality)
+				if quality < minQualityChar {
+					minQualityChar = quality
+				}
+				if quality > maxQualityChar {
+					maxQualityChar = quality
+				}
+				if quality >= 33+20 {
+					q20Bases++
+				}
+				if quality >= 33+30 {
+					q30Bases++
+				}
+				if quality >= 64+20 {
+					q20Bases64++
+				}
+				if quality >= 64+30 {
+					q30Bases64++
+				}
+			}
+			qualityLength += int64(len(fragment))
+			totalQualityBases += int64(len(fragment))
+		}
+	}
+
+	// the last record is complete if its quality line is read to the end
+	if lineInRecord == 3 && !(budgetExhausted() && reader.partialLine) && qualityLength == sequenceLength {
+		finishRecord()
+	}
+
+	output.Sequences = sequences.stats()
+
+	if totalQualityBases > 0 {
+		encoding := "phred+33"
+		offset := float64(33)
+		if minQualityChar >= 64 {
+			encoding = "phred+64"
+			offset = 64
+			q20Bases = q20Bases64
+			q30Bases = q30Bases64
+		} else if minQualityChar >= 59 && maxQualityChar > 74 {
+			encoding = "unknown"
+		}
+
+		readQualityDistribution := readQualities.distribution()
+		if readQualityDistribution != nil && offset != 33 {
+			shift := offset - 33
+			readQualityDistribution.Min -= shift
+			readQualityDistribution.Max -= shift
+			readQualityDistribution.Mean -= shift
+			readQualityDistribution.P10 -= shift
+			readQualityDistribution.Median -= shift
+			readQualityDistribution.P90 -= shift
+		}
+
+		meanQuality := float64(0)
+		if readQualities.count > 0 {
+			meanQuality = readQualities.sum/float64(readQualities.count) - (offset - 33)
+		}
+
+		output.Quality = &model.BioQualityStats{
+			Encoding:          encoding,
+			MeanQuality:       meanQuality,
+			Q20Fraction:       float64(q20Bases) / float64(totalQualityBases),
+			Q30Fraction:       float64(q30Bases) / float64(totalQualityBases),
+			ReadMeanQualities: readQualityDistribution,
+		}
+	}
+
+	return nil
+}
+
+// bioContigCollector collects contigs in the order of appearance
+type bioContigCollector struct {
+	contigs   []model.BioContig
+	index     map[string]int
+	truncated bool
+}
+
+func (collector *bioContigCollector) get(name string) *model.BioContig {
+	if collector.index == nil {
+		collector.index = map[string]int{}
+	}
+
+	if idx, ok := collector.index[name]; ok {
+		return &collector.contigs[idx]
+	}
+
+	if len(collector.contigs) >= bioSummaryMaxContigs {
+		collector.truncated = true
+		return nil
+	}
+
+	collector.contigs = append(collector.contigs, model.BioContig{Name: name})
+	collector.index[name] = len(collector.contigs) - 1
+	return &collector.contigs[len(collector.contigs)-1]
+}
+
+// parseHeaderFields parses key=value pairs of a VCF meta line, such as '<ID=chr1,length=248956422>'
+func parseVCFMetaFields(value string) map[string]string {
+	value = strings.TrimSuffix(strings.TrimPrefix(value, "<"), ">")
+	fields := map[string]string{}
+	for _, pair := range strings.Split(value, ",") {
+		key, fieldValue, ok := strings.Cut(pair, "=")
+		if ok {
+			fields[key] = fieldValue
+		}
+	}
+	return fields
+}
+
+func (t *SummarizeBioFile) summarizeVCF(reader *bioLineReader, budgetExhausted func() bool, output *model.SummarizeBioFileOutput) error {
+	contigs := bioContigCollector{}
+	variants := &model.BioVariantStats{}
+
+	for {
+		fragment, start, err := reader.next()
+		if err != nil {
+			if err == io.EOF {
+				break
+			}
+			return errors.Wrapf(err, "failed to read line %d", reader.lineNumber)
+		}
+
+		// only the beginning of lines is needed
+		if !start || len(fragment) == 0 {
+			continue
+		}
+
+		if reader.partialLine && budgetExhausted() {
+			// partial record
+			break
+		}
+
+		line := string(fragment)
+
+		if strings.HasPrefix(line, "##") {
+			key, value, _ := strings.Cut(line[2:], "=")
+			switch key {
+			case "fileformat":
+				output.FormatVersion = value
+			case "contig":
+				fields := parseVCFMetaFields(value)
+				if contig := contigs.get(fields["ID"]); contig != nil {
+					contig.Length, _ = strconv.ParseInt(fields["length"], 10, 64)
+				}
+			}
+			continue
+		}
+
+		if strings.HasPrefix(line, "#CHROM") {
+			columns := strings.Split(line, "\t")
+			if len(columns) > 9 {
+				output.SampleCount = len(columns) - 9
+				for _, sample := range columns[9:] {
+					if len(output.Samples) >= bioSummaryMaxSamples {
+						break
+					}
+					output.Samples = append(output.Samples, sample)
+				}
+			}
+			continue
+		}
+
+		// CHROM POS ID REF ALT QUAL FILTER ...
+		columns := strings.SplitN(line, "\t", 8)
+		if len(columns) < 7 {
+			return errors.Newf("malformed VCF record at line %d", reader.lineNumber)
+		}
+
+		output.RecordsScanned++
+
+		if contig := contigs.get(columns[0]); contig != nil {
+			contig.Records++
+		}
+
+		if columns[6] == "PASS" {
+			variants.Passed++
+		}
+
+		ref := columns[3]
+		for _, alt := range strings.Split(columns[4], ",") {
+			switch {
+			case alt == "." || alt == "*" || strings.HasPrefix(alt, "<") || strings.ContainsAny(alt, "[]"):
+				variants.Others++
+			case len(ref) == 1 && len(alt) == 1:
+				variants.SNPs++
+			case len(ref) != len(alt):
+				variants.Indels++
+			default:
+				variants.MNPs++
+			}
+		}
+	}
+
+	output.Contigs = contigs.contigs
+	output.ContigsTruncated = contigs.truncated
+	output.Variants = variants
+	return nil
+}
+
+func (t *SummarizeBioFile) summarizeSAM(reader *bioLineReader, budgetExhausted func() bool, output *model.SummarizeBioFileOutput) error {
+	contigs := bioContigCollector{}
+	sequences := bioSequenceCollector{}
+	mappingQualities := bioValueCollector{}
+	alignments := &model.BioAlignmentStats{}
+	samples := map[string]bool{}
+
+	for {
+		fragment, start, err := reader.next()
+		if err != nil {
+			if err == io.EOF {
+				break
+			}
+			return errors.Wrapf(err, "failed to read line %d", reader.lineNumber)
+		}
+
+		// only the beginning of lines is needed
+		if !start || len(fragment) == 0 {
+			continue
+		}
+
+		if reader.partialLine && budgetExhausted() {
+			// partial record
+			break
+		}
+
+		line := string(fragment)
+
+		if strings.HasPrefix(line, "@") {
+			columns := strings.Split(line, "\t")
+			tags := map[string]string{}
+			for _, column := range columns[1:] {
+				key, value, ok := strings.Cut(column, ":")
+				if ok {
+					tags[key] = value
+				}
+			}
+
+			switch columns[0] {
+			case "@HD":
+				output.FormatVersion = tags["VN"]
+				alignments.SortOrder = tags["SO"]
+			case "@SQ":
+				if contig := contigs.get(tags["SN"]); contig != nil {
+					contig.Length, _ = strconv.ParseInt(tags["LN"], 10, 64)
+				}
+			case "@RG":
+				sample := tags["SM"]
+				if len(sample) > 0 && !samples[sample] && len(output.Samples) < bioSummaryMaxSamples {
+					samples[sample] = true
+					output.Samples = append(output.Samples, sample)
+				}
+			case "@PG":
+				program := tags["PN"]
+				if len(program) == 0 {
+					program = tags["ID"]
+				}
+				if len(tags["VN"]) > 0 {
+					program += " " + tags["VN"]
+				}
+				if len(alignments.Programs) < bioSummaryMaxNames {
+					alignments.Programs = append(alignments.Programs, program)
+				}
+			}
+			continue
+		}
+
+		// QNAME FLAG RNAME POS MAPQ CIGAR RNEXT PNEXT TLEN SEQ QUAL
+		columns := strings.SplitN(line, "\t", 12)
+		if len(columns) < 11 && (len(columns) < 5 || !reader.inLine) {
+			// long reads may not fit in a fragment, the leading fields are still usable
+			return errors.Newf("malformed SAM record at line %d", reader.lineNumber)
+		}
+
+		flag, err := strconv.ParseInt(columns[1], 10, 64)
+		if err != nil {
+			return errors.Newf("malformed SAM record flag %q at line %d", columns[1], reader.lineNumber)
+		}
+
+		output.RecordsScanned++
+
+		switch {
+		case flag&0x100 != 0:
+			alignments.Secondary++
+			continue
+		case flag&0x800 != 0:
+			alignments.Supplementary++
+			continue
+		case flag&0x4 != 0:
+			alignments.Unmapped++
+		default:
+			alignments.Mapped++
+
+			if contig := contigs.get(columns[2]); contig != nil {
+				contig.Records++
+			}
+
+			mappingQuality, err := strconv.ParseInt(columns[4], 10, 64)
+			if err == nil && mappingQuality != 255 {
+				mappingQualities.add(float64(mappingQuality))
+			}
+		}
+
+		// primary alignments only
+		if len(columns) >= 11 && columns[9] != "*" {
+			sequences.lengths.add(float64(len(columns[9])))
+			sequences.addBases([]byte(columns[9]))
+		}
+	}
+
+	if mappingQualities.count > 0 {
+		alignments.MeanMappingQuality = mappingQualities.sum / float64(mappingQualities.count)
+	}
+
+	output.Contigs = contigs.contigs
+	output.ContigsTruncated = contigs.truncated
+	output.Sequences = sequences.stats()
+	output.Alignments = alignments
+	return nil
+}
@@ -0,0 +1,562 @@
+package irods
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"path"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/cockroachdb/errors"
+	irodsclient_fs "github.com/cyverse/go-irodsclient/fs"
+	irodsclient_types "github.com/cyverse/go-irodsclient/irods/types"
+	"github.com/cyverse/irods-mcp-server/common"
+	irods_common "github.com/cyverse/irods-mcp-server/irods/common"
+	"github.com/cyverse/irods-mcp-server/irods/model"
+	"github.com/google/jsonschema-go/jsonschema"
+	"github.com/modelcontextprotocol/go-sdk/mcp"
+)
+
+const (
+	SyncName = irods_common.IRODSAPIPrefix + "sync"
+
+	syncMaxScanEntries int = 100000
+	syncMaxActions     int = 1000 // maximum number of actions listed in the output
+)
+
+// SyncCompareMode determines how files (data-objects) are judged as changed
+type SyncCompareMode string
+
+const (
+	SyncCompareSizeMtime SyncCompareMode = "size_mtime"
+	SyncCompareSize      SyncCompareMode = "size"
+	SyncCompareChecksum  SyncCompareMode = "checksum"
+)
+
+const (
+	syncActionCreateDir string = "create_dir"
+	syncActionCopy      string = "copy"
+	syncActionUpdate    string = "update"
+	syncActionDelete    string = "delete"
+	syncActionConflict  string = "conflict"
+)
+
+type SyncInputArgs struct {
+	SourcePath      string   `json:"source_path"`
+	DestinationPath string   `json:"destination_path"`
+	Compare         string   `json:"compare,omitempty"`
+	DeleteExtras    bool     `json:"delete_extras,omitempty"`
+	DryRun          bool     `json:"dry_run,omitempty"`
+	SyncAVUs        bool     `json:"sync_avus,omitempty"`
+	Exclude         []string `json:"exclude,omitempty"`
+	Parallelism     int      `json:"parallelism,omitempty"`
+}
+
+type Sync struct {
+	mcpServer *IRODSMCPServer
+	config    *common.Config
+}
+
+func NewSync(svr *IRODSMCPServer) ToolAPI {
+	return &Sync{
+		mcpServer: svr,
+		config:    svr.GetConfig(),
+	}
+}
+
+func (t *Sync) GetName() string {
+	return SyncName
+}
+
+func (t *Sync) GetDescription() string {
+	return `Make a destination directory (collection) mirror a source directory (collection) on the server side.
+	The specified paths must be iRODS paths. Only new or changed files (data-objects) are copied.
+	Extra files and directories in the destination are deleted only if requested. Use 'dry_run' to preview the changes.
+	The list of actions taken is returned in JSON format.`
+}
+
+func (t *Sync) GetTool() *mcp.Tool {
+	return &mcp.Tool{
+		Name:        t.GetName(),
+		Description: t.GetDescription(),
+		InputSchema: &jsonschema.Schema{
+			Type: "object",
+			Properties: map[string]*jsonschema.Schema{
+				"source_path": {
+					Type:        "string",
+					Description: "The path to the source directory (collection).",
+				},
+				"destination_path": {
+					Type:        "string",
+					Description: "The path to the destination directory (collection). It is created if it does not exist.",
+				},
+				"compare": {
+					Type:        "string",
+					Enum:        []interface{}{string(SyncCompareSizeMtime), string(SyncCompareSize), string(SyncCompareChecksum)},
+					Description: "How to judge a file as changed. 'size_mtime' copies files with a different size or a newer modification time, 'size' compares sizes only, and 'checksum' compares sizes and checksums. Default is 'size_mtime'.",
+					Default:     json.RawMessage(fmt.Sprintf("%q", SyncCompareSizeMtime)),
+				},
+				"delete_extras": {
+					Type:        "boolean",
+					Description: "Set to true to delete files and directories in the destination that do not exist in the source. Default is false.",
+					Default:     json.RawMessage("false"),
+				},
+				"dry_run": {
+					Type:        "boolean",
+					Description: "Set to true to return the planned actions without making changes. Default is false.",
+					Default:     json.RawMessage("false"),
+				},
+				"sync_avus": {
+					Type:        "boolean",
+					Description: "Set to true to make AVUs of destination entries the same as the source entries. Default is false.",
+					Default:     json.RawMessage("false"),
+				},
+				"exclude": {
+					Type:        "array",
+					Items:       &jsonschema.Schema{Type: "string"},
+					Description: "Unix wildcard patterns of files and directories to leave out of the sync on both sides. Patterns without '/' match names, others match paths relative to the source or destination.",
+				},
+				"parallelism": {
+					Type:        "number",
+					Description: fmt.Sprintf("The number of files to copy in parallel. Maximum is %d. Default is %d.", irods_common.MaxParallelism, irods_common.GetDefaultTransferThreadNum()),
+					Default:     json.RawMessage(fmt.Sprintf("%d", irods_common.GetDefaultTransferThreadNum())),
+				},
+			},
+			Required: []string{"source_path", "destination_path"},
+		},
+	}
+}
+
+func (t *Sync) GetHandler() mcp.ToolHandler {
+	return t.Handler
+}
+
+func (t *Sync) GetAccessiblePaths(authValue *common.AuthValue) []string {
+	account, err := t.mcpServer.GetIRODSAccountFromAuthValue(authValue)
+	if err != nil {
+		return []string{}
+	}
+
+	homePath := irods_common.GetHomePath(t.config, account)
+	sharedPath := irods_common.GetSharedPath(t.config, account)
+
+	paths := []string{
+		sharedPath + "/*",
+	}
+
+	if !account.IsAnonymousUser() {
+		paths = append(paths, homePath)
+		paths = append(paths, homePath+"/*")
+	}
+
+	return paths
+}
+
+func (t *Sync) Handler(ctx context.Context, request *mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	// arguments
+	args := SyncInputArgs{}
+	err := irods_common.MarshalInputArguments(t.GetTool(), request, &args)
+	if err != nil {
+		outputErr := errors.Wrapf(err, "failed to marshal input arguments")
+		return irods_common.ToolErrorResult(outputErr), nil
+	}
+
+	// auth
+	authValue, err := common.GetAuthValue(ctx)
+	if err != nil {
+		outputErr := errors.Wrapf(err, "failed to get auth value")
+		return irods_common.ToolErrorResult(outputErr), nil
+	}
+
+	// make a irods filesystem client
+	fs, err := t.mcpServer.GetIRODSFSClientFromAuthValue(&authValue)
+	if err != nil {
+		outputErr := errors.Wrapf(err, "failed to create a irods fs client")
+		return irods_common.ToolErrorResult(outputErr), nil
+	}
+
+	irodsSourcePath := irods_common.MakeIRODSPath(t.config, fs.GetAccount(), args.SourcePath)
+	irodsDestinationPath := irods_common.MakeIRODSPath(t.config, fs.GetAccount(), args.DestinationPath)
+
+	// check permission
+	if !irods_common.IsAccessAllowed(irodsSourcePath, t.GetAccessiblePaths(&authValue)) {
+		outputErr := errors.Newf("%q request is not permitted for path %q", t.GetName(), irodsSourcePath)
+		return irods_common.ToolErrorResult(outputErr), nil
+	}
+	if !irods_common.IsAccessAllowed(irodsDestinationPath, t.GetAccessiblePaths(&authValue)) {
+		outputErr := errors.Newf("%q request is not permitted for path %q", t.GetName(), irodsDestinationPath)
+		return irods_common.ToolErrorResult(outputErr), nil
+	}
+
+	if irods_common.IsIRODSSubPath(irodsSourcePath, irodsDestinationPath) || irods_common.IsIRODSSubPath(irodsDestinationPath, irodsSourcePath) {
+		outputErr := errors.Newf("source path %q and destination path %q must not overlap", irodsSourcePath, irodsDestinationPath)
+		return irods_common.ToolErrorResult(outputErr), nil
+	}
+
+	sourceEntry, err := fs.Stat(irodsSourcePath)
+	if err != nil {
+		outputErr := errors.Wrapf(err, "failed to stat directory info for %q", irodsSourcePath)
+		return irods_common.ToolErrorResult(outputErr), nil
+	}
+
+	if !sourceEntry.IsDir() {
+		outputErr := errors.Newf("path %q is not a directory (collection)", irodsSourcePath)
+		return irods_common.ToolErrorResult(outputErr), nil
+	}
+
+	destEntry, err := fs.Stat(irodsDestinationPath)
+	if err != nil {
+		if !irodsclient_types.IsFileNotFoundError(err) {
+			outputErr := errors.Wrapf(err, "failed to stat directory info for %q", irodsDestinationPath)
+			return irods_common.ToolErrorResult(outputErr), nil
+		}
+
+		destEntry = nil
+	} else if !destEntry.IsDir() {
+		outputErr := errors.Newf("path %q is not a directory (collection)", irodsDestinationPath)
+		return irods_common.ToolErrorResult(outputErr), nil
+	}
+
+	progress := irods_common.NewProgressReporter(ctx, request)
+
+	content, err := t.sync(ctx, fs, sourceEntry, irodsDestinationPath, destEntry != nil, progress, &args)
+	if err != nil {
+		outputErr := errors.Wrapf(err, "failed to sync directory (collection) from %q to %q", irodsSourcePath, irodsDestinationPath)
+		return irods_common.ToolErrorResult(outputErr), nil
+	}
+
+	return irods_common.ToolJSONResult(*content)
+}
+
+func (t *Sync) scanCollection(ctx context.Context, fs *irodsclient_fs.FileSystem, rootPath string, excludes []string) (map[string]*irodsclient_fs.Entry, error) {
+	entries := map[string]*irodsclient_fs.Entry{}
+
+	err := irods_common.WalkCollection(fs, rootPath, 0, func(entry *irodsclient_fs.Entry, depth int) error {
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+
+		relPath := irods_common.GetRelativeIRODSPath(rootPath, entry.Path)
+		if !irods_common.MatchGlobs(relPath, nil, excludes) {
+			if entry.IsDir() {
+				return irods_common.ErrSkipDir
+			}
+			return nil
+		}
+
+		if len(entries) >= syncMaxScanEntries {
+			return errors.Newf("directory (collection) %q has too many entries, maximum is %d", rootPath, syncMaxScanEntries)
+		}
+
+		entries[relPath] = entry
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return entries, nil
+}
+
+func (t *Sync) sync(ctx context.Context, fs *irodsclient_fs.FileSystem, sourceEntry *irodsclient_fs.Entry, destPath string, destExist bool, progress *irods_common.ProgressReporter, args *SyncInputArgs) (*model.SyncOutput, error) {
+	compareMode := SyncCompareMode(args.Compare)
+	if len(compareMode) == 0 {
+		compareMode = SyncCompareSizeMtime
+	}
+
+	sourceEntries, err := t.scanCollection(ctx, fs, sourceEntry.Path, args.Exclude)
+	if err != nil {
+		return nil, err
+	}
+
+	destEntries := map[string]*irodsclient_fs.Entry{}
+	if destExist {
+		destEntries, err = t.scanCollection(ctx, fs, destPath, args.Exclude)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	// plan
+	actions, unchanged, err := t.planSync(fs, sourceEntries, destEntries, compareMode, args.DeleteExtras)
+	if err != nil {
+		return nil, err
+	}
+
+	syncOutput := &model.SyncOutput{
+		SourcePath:      sourceEntry.Path,
+		DestinationPath: destPath,
+		DryRun:          args.DryRun,
+		Compare:         string(compareMode),
+		Unchanged:       unchanged,
+	}
+
+	if !args.DryRun {
+		progress.SetTotal(float64(len(actions)))
+
+		if !destExist {
+			err = fs.MakeDir(destPath, true)
+			if err != nil {
+				return nil, errors.Wrapf(err, "failed to make directory (collection) %q", destPath)
+			}
+		}
+
+		t.runActions(ctx, fs, sourceEntry.Path, destPath, actions, progress, args)
+
+		if args.SyncAVUs {
+			t.syncAVUs(ctx, fs, sourceEntry.Path, destPath, sourceEntries, actions)
+		}
+
+		progress.Done("sync completed")
+	}
+
+	for idx := range actions {
+		action := &actions[idx]
+		switch {
+		case len(action.Error) > 0:
+			syncOutput.Failed++
+		case action.Action == syncActionCreateDir:
+			syncOutput.CreatedDirs++
+		case action.Action == syncActionCopy:
+			syncOutput.Copied++
+			syncOutput.BytesCopied += action.Size
+		case action.Action == syncActionUpdate:
+			syncOutput.Updated++
+			syncOutput.BytesCopied += action.Size
+		case action.Action == syncActionDelete:
+			syncOutput.Deleted++
+		}
+	}
+
+	if len(actions) > syncMaxActions {
+		actions = actions[:syncMaxActions]
+		syncOutput.Truncated = true
+	}
+	syncOutput.Actions = actions
+
+	return syncOutput, nil
+}
+
+func (t *Sync) planSync(fs *irodsclient_fs.FileSystem, sourceEntries map[string]*irodsclient_fs.Entry, destEntries map[string]*irodsclient_fs.Entry, compareMode SyncCompareMode, deleteExtras bool) ([]model.SyncAction, int, error) {
+	actions := []model.SyncAction{}
+	unchanged := 0
+
+	sourceRelPaths := make([]string, 0, len(sourceEntries))
+	for relPath := range sourceEntries {
+		sourceRelPaths = append(sourceRelPaths, relPath)
+	}
+	sort.Strings(sourceRelPaths)
+
+	for _, relPath := range sourceRelPaths {
+		sourceEntry := sourceEntries[relPath]
+		destEntry := destEntries[relPath]
+
+		action := model.SyncAction{
+			RelativePath: relPath,
+			Type:         string(sourceEntry.Type),
+			Size:         sourceEntry.Size,
+		}
+
+		switch {
+		case destEntry == nil:
+			if sourceEntry.IsDir() {
+				action.Action = syncActionCreateDir
+			} else {
+				action.Action = syncActionCopy
+			}
+		case sourceEntry.Type != destEntry.Type:
+			action.Action = syncActionConflict
+			action.Error = fmt.Sprintf("destination is a %s, but source is a %s", destEntry.Type, sourceEntry.Type)
+		case sourceEntry.IsDir():
+			unchanged++
+			continue
+		default:
+			changed, err := t.isChanged(fs, sourceEntry, destEntry, compareMode)
+			if err != nil {
+				return nil, 0, err
+			}
+
+			if !changed {
+				unchanged++
+				continue
+			}
+
+			action.Action = syncActionUpdate
+		}
+
+		actions = append(actions, action)
+	}
+
+	if deleteExtras {
+		destRelPaths := make([]string, 0, len(destEntries))
+		for relPath := range destEntries {
+			if _, ok := sourceEntries[relPath]; !ok {
+				destRelPaths = append(destRelPaths, relPath)
+			}
+		}
+		sort.Strings(destRelPaths)
+
+		deletedDir := ""
+		for _, relPath := range destRelPaths {
+			// entries under a deleted directory are removed with it
+			if len(deletedDir) > 0 && strings.HasPrefix(relPath, deletedDir+"/") {
+				continue
+			}
+
+			destEntry := destEntries[relPath]
+			if destEntry.IsDir() {
+				deletedDir = relPath
+			}
+
+			actions = append(actions, model.SyncAction{
+				RelativePath: relPath,
+				Action:       syncActionDelete,
+				Type:         string(destEntry.Type),
+				Size:         destEntry.Size,
+			})
+		}
+	}
+
+	return actions, unchanged, nil
+}
+
+func (t *Sync) isChanged(fs *irodsclient_fs.FileSystem, sourceEntry *irodsclient_fs.Entry, destEntry *irodsclient_fs.Entry, compareMode SyncCompareMode) (bool, error) {
+	if sourceEntry.Size != destEntry.Size {
+		return true, nil
+	}
+
+	switch compareMode {
+	case SyncCompareSize:
+		return false, nil
+	case SyncCompareChecksum:
+		var err error
+
+		sourceChecksum := irods_common.GetEntryChecksum(sourceEntry)
+		if len(sourceChecksum) == 0 {
+			sourceChecksum, err = irods_common.GetDataObjectChecksum(fs, sourceEntry.Path)
+			if err != nil {
+				return false, err
+			}
+		}
+
+		destChecksum := irods_common.GetEntryChecksum(destEntry)
+		if len(destChecksum) == 0 {
+			destChecksum, err = irods_common.GetDataObjectChecksum(fs, destEntry.Path)
+			if err != nil {
+				return false, err
+			}
+		}
+
+		return !irods_common.IsSameChecksum(sourceChecksum, destChecksum), nil
+	default:
+		// copies get a newer modification time than their source
+		return sourceEntry.ModifyTime.Unix() > destEntry.ModifyTime.Unix(), nil
+	}
+}
+
+func (t *Sync) runActions(ctx context.Context, fs *irodsclient_fs.FileSystem, sourcePath string, destPath string, actions []model.SyncAction, progress *irods_common.ProgressReporter, args *SyncInputArgs) {
+	// directories first, in order
+	copyActionIndices := []int{}
+	deleteActionIndices := []int{}
+	for idx := range actions {
+		action := &actions[idx]
+		switch action.Action {
+		case syncActionCreateDir:
+			if ctx.Err() != nil {
+				action.Error = ctx.Err().Error()
+				continue
+			}
+
+			err := fs.MakeDir(path.Join(destPath, action.RelativePath), true)
+			if err != nil {
+				action.Error = err.Error()
+			}
+			progress.Add(1, fmt.Sprintf("created directory %q", action.RelativePath))
+		case syncActionCopy, syncActionUpdate:
+			copyActionIndices = append(copyActionIndices, idx)
+		case syncActionDelete:
+			deleteActionIndices = append(deleteActionIndices, idx)
+		}
+	}
+
+	// copy files in parallel
+	irods_common.RunParallel(len(copyActionIndices), irods_common.GetParallelism(args.Parallelism), func(jobIdx int) {
+		action := &actions[copyActionIndices[jobIdx]]
+		if ctx.Err() != nil {
+			action.Error = ctx.Err().Error()
+			return
+		}
+
+		sourceFilePath := path.Join(sourcePath, action.RelativePath)
+		destFilePath := path.Join(destPath, action.RelativePath)
+		err := fs.CopyFileToFile(sourceFilePath, destFilePath, true)
+		if err != nil {
+			action.Error = err.Error()
+		}
+		progress.Add(1, fmt.Sprintf("copied %q", action.RelativePath))
+	})
+
+	// delete extras
+	for _, idx := range deleteActionIndices {
+		action := &actions[idx]
+		if ctx.Err() != nil {
+			action.Error = ctx.Err().Error()
+			continue
+		}
+
+		destEntryPath := path.Join(destPath, action.RelativePath)
+
+		var err error
+		if action.Type == string(irodsclient_fs.DirectoryEntry) {
+			err = fs.RemoveDir(destEntryPath, true, true)
+		} else {
+			err = fs.RemoveFile(destEntryPath, true)
+		}
+
+		if err != nil {
+			action.Error = err.Error()
+		}
+		progress.Add(1, fmt.Sprintf("deleted %q", action.RelativePath))
+	}
+}
+
+func (t *Sync) syncAVUs(ctx context.Context, fs *irodsclient_fs.FileSystem, sourcePath string, destPath string, sourceEntries map[string]*irodsclient_fs.Entry, actions []model.SyncAction) {
+	failedActions := map[string]*model.SyncAction{}
+	for idx := range actions {
+		action := &actions[idx]
+		if len(action.Error) > 0 || action.Action == syncActionConflict {
+			failedActions[action.RelativePath] = action
+		}
+	}
+
+	relPaths := make([]string, 0, len(sourceEntries)+1)
+	relPaths = append(relPaths, ".")
+	for relPath := range sourceEntries {
+		if _, ok := failedActions[relPath]; !ok {
+			relPaths = append(relPaths, relPath)
+		}
+	}
+
+	errorMutex := sync.Mutex{}
+	irods_common.RunParallel(len(relPaths), irods_common.GetDefaultTransferThreadNum(), func(idx int) {
+		if ctx.Err() != nil {
+			return
+		}
+
+		relPath := relPaths[idx]
+		err := irods_common.SyncMetadata(fs, path.Join(sourcePath, relPath), path.Join(destPath, relPath))
+		if err != nil {
+			errorMutex.Lock()
+			defer errorMutex.Unlock()
+
+			for actionIdx := range actions {
+				if actions[actionIdx].RelativePath == relPath {
+					actions[actionIdx].Error = err.Error()
+					return
+				}
+			}
+		}
+	})
+}
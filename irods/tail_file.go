@@ -0,0 +1,401 @@
+package irods
+
+import (
+	"bytes"
+	"context"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"hash/crc32"
+	"io"
+
+	"github.com/cockroachdb/errors"
+	irodsclient_fs "github.com/cyverse/go-irodsclient/fs"
+	"github.com/cyverse/irods-mcp-server/common"
+	irods_common "github.com/cyverse/irods-mcp-server/irods/common"
+	"github.com/cyverse/irods-mcp-server/irods/model"
+	"github.com/google/jsonschema-go/jsonschema"
+	"github.com/modelcontextprotocol/go-sdk/mcp"
+)
+
+const (
+	TailFileName = irods_common.IRODSAPIPrefix + "tail_file"
+
+	tailFileLinesDefault    int   = 20
+	tailFileMaxLines        int   = 1000
+	tailFileMaxBytesDefault int64 = 64 * 1024 // 64KB
+	tailFileReadSize        int64 = 64 * 1024 // 64KB
+	tailFileCheckSize       int64 = 64        // bytes before the cursor offset to detect rewrites
+)
+
+// tail file statuses
+const (
+	TailFileStatusInitial   string = "initial"
+	TailFileStatusAppended  string = "appended"
+	TailFileStatusUnchanged string = "unchanged"
+	TailFileStatusTruncated string = "truncated"
+	TailFileStatusReplaced  string = "replaced"
+	TailFileStatusRewritten string = "rewritten"
+)
+
+type TailFileInputArgs struct {
+	Path     string `json:"path"`
+	Lines    int    `json:"lines,omitempty"`
+	Cursor   string `json:"cursor,omitempty"`
+	MaxBytes int64  `json:"max_bytes,omitempty"`
+}
+
+// tailCursor is the position read up to in a data object, encoded as an opaque string
+type tailCursor struct {
+	Path   string `json:"p"`
+	ID     int64  `json:"i"`
+	Offset int64  `json:"o"`
+	Check  string `json:"c,omitempty"`
+}
+
+func (cursor *tailCursor) encode() string {
+	cursorJSON, _ := json.Marshal(cursor)
+	return base64.RawURLEncoding.EncodeToString(cursorJSON)
+}
+
+func decodeTailCursor(cursorString string) (*tailCursor, error) {
+	cursorJSON, err := base64.RawURLEncoding.DecodeString(cursorString)
+	if err != nil {
+		return nil, errors.Wrapf(err, "invalid cursor")
+	}
+
+	cursor := &tailCursor{}
+	err = json.Unmarshal(cursorJSON, cursor)
+	if err != nil {
+		return nil, errors.Wrapf(err, "invalid cursor")
+	}
+
+	return cursor, nil
+}
+
+type TailFile struct {
+	mcpServer *IRODSMCPServer
+	config    *common.Config
+}
+
+func NewTailFile(svr *IRODSMCPServer) ToolAPI {
+	return &TailFile{
+		mcpServer: svr,
+		config:    svr.GetConfig(),
+	}
+}
+
+func (t *TailFile) GetName() string {
+	return TailFileName
+}
+
+func (t *TailFile) GetDescription() string {
+	return `Read the last lines of a text file (data-object), such as a log file that is still being written, with the specified path.
+	The specified path must be an iRODS path. Returns the content with a cursor in JSON format.
+	Pass the cursor to a later call to read only the content appended since then. If the file is truncated or replaced, the last lines are returned again with the status.`
+}
+
+func (t *TailFile) GetTool() *mcp.Tool {
+	return &mcp.Tool{
+		Name:        t.GetName(),
+		Description: t.GetDescription(),
+		InputSchema: &jsonschema.Schema{
+			Type: "object",
+			Properties: map[string]*jsonschema.Schema{
+				"path": {
+					Type:        "string",
+					Description: "The path to the file (data-object) to read.",
+				},
+				"lines": {
+					Type:        "number",
+					Description: fmt.Sprintf("The number of lines to read from the end of the file when no cursor is given or the file has changed. Maximum is %d. Default is %d.", tailFileMaxLines, tailFileLinesDefault),
+					Default:     json.RawMessage(fmt.Sprintf("%d", tailFileLinesDefault)),
+				},
+				"cursor": {
+					Type:        "string",
+					Description: "The cursor returned by a previous call, to read only the content appended since then.",
+				},
+				"max_bytes": {
+					Type:        "number",
+					Description: fmt.Sprintf("The maximum number of bytes to return. If more content is appended, the cursor points to the rest. Maximum is %d bytes. Default is %d bytes.", irods_common.MaxInlineSize, tailFileMaxBytesDefault),
+					Default:     json.RawMessage(fmt.Sprintf("%d", tailFileMaxBytesDefault)),
+				},
+			},
+			Required: []string{"path"},
+		},
+	}
+}
+
+func (t *TailFile) GetHandler() mcp.ToolHandler {
+	return t.Handler
+}
+
+func (t *TailFile) GetAccessiblePaths(authValue *common.AuthValue) []string {
+	account, err := t.mcpServer.GetIRODSAccountFromAuthValue(authValue)
+	if err != nil {
+		return []string{}
+	}
+
+	homePath := irods_common.GetHomePath(t.config, account)
+	sharedPath := irods_common.GetSharedPath(t.config, account)
+
+	paths := []string{
+		sharedPath + "/*",
+	}
+
+	if !account.IsAnonymousUser() {
+		paths = append(paths, homePath)
+		paths = append(paths, homePath+"/*")
+	}
+
+	return paths
+}
+
+func (t *TailFile) Handler(ctx context.Context, request *mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	// arguments
+	args := TailFileInputArgs{}
+	err := irods_common.MarshalInputArguments(t.GetTool(), request, &args)
+	if err != nil {
+		outputErr := errors.Wrapf(err, "failed to marshal input arguments")
+		return irods_common.ToolErrorResult(outputErr), nil
+	}
+
+	if args.Lines <= 0 {
+		args.Lines = tailFileLinesDefault
+	} else if args.Lines > tailFileMaxLines {
+		args.Lines = tailFileMaxLines
+	}
+
+	if args.MaxBytes <= 0 {
+		args.MaxBytes = tailFileMaxBytesDefault
+	} else if args.MaxBytes > irods_common.MaxInlineSize {
+		args.MaxBytes = irods_common.MaxInlineSize
+	}
+
+	var cursor *tailCursor
+	if len(args.Cursor) > 0 {
+		cursor, err = decodeTailCursor(args.Cursor)
+		if err != nil {
+			return irods_common.ToolErrorResult(err), nil
+		}
+	}
+
+	// auth
+	authValue, err := common.GetAuthValue(ctx)
+	if err != nil {
+		outputErr := errors.Wrapf(err, "failed to get auth value")
+		return irods_common.ToolErrorResult(outputErr), nil
+	}
+
+	// make a irods filesystem client
+	fs, err := t.mcpServer.GetIRODSFSClientFromAuthValue(&authValue)
+	if err != nil {
+		outputErr := errors.Wrapf(err, "failed to create a irods fs client")
+		return irods_common.ToolErrorResult(outputErr), nil
+	}
+
+	irodsPath := irods_common.MakeIRODSPath(t.config, fs.GetAccount(), args.Path)
+
+	// check permission
+	if !irods_common.IsAccessAllowed(irodsPath, t.GetAccessiblePaths(&authValue)) {
+		outputErr := errors.Newf("%q request is not permitted for path %q", t.GetName(), irodsPath)
+		return irods_common.ToolErrorResult(outputErr), nil
+	}
+
+	if cursor != nil && cursor.Path != irodsPath {
+		outputErr := errors.Newf("cursor is for path %q, not %q", cursor.Path, irodsPath)
+		return irods_common.ToolErrorResult(outputErr), nil
+	}
+
+	content, err := t.tailFile(fs, irodsPath, cursor, &args)
+	if err != nil {
+		outputErr := errors.Wrapf(err, "failed to tail file (data-object) %q", irodsPath)
+		return irods_common.ToolErrorResult(outputErr), nil
+	}
+
+	return irods_common.ToolJSONResult(*content)
+}
+
+func (t *TailFile) tailFile(fs *irodsclient_fs.FileSystem, irodsPath string, cursor *tailCursor, args *TailFileInputArgs) (*model.TailFileOutput, error) {
+	// the catalog is read directly, as the cached entry does not follow a growing file
+	dataObject, err := irods_common.GetDataObjectNoCache(fs, irodsPath)
+	if err != nil {
+		return nil, err
+	}
+
+	handle, err := fs.OpenFile(irodsPath, "", "r")
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to open file %q", irodsPath)
+	}
+	defer handle.Close()
+
+	// the size in the catalog is updated only when the writer closes the file, seek to the end to get the current size
+	size, err := handle.Seek(0, io.SeekEnd)
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to seek to the end of file %q", irodsPath)
+	}
+
+	output := &model.TailFileOutput{
+		Path:        irodsPath,
+		ResourceURI: irods_common.MakeResourceURI(irodsPath),
+		Size:        size,
+	}
+
+	status := TailFileStatusInitial
+	if cursor != nil {
+		switch {
+		case cursor.ID != dataObject.ID:
+			status = TailFileStatusReplaced
+		case size < cursor.Offset:
+			status = TailFileStatusTruncated
+		default:
+			check, err := t.makeCheck(handle, cursor.Offset)
+			if err != nil {
+				return nil, err
+			}
+
+			if check != cursor.Check {
+				status = TailFileStatusRewritten
+			} else if size == cursor.Offset {
+				status = TailFileStatusUnchanged
+			} else {
+				status = TailFileStatusAppended
+			}
+		}
+	}
+
+	output.Status = status
+
+	offset := size
+	readLength := int64(0)
+	switch status {
+	case TailFileStatusUnchanged:
+		offset = cursor.Offset
+	case TailFileStatusAppended:
+		offset = cursor.Offset
+		readLength = size - offset
+		if readLength > args.MaxBytes {
+			readLength = args.MaxBytes
+			output.Truncated = true
+		}
+	default:
+		offset, err = t.findTailOffset(handle, size, args.Lines, args.MaxBytes)
+		if err != nil {
+			return nil, err
+		}
+		readLength = size - offset
+	}
+
+	content := make([]byte, readLength)
+	if readLength > 0 {
+		n, err := handle.ReadAt(content, offset)
+		if err != nil && err != io.EOF {
+			return nil, errors.Wrapf(err, "failed to read file %q at offset %d", irodsPath, offset)
+		}
+		content = content[:n]
+	}
+
+	chunk, err := irods_common.DecodeTextChunk(content, offset, irods_common.TextEncodingUTF8, offset+int64(len(content)) >= size)
+	if err != nil {
+		return nil, err
+	}
+
+	output.Offset = chunk.Offset
+	output.Content = chunk.Text
+
+	check, err := t.makeCheck(handle, chunk.NextOffset())
+	if err != nil {
+		return nil, err
+	}
+
+	nextCursor := &tailCursor{
+		Path:   irodsPath,
+		ID:     dataObject.ID,
+		Offset: chunk.NextOffset(),
+		Check:  check,
+	}
+	output.Cursor = nextCursor.encode()
+
+	return output, nil
+}
+
+// makeCheck returns a checksum of bytes before the offset to detect rewrites of content already read
+func (t *TailFile) makeCheck(handle *irodsclient_fs.FileHandle, offset int64) (string, error) {
+	checkOffset := offset - tailFileCheckSize
+	if checkOffset < 0 {
+		checkOffset = 0
+	}
+
+	buffer := make([]byte, offset-checkOffset)
+	if len(buffer) == 0 {
+		return "", nil
+	}
+
+	n, err := handle.ReadAt(buffer, checkOffset)
+	if err != nil && err != io.EOF {
+		return "", errors.Wrapf(err, "failed to read file at offset %d", checkOffset)
+	}
+
+	checksum := crc32.ChecksumIEEE(buffer[:n])
+	return hex.EncodeToString([]byte{byte(checksum >> 24), byte(checksum >> 16), byte(checksum >> 8), byte(checksum)}), nil
+}
+
+// findTailOffset reads backward from the end of the file to find the offset of the last lines, within maxBytes
+func (t *TailFile) findTailOffset(handle *irodsclient_fs.FileHandle, size int64, lines int, maxBytes int64) (int64, error) {
+	minOffset := size - maxBytes
+	if minOffset < 0 {
+		minOffset = 0
+	}
+
+	newlines := 0
+	end := size
+	buffer := make([]byte, tailFileReadSize)
+
+	for end > minOffset {
+		start := end - tailFileReadSize
+		if start < minOffset {
+			start = minOffset
+		}
+
+		chunk := buffer[:end-start]
+		n, err := handle.ReadAt(chunk, start)
+		if err != nil && err != io.EOF {
+			return 0, errors.Wrapf(err, "failed to read file at offset %d", start)
+		}
+		chunk = chunk[:n]
+
+		for idx := len(chunk) - 1; idx >= 0; idx-- {
+			if chunk[idx] != '\n' {
+				continue
+			}
+
+			// newline at the end of the file does not start a line
+			if start+int64(idx) == size-1 {
+				continue
+			}
+
+			newlines++
+			if newlines >= lines {
+				return start + int64(idx) + 1, nil
+			}
+		}
+
+		end = start
+	}
+
+	if minOffset > 0 {
+		// the last lines are longer than maxBytes, start at a line boundary if any
+		chunk := make([]byte, size-minOffset)
+		n, err := handle.ReadAt(chunk, minOffset)
+		if err != nil && err != io.EOF {
+			return 0, errors.Wrapf(err, "failed to read file at offset %d", minOffset)
+		}
+
+		if idx := bytes.IndexByte(chunk[:n], '\n'); idx >= 0 && minOffset+int64(idx)+1 < size {
+			return minOffset + int64(idx) + 1, nil
+		}
+	}
+
+	return minOffset, nil
+}
@@ -21,9 +21,11 @@ const (
 )
 
 type WriteFileInputArgs struct {
-	Path    string `json:"path"`
-	Offset  int64  `json:"offset,omitempty"`
-	Content string `json:"content"`
+	Path    string                          `json:"path"`
+	Mode    string                          `json:"mode,omitempty"`
+	Offset  int64                           `json:"offset,omitempty"`
+	Content string                          `json:"content"`
+	IfMatch *irods_common.WritePrecondition `json:"if_match,omitempty"`
 }
 
 type WriteFile struct {
@@ -43,12 +45,18 @@ func (t *WriteFile) GetName() string {
 }
 
 func (t *WriteFile) GetDescription() string {
-	return `Write the partial content to a file (data-object) with the specified path and offset.
+	return `Write content to a file (data-object) with the specified path and write mode.
 	The specified path must be an iRODS path.
-	If the file is too large to be displayed inline, use the WebDAV URI to access it.`
+	Use 'if_match' to make sure the file has not been changed by others since it was last read.
+	Returns the resulting size and checksum of the file.`
 }
 
 func (t *WriteFile) GetTool() *mcp.Tool {
+	modeEnum := []interface{}{}
+	for _, mode := range irods_common.GetWriteModes() {
+		modeEnum = append(modeEnum, string(mode))
+	}
+
 	return &mcp.Tool{
 		Name:        t.GetName(),
 		Description: t.GetDescription(),
@@ -59,15 +67,40 @@ func (t *WriteFile) GetTool() *mcp.Tool {
 					Type:        "string",
 					Description: "The path to the file (data-object) to write to.",
 				},
+				"mode": {
+					Type: "string",
+					Enum: modeEnum,
+					Description: `The write mode. Default is 'overwrite'.
+					'create_new' creates a new file and fails if the file already exists.
+					'overwrite' replaces the entire content of the file.
+					'append' writes the content at the end of the file.
+					'patch' overwrites bytes at the offset and keeps the rest of the file.
+					'truncate' discards the content after the offset, then writes the content at the offset.`,
+					Default: json.RawMessage(`"overwrite"`),
+				},
 				"offset": {
-					Type:        "string",
-					Description: "The offset to start writing the file from. Default is 0.",
+					Type:        "number",
+					Description: "The offset to start writing the file from. Only used in 'patch' and 'truncate' modes. Must not be larger than the file size. Default is 0.",
 					Default:     json.RawMessage("0"),
 				},
 				"content": {
 					Type:        "string",
 					Description: fmt.Sprintf("The Base64-encoded content to write to the file (data-object). Maximum size is %d bytes.", irods_common.MaxInlineSize),
 				},
+				"if_match": {
+					Type:        "object",
+					Description: "Preconditions on the existing file. The write fails if any of the given conditions does not match the current file.",
+					Properties: map[string]*jsonschema.Schema{
+						"checksum": {
+							Type:        "string",
+							Description: "The expected iRODS checksum of the file, e.g., 'sha2:...'.",
+						},
+						"modify_time": {
+							Type:        "string",
+							Description: "The expected modification time of the file in RFC3339 format.",
+						},
+					},
+				},
 			},
 			Required: []string{"path", "content"},
 		},
@@ -130,31 +163,62 @@ func (t *WriteFile) Handler(ctx context.Context, request *mcp.CallToolRequest) (
 		return irods_common.ToolErrorResult(outputErr), nil
 	}
 
+	writeMode := irods_common.WriteMode(args.Mode)
+	if len(writeMode) == 0 {
+		writeMode = irods_common.WriteModeOverwrite
+	}
+
 	// Get file info
+	var entry *irodsclient_fs.Entry
 	fileSize := int64(0)
-	entry, err := fs.Stat(irodsPath)
+	statEntry, err := fs.Stat(irodsPath)
 	if err != nil {
 		if !irodsclient_types.IsFileNotFoundError(err) {
 			outputErr := errors.Wrapf(err, "failed to stat file info for %q", irodsPath)
 			return irods_common.ToolErrorResult(outputErr), nil
 		}
 	} else {
-		if entry.IsDir() {
+		if statEntry.IsDir() {
 			outputErr := errors.Newf("path %q is a directory (collection)", irodsPath)
 			return irods_common.ToolErrorResult(outputErr), nil
 		}
 
+		entry = statEntry
 		fileSize = entry.Size
 	}
 
 	inputOffset := args.Offset
-	if inputOffset < 0 {
-		inputOffset = 0
-	} else if inputOffset >= fileSize {
-		inputOffset = fileSize
+	switch writeMode {
+	case irods_common.WriteModePatch, irods_common.WriteModeTruncate:
+		if entry == nil {
+			outputErr := errors.Newf("file %q does not exist, %q mode requires an existing file", irodsPath, writeMode)
+			return irods_common.ToolErrorResult(outputErr), nil
+		}
+
+		if inputOffset < 0 || inputOffset > fileSize {
+			outputErr := errors.Newf("offset %d is out of range, file %q is %d bytes", inputOffset, irodsPath, fileSize)
+			return irods_common.ToolErrorResult(outputErr), nil
+		}
+	case irods_common.WriteModeCreateNew:
+		if entry != nil {
+			outputErr := errors.Newf("file %q already exists", irodsPath)
+			return irods_common.ToolErrorResult(outputErr), nil
+		}
+		fallthrough
+	default:
+		if inputOffset != 0 {
+			outputErr := errors.Newf("offset is not allowed in %q mode", writeMode)
+			return irods_common.ToolErrorResult(outputErr), nil
+		}
+	}
+
+	// check preconditions
+	err = irods_common.CheckWritePrecondition(fs, irodsPath, args.IfMatch)
+	if err != nil {
+		return irods_common.ToolErrorResult(err), nil
 	}
 
-	content, err := t.writeFile(fs, irodsPath, inputOffset, args.Content)
+	content, err := t.writeFile(fs, irodsPath, writeMode, inputOffset, args.Content)
 	if err != nil {
 		outputErr := errors.Wrapf(err, "failed to write file (data-object) for %q", irodsPath)
 		return irods_common.ToolErrorResult(outputErr), nil
@@ -163,22 +227,44 @@ func (t *WriteFile) Handler(ctx context.Context, request *mcp.CallToolRequest) (
 	return irods_common.ToolJSONResult(*content)
 }
 
-func (t *WriteFile) writeFile(fs *irodsclient_fs.FileSystem, path string, offset int64, inputContent string) (*model.WriteFileOutput, error) {
+func (t *WriteFile) writeFile(fs *irodsclient_fs.FileSystem, path string, mode irods_common.WriteMode, offset int64, inputContent string) (*model.WriteFileOutput, error) {
 	byteContent, err := base64.StdEncoding.DecodeString(inputContent)
 	if err != nil {
 		return nil, errors.Wrapf(err, "failed to decode base64 content for file (data-object) %q", path)
 	}
 
 	// write the file content
-	err = irods_common.WriteDataObject(fs, path, offset, byteContent)
+	err = irods_common.WriteDataObject(fs, path, mode, offset, byteContent)
 	if err != nil {
 		return nil, errors.Wrapf(err, "failed to write file (data-object) %q", path)
 	}
 
+	return makeWriteFileOutput(fs, path, mode, offset, len(byteContent))
+}
+
+func makeWriteFileOutput(fs *irodsclient_fs.FileSystem, path string, mode irods_common.WriteMode, offset int64, bytesWritten int) (*model.WriteFileOutput, error) {
+	entry, err := fs.Stat(path)
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to stat file info for %q", path)
+	}
+
+	checksum, err := irods_common.GetDataObjectChecksum(fs, path)
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to get checksum of file (data-object) %q", path)
+	}
+
+	if mode == irods_common.WriteModeAppend {
+		offset = entry.Size - int64(bytesWritten)
+	}
+
 	fileWriteOutput := &model.WriteFileOutput{
 		Path:         path,
+		Mode:         string(mode),
 		Offset:       offset,
-		BytesWritten: len(byteContent),
+		BytesWritten: bytesWritten,
+		Size:         entry.Size,
+		Checksum:     checksum,
+		ModifyTime:   entry.ModifyTime,
 	}
 
 	return fileWriteOutput, nil
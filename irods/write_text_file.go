@@ -0,0 +1,252 @@
+package irods
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+
+	"github.com/cockroachdb/errors"
+	irodsclient_fs "github.com/cyverse/go-irodsclient/fs"
+	irodsclient_types "github.com/cyverse/go-irodsclient/irods/types"
+	"github.com/cyverse/irods-mcp-server/common"
+	irods_common "github.com/cyverse/irods-mcp-server/irods/common"
+	"github.com/cyverse/irods-mcp-server/irods/model"
+	"github.com/google/jsonschema-go/jsonschema"
+	"github.com/modelcontextprotocol/go-sdk/mcp"
+)
+
+const (
+	WriteTextFileName = irods_common.IRODSAPIPrefix + "write_text_file"
+)
+
+type WriteTextFileInputArgs struct {
+	Path             string                          `json:"path"`
+	Mode             string                          `json:"mode,omitempty"`
+	Content          string                          `json:"content"`
+	Encoding         string                          `json:"encoding,omitempty"`
+	Newline          string                          `json:"newline,omitempty"`
+	TrailingNewline  string                          `json:"trailing_newline,omitempty"`
+	CreateParentDirs bool                            `json:"create_parent_dirs,omitempty"`
+	IfMatch          *irods_common.WritePrecondition `json:"if_match,omitempty"`
+}
+
+type WriteTextFile struct {
+	mcpServer *IRODSMCPServer
+	config    *common.Config
+}
+
+func NewWriteTextFile(svr *IRODSMCPServer) ToolAPI {
+	return &WriteTextFile{
+		mcpServer: svr,
+		config:    svr.GetConfig(),
+	}
+}
+
+func (t *WriteTextFile) GetName() string {
+	return WriteTextFileName
+}
+
+func (t *WriteTextFile) GetDescription() string {
+	return `Write plain text to a file (data-object) with the specified path.
+	The specified path must be an iRODS path.
+	The content is given as plain text, not Base64-encoded, and is converted to the target encoding before writing.
+	Returns the resulting size and checksum of the file.`
+}
+
+func (t *WriteTextFile) GetTool() *mcp.Tool {
+	return &mcp.Tool{
+		Name:        t.GetName(),
+		Description: t.GetDescription(),
+		InputSchema: &jsonschema.Schema{
+			Type: "object",
+			Properties: map[string]*jsonschema.Schema{
+				"path": {
+					Type:        "string",
+					Description: "The path to the file (data-object) to write to.",
+				},
+				"mode": {
+					Type: "string",
+					Enum: []interface{}{
+						string(irods_common.WriteModeCreateNew),
+						string(irods_common.WriteModeOverwrite),
+						string(irods_common.WriteModeAppend),
+					},
+					Description: "The write mode. 'create_new' fails if the file already exists, 'overwrite' replaces the entire content, and 'append' writes at the end of the file. Default is 'overwrite'.",
+					Default:     json.RawMessage(`"overwrite"`),
+				},
+				"content": {
+					Type:        "string",
+					Description: fmt.Sprintf("The plain text to write to the file (data-object). Maximum size is %d bytes.", irods_common.MaxInlineSize),
+				},
+				"encoding": {
+					Type:        "string",
+					Description: "The text encoding of the file, such as 'utf-8', 'utf-16le', 'utf-16be', 'iso-8859-1', or 'windows-1252'. Default is 'utf-8'.",
+					Default:     json.RawMessage(fmt.Sprintf("%q", irods_common.DefaultTextEncoding)),
+				},
+				"newline": {
+					Type:        "string",
+					Enum:        []interface{}{string(irods_common.NewlineStyleKeep), string(irods_common.NewlineStyleLF), string(irods_common.NewlineStyleCRLF)},
+					Description: "Converts line endings to LF or CRLF. Default is 'keep', which writes line endings as given.",
+					Default:     json.RawMessage(`"keep"`),
+				},
+				"trailing_newline": {
+					Type:        "string",
+					Enum:        []interface{}{string(irods_common.TrailingNewlineKeep), string(irods_common.TrailingNewlineEnsure), string(irods_common.TrailingNewlineRemove)},
+					Description: "Adds ('ensure') or removes ('remove') a newline at the end of the content. Default is 'keep'.",
+					Default:     json.RawMessage(`"keep"`),
+				},
+				"create_parent_dirs": {
+					Type:        "boolean",
+					Description: "Set to true to create missing parent directories (collections). Default is false.",
+					Default:     json.RawMessage("false"),
+				},
+				"if_match": {
+					Type:        "object",
+					Description: "Preconditions on the existing file. The write fails if any of the given conditions does not match the current file.",
+					Properties: map[string]*jsonschema.Schema{
+						"checksum": {
+							Type:        "string",
+							Description: "The expected iRODS checksum of the file, e.g., 'sha2:...'.",
+						},
+						"modify_time": {
+							Type:        "string",
+							Description: "The expected modification time of the file in RFC3339 format.",
+						},
+					},
+				},
+			},
+			Required: []string{"path", "content"},
+		},
+	}
+}
+
+func (t *WriteTextFile) GetHandler() mcp.ToolHandler {
+	return t.Handler
+}
+
+func (t *WriteTextFile) GetAccessiblePaths(authValue *common.AuthValue) []string {
+	account, err := t.mcpServer.GetIRODSAccountFromAuthValue(authValue)
+	if err != nil {
+		return []string{}
+	}
+
+	homePath := irods_common.GetHomePath(t.config, account)
+	sharedPath := irods_common.GetSharedPath(t.config, account)
+
+	paths := []string{
+		sharedPath + "/*",
+	}
+
+	if !account.IsAnonymousUser() {
+		paths = append(paths, homePath)
+		paths = append(paths, homePath+"/*")
+	}
+
+	return paths
+}
+
+func (t *WriteTextFile) Handler(ctx context.Context, request *mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	// arguments
+	args := WriteTextFileInputArgs{}
+	err := irods_common.MarshalInputArguments(t.GetTool(), request, &args)
+	if err != nil {
+		outputErr := errors.Wrapf(err, "failed to marshal input arguments")
+		return irods_common.ToolErrorResult(outputErr), nil
+	}
+
+	// auth
+	authValue, err := common.GetAuthValue(ctx)
+	if err != nil {
+		outputErr := errors.Wrapf(err, "failed to get auth value")
+		return irods_common.ToolErrorResult(outputErr), nil
+	}
+
+	// make a irods filesystem client
+	fs, err := t.mcpServer.GetIRODSFSClientFromAuthValue(&authValue)
+	if err != nil {
+		outputErr := errors.Wrapf(err, "failed to create a irods fs client")
+		return irods_common.ToolErrorResult(outputErr), nil
+	}
+
+	irodsPath := irods_common.MakeIRODSPath(t.config, fs.GetAccount(), args.Path)
+
+	// check permission
+	if !irods_common.IsAccessAllowed(irodsPath, t.GetAccessiblePaths(&authValue)) {
+		outputErr := errors.Newf("%q request is not permitted for path %q", t.GetName(), irodsPath)
+		return irods_common.ToolErrorResult(outputErr), nil
+	}
+
+	writeMode := irods_common.WriteMode(args.Mode)
+	if len(writeMode) == 0 {
+		writeMode = irods_common.WriteModeOverwrite
+	}
+
+	// Get file info
+	var entry *irodsclient_fs.Entry
+	statEntry, err := fs.Stat(irodsPath)
+	if err != nil {
+		if !irodsclient_types.IsFileNotFoundError(err) {
+			outputErr := errors.Wrapf(err, "failed to stat file info for %q", irodsPath)
+			return irods_common.ToolErrorResult(outputErr), nil
+		}
+	} else {
+		if statEntry.IsDir() {
+			outputErr := errors.Newf("path %q is a directory (collection)", irodsPath)
+			return irods_common.ToolErrorResult(outputErr), nil
+		}
+
+		entry = statEntry
+	}
+
+	if writeMode == irods_common.WriteModeCreateNew && entry != nil {
+		outputErr := errors.Newf("file %q already exists", irodsPath)
+		return irods_common.ToolErrorResult(outputErr), nil
+	}
+
+	// check preconditions
+	err = irods_common.CheckWritePrecondition(fs, irodsPath, args.IfMatch)
+	if err != nil {
+		return irods_common.ToolErrorResult(err), nil
+	}
+
+	if entry == nil && args.CreateParentDirs {
+		parentPath := irods_common.GetIRODSPathDirname(irodsPath)
+		err = fs.MakeDir(parentPath, true)
+		if err != nil {
+			outputErr := errors.Wrapf(err, "failed to make parent directory (collection) %q", parentPath)
+			return irods_common.ToolErrorResult(outputErr), nil
+		}
+	}
+
+	content, err := t.writeTextFile(fs, irodsPath, writeMode, &args)
+	if err != nil {
+		outputErr := errors.Wrapf(err, "failed to write text file (data-object) for %q", irodsPath)
+		return irods_common.ToolErrorResult(outputErr), nil
+	}
+
+	return irods_common.ToolJSONResult(*content)
+}
+
+func (t *WriteTextFile) writeTextFile(fs *irodsclient_fs.FileSystem, path string, mode irods_common.WriteMode, args *WriteTextFileInputArgs) (*model.WriteFileOutput, error) {
+	newlineStyle := irods_common.NewlineStyle(args.Newline)
+
+	text := irods_common.NormalizeNewlines(args.Content, newlineStyle)
+	text = irods_common.ApplyTrailingNewlinePolicy(text, irods_common.TrailingNewlinePolicy(args.TrailingNewline), newlineStyle)
+
+	byteContent, err := irods_common.EncodeText(text, args.Encoding)
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to encode text for file (data-object) %q", path)
+	}
+
+	if int64(len(byteContent)) > irods_common.MaxInlineSize {
+		return nil, errors.Newf("content is too large (%d bytes), maximum size is %d bytes", len(byteContent), irods_common.MaxInlineSize)
+	}
+
+	// write the file content
+	err = irods_common.WriteDataObject(fs, path, mode, 0, byteContent)
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to write file (data-object) %q", path)
+	}
+
+	return makeWriteFileOutput(fs, path, mode, 0, len(byteContent))
+}